@@ -0,0 +1,234 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressions of the generated data:
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var CompressionChoices = []string{CompressionNone, CompressionGzip, CompressionZstd}
+
+// CompressionBlockSize is the size of the blocks of data compressed in
+// parallel.
+const CompressionBlockSize = 1 << 20
+
+// CompressionExtension returns the file name extension of data compressed
+// with the given compression.
+func CompressionExtension(compression string) string {
+	switch compression {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// NewCompressingWriter returns a writer compressing the data written to it
+// into w. The data is cut in blocks of CompressionBlockSize bytes,
+// compressed by up to GOMAXPROCS goroutines, and written in order as
+// concatenated gzip members or zstd frames, which gunzip, zstd and the Go
+// decoders read as a single stream.
+// Closing the writer flushes it, but does not close w.
+func NewCompressingWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	var compress func(dst, src []byte) ([]byte, error)
+	switch compression {
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	case CompressionGzip:
+		compress = gzipBlock
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(runtime.GOMAXPROCS(0)))
+		if err != nil {
+			return nil, err
+		}
+		compress = func(dst, src []byte) ([]byte, error) {
+			return enc.EncodeAll(src, dst), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression '%s' (choices: %s)", compression, strings.Join(CompressionChoices, ", "))
+	}
+
+	c := &compressingWriter{
+		w:        w,
+		compress: compress,
+		// the queue bounds the number of blocks in flight:
+		pending: make(chan chan []byte, runtime.GOMAXPROCS(0)),
+		done:    make(chan struct{}),
+	}
+	go c.writeBlocks()
+	return c, nil
+}
+
+// NewDecompressingReader returns a reader of the data of r, decompressed if
+// it starts like gzip or zstd data. It also returns the compression found.
+func NewDecompressingReader(r io.Reader) (io.ReadCloser, string, error) {
+	br := bufio.NewReaderSize(r, 1<<20)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, "", err
+		}
+		return zr, CompressionGzip, nil
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, "", err
+		}
+		return zr.IOReadCloser(), CompressionZstd, nil
+	default:
+		return ioutil.NopCloser(br), CompressionNone, nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+func gzipBlock(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	zw := gzipWriterPool.Get().(*gzip.Writer)
+	defer gzipWriterPool.Put(zw)
+	zw.Reset(buf)
+	if _, err := zw.Write(src); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var compressionBlockPool = sync.Pool{
+	New: func() interface{} {
+		return make([]byte, 0, CompressionBlockSize)
+	},
+}
+
+// compressingWriter compresses blocks in parallel, writing them in order.
+type compressingWriter struct {
+	w        io.Writer
+	compress func(dst, src []byte) ([]byte, error)
+
+	block   []byte
+	written bool
+
+	// the compressed blocks to write, in order:
+	pending chan chan []byte
+	done    chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func (c *compressingWriter) Write(p []byte) (int, error) {
+	if err := c.error(); err != nil {
+		return 0, err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		if c.block == nil {
+			c.block = compressionBlockPool.Get().([]byte)
+		}
+		k := CompressionBlockSize - len(c.block)
+		if k > len(p) {
+			k = len(p)
+		}
+		c.block = append(c.block, p[:k]...)
+		p = p[k:]
+		if len(c.block) == CompressionBlockSize {
+			c.dispatch()
+		}
+	}
+	return n, nil
+}
+
+// dispatch compresses the current block in its own goroutine.
+func (c *compressingWriter) dispatch() {
+	block := c.block
+	c.block = nil
+	c.written = true
+	result := make(chan []byte, 1)
+	c.pending <- result
+	go func() {
+		out, err := c.compress(nil, block)
+		if err != nil {
+			c.setError(err)
+		}
+		compressionBlockPool.Put(block[:0])
+		result <- out
+	}()
+}
+
+func (c *compressingWriter) writeBlocks() {
+	for result := range c.pending {
+		out := <-result
+		if c.error() != nil {
+			continue
+		}
+		if _, err := c.w.Write(out); err != nil {
+			c.setError(err)
+		}
+	}
+	close(c.done)
+}
+
+// Close compresses the last block, waits for all blocks to be written and
+// returns the first error met. Empty data still makes a valid (empty)
+// compressed stream.
+func (c *compressingWriter) Close() error {
+	if c.block != nil || !c.written {
+		if c.block == nil {
+			c.block = compressionBlockPool.Get().([]byte)
+		}
+		c.dispatch()
+	}
+	close(c.pending)
+	<-c.done
+	return c.error()
+}
+
+func (c *compressingWriter) error() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *compressingWriter) setError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
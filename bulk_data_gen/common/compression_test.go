@@ -0,0 +1,72 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDecompressingReaderRoundTrip(t *testing.T) {
+	// several compression blocks, written in pieces not aligned on them:
+	var data bytes.Buffer
+	for i := 0; data.Len() < 3*CompressionBlockSize+CompressionBlockSize/2; i++ {
+		fmt.Fprintf(&data, "cpu,hostname=host_%d usage_user=%d %d\n", i%100, i*7919%101, 1514764800000000000+int64(i))
+	}
+
+	for _, compression := range CompressionChoices {
+		var compressed bytes.Buffer
+		w, err := NewCompressingWriter(&compressed, compression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rest := data.Bytes(); len(rest) > 0; {
+			n := 100003
+			if n > len(rest) {
+				n = len(rest)
+			}
+			if _, err := w.Write(rest[:n]); err != nil {
+				t.Fatal(err)
+			}
+			rest = rest[n:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if compression != CompressionNone && compressed.Len() >= data.Len() {
+			t.Errorf("%s: %d bytes compressed to %d", compression, data.Len(), compressed.Len())
+		}
+
+		r, found, err := NewDecompressingReader(&compressed)
+		if err != nil {
+			t.Fatalf("%s: %v", compression, err)
+		}
+		if found != compression {
+			t.Errorf("compression %s detected as %s", compression, found)
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: %v", compression, err)
+		}
+		r.Close()
+		if !bytes.Equal(got, data.Bytes()) {
+			t.Errorf("%s: read %d bytes differing from the %d written", compression, len(got), data.Len())
+		}
+	}
+}
+
+func TestDecompressingReaderShortInput(t *testing.T) {
+	for _, data := range []string{"", "a", "ab\n"} {
+		r, found, err := NewDecompressingReader(bytes.NewBufferString(data))
+		if err != nil {
+			t.Fatalf("%q: %v", data, err)
+		}
+		if found != CompressionNone {
+			t.Errorf("%q detected as %s", data, found)
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil || string(got) != data {
+			t.Errorf("%q read as %q, %v", data, got, err)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package common
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
+	"time"
 )
 
 // Distribution provides an interface to model a statistical distribution.
@@ -11,30 +13,36 @@ type Distribution interface {
 	Get() float64 // should be idempotent
 }
 
+// NewRand returns the random source of the simulated entity (host, smart
+// home, ...) with the given index. Every entity gets its own, independently
+// seeded stream, so the values it generates for a given seed do not depend
+// on the order in which entities are created or advanced.
+func NewRand(seed int64, index int64) *rand.Rand {
+	// splitmix64 finalizer, to decorrelate the streams of neighbouring indexes:
+	z := uint64(seed) + uint64(index+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return rand.New(rand.NewSource(int64(z)))
+}
+
 // NormalDistribution models a normal distribution.
 type NormalDistribution struct {
 	Mean   float64
 	StdDev float64
+	Rand   *rand.Rand
 
 	value float64
 }
 
-func ND(mean, stddev float64) *NormalDistribution {
-	return &NormalDistribution{Mean: mean, StdDev: stddev}
-}
-
-// Unsynchronized random source
-var localRand = rand.New(rand.NewSource(1))
-
-// Seed uses the provided seed value to initialize the generator to a deterministic state.
-func Seed(seed int64) {
-	localRand.Seed(seed)
+func ND(r *rand.Rand, mean, stddev float64) *NormalDistribution {
+	return &NormalDistribution{Mean: mean, StdDev: stddev, Rand: r}
 }
 
 // Advance advances this distribution. Since a normal distribution is
 // stateless, this is just overwrites the internal cache value.
 func (d *NormalDistribution) Advance() {
-	d.value = localRand.NormFloat64()*d.StdDev + d.Mean
+	d.value = d.Rand.NormFloat64()*d.StdDev + d.Mean
 }
 
 // Get returns the last computed value for this distribution.
@@ -46,18 +54,19 @@ func (d *NormalDistribution) Get() float64 {
 type UniformDistribution struct {
 	Low  float64
 	High float64
+	Rand *rand.Rand
 
 	value float64
 }
 
-func UD(low, high float64) *UniformDistribution {
-	return &UniformDistribution{Low: low, High: high}
+func UD(r *rand.Rand, low, high float64) *UniformDistribution {
+	return &UniformDistribution{Low: low, High: high, Rand: r}
 }
 
 // Advance advances this distribution. Since a uniform distribution is
 // stateless, this is just overwrites the internal cache value.
 func (d *UniformDistribution) Advance() {
-	x := rand.Float64() // uniform
+	x := d.Rand.Float64() // uniform
 	x *= d.High - d.Low
 	x += d.Low
 	d.value = x
@@ -188,6 +197,89 @@ func MUDWD(step Distribution, min float64, max float64, state float64) *Monotoni
 	return &MonotonicUpDownRandomWalkDistribution{Step: step, Min: min, Max: max, State: state, direction: direction}
 }
 
+// PeriodicDistribution is a sinusoid of the time read from Clock (usually
+// the timestamp of the measurement it belongs to), plus an optional noise
+// distribution:
+//
+//	Mean + Amplitude * sin(2*Pi*t/Period + Phase) + Noise
+//
+// t is the time since the Unix epoch, so a daily period is aligned with UTC
+// days and a weekly one starts on Thursday.
+type PeriodicDistribution struct {
+	Mean      float64
+	Amplitude float64
+	Period    time.Duration
+	Phase     float64      // radians
+	Noise     Distribution // optional
+
+	Clock *time.Time
+}
+
+// NewPeriodicDistribution makes a PeriodicDistribution, checking its period
+// is positive.
+func NewPeriodicDistribution(clock *time.Time, period time.Duration, mean, amplitude, phase float64, noise Distribution) (*PeriodicDistribution, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("period must be positive, got %v", period)
+	}
+	return &PeriodicDistribution{Mean: mean, Amplitude: amplitude, Period: period, Phase: phase, Noise: noise, Clock: clock}, nil
+}
+
+// PD is like NewPeriodicDistribution, for periods known to be positive: it
+// panics otherwise.
+func PD(clock *time.Time, period time.Duration, mean, amplitude, phase float64, noise Distribution) *PeriodicDistribution {
+	d, err := NewPeriodicDistribution(clock, period, mean, amplitude, phase, noise)
+	if err != nil {
+		panic(fmt.Sprintf("logic error: %v", err))
+	}
+	return d
+}
+
+// PeakPhase returns the phase making a sinusoid of the given period peak at
+// the given offset into the period (e.g. 14h into a day).
+func PeakPhase(peak time.Duration, period time.Duration) float64 {
+	return math.Pi/2 - 2*math.Pi*float64(peak)/float64(period)
+}
+
+// Advance advances the noise; the sinusoid only depends on the clock.
+func (d *PeriodicDistribution) Advance() {
+	if d.Noise != nil {
+		d.Noise.Advance()
+	}
+}
+
+func (d *PeriodicDistribution) Get() float64 {
+	t := d.Clock.UnixNano() % int64(d.Period)
+	v := d.Mean + d.Amplitude*math.Sin(2*math.Pi*float64(t)/float64(d.Period)+d.Phase)
+	if d.Noise != nil {
+		v += d.Noise.Get()
+	}
+	return v
+}
+
+// SumDistribution is the sum of its parts, e.g. a daily and a weekly
+// periodic distribution plus a random walk.
+type SumDistribution struct {
+	Parts []Distribution
+}
+
+func SD(parts ...Distribution) *SumDistribution {
+	return &SumDistribution{Parts: parts}
+}
+
+func (d *SumDistribution) Advance() {
+	for _, p := range d.Parts {
+		p.Advance()
+	}
+}
+
+func (d *SumDistribution) Get() float64 {
+	var sum float64
+	for _, p := range d.Parts {
+		sum += p.Get()
+	}
+	return sum
+}
+
 type ConstantDistribution struct {
 	State float64
 }
@@ -204,11 +296,12 @@ type TwoStateDistribution struct {
 	Low   float64
 	High  float64
 	State float64
+	Rand  *rand.Rand
 }
 
 func (d *TwoStateDistribution) Advance() {
 	d.State = d.Low
-	if rand.Float64() > 0.5 {
+	if d.Rand.Float64() > 0.5 {
 		d.State = d.High
 	}
 }
@@ -217,11 +310,11 @@ func (d *TwoStateDistribution) Get() float64 {
 	return d.State
 }
 
-func TSD(low float64, high float64, state float64) *TwoStateDistribution {
-	return &TwoStateDistribution{Low: low, High: high, State: state}
+func TSD(r *rand.Rand, low float64, high float64, state float64) *TwoStateDistribution {
+	return &TwoStateDistribution{Low: low, High: high, State: state, Rand: r}
 }
 
-func RandChoice(choices [][]byte) []byte {
-	idx := rand.Int63n(int64(len(choices)))
+func RandChoice(r *rand.Rand, choices [][]byte) []byte {
+	idx := r.Int63n(int64(len(choices)))
 	return choices[idx]
 }
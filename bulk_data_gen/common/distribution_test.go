@@ -0,0 +1,141 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewRandDeterministic(t *testing.T) {
+	a, b := NewRand(42, 7), NewRand(42, 7)
+	for i := 0; i < 100; i++ {
+		if x, y := a.Int63(), b.Int63(); x != y {
+			t.Fatalf("draw %d: %d != %d for the same seed and index", i, x, y)
+		}
+	}
+}
+
+func TestNewRandIndependentStreams(t *testing.T) {
+	const n = 10
+	const draws = 50
+
+	// streams drawn one after the other, in index order:
+	want := make([][]int64, n)
+	for i := range want {
+		r := NewRand(42, int64(i))
+		for j := 0; j < draws; j++ {
+			want[i] = append(want[i], r.Int63())
+		}
+	}
+
+	// streams created in reverse order, and drawn interleaved:
+	rands := make([]interface{ Int63() int64 }, n)
+	for i := n - 1; i >= 0; i-- {
+		rands[i] = NewRand(42, int64(i))
+	}
+	for j := 0; j < draws; j++ {
+		for i := n - 1; i >= 0; i-- {
+			if got := rands[i].Int63(); got != want[i][j] {
+				t.Fatalf("stream %d, draw %d: got %d, want %d", i, j, got, want[i][j])
+			}
+		}
+	}
+}
+
+func TestNewRandDistinctStreams(t *testing.T) {
+	seen := make(map[int64]string)
+	for _, seed := range []int64{0, 1, 42} {
+		for index := int64(0); index < 1000; index++ {
+			v := NewRand(seed, index).Int63()
+			if prev, ok := seen[v]; ok {
+				t.Fatalf("seed %d, index %d: same first value as %s", seed, index, prev)
+			}
+			seen[v] = fmt.Sprintf("seed %d, index %d", seed, index)
+		}
+	}
+}
+
+func TestNewPeriodicDistributionPeriod(t *testing.T) {
+	for _, period := range []time.Duration{0, -time.Hour} {
+		if _, err := NewPeriodicDistribution(&time.Time{}, period, 0, 1, 0, nil); err == nil {
+			t.Errorf("period %v: no error", period)
+		}
+	}
+}
+
+func TestPeriodicDistributionPeak(t *testing.T) {
+	const day = 24 * time.Hour
+	midnight := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := midnight
+	d := PD(&clock, day, 50, 40, PeakPhase(14*time.Hour, day), nil)
+
+	for _, tc := range []struct {
+		at   time.Duration
+		want float64
+	}{
+		{14 * time.Hour, 90},      // peak
+		{2 * time.Hour, 10},       // trough
+		{8 * time.Hour, 50},       // halfway up
+		{20 * time.Hour, 50},      // halfway down
+		{day + 14*time.Hour, 90},  // next day's peak
+		{-10 * time.Hour, 90},     // previous day's peak
+		{7*day + 2*time.Hour, 10}, // a week later
+	} {
+		clock = midnight.Add(tc.at)
+		if got := d.Get(); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("at %v: got %v, want %v", tc.at, got, tc.want)
+		}
+	}
+
+	// the maximum over a day is at the peak:
+	var peak time.Duration
+	max := math.Inf(-1)
+	for at := time.Duration(0); at < day; at += time.Minute {
+		clock = midnight.Add(at)
+		if v := d.Get(); v > max {
+			max, peak = v, at
+		}
+	}
+	if peak != 14*time.Hour {
+		t.Errorf("peak at %v, want 14h", peak)
+	}
+}
+
+func TestPeriodicDistributionNoise(t *testing.T) {
+	clock := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	noise := &ConstantDistribution{State: 3}
+	d := PD(&clock, time.Hour, 10, 0, 0, noise)
+	d.Advance()
+	if got := d.Get(); got != 13 {
+		t.Errorf("got %v, want 13", got)
+	}
+}
+
+// countingDistribution returns the number of times it was advanced.
+type countingDistribution struct {
+	n float64
+}
+
+func (d *countingDistribution) Advance()     { d.n++ }
+func (d *countingDistribution) Get() float64 { return d.n }
+
+func TestSumDistribution(t *testing.T) {
+	clock := time.Date(2018, 1, 1, 6, 0, 0, 0, time.UTC)
+	a, b := &countingDistribution{}, &countingDistribution{n: 10}
+	d := SD(a, b, PD(&clock, 24*time.Hour, 5, 2, 0, nil))
+	if got, want := d.Get(), 10.0+5+2; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	d.Advance()
+	d.Advance()
+	if a.n != 2 || b.n != 12 {
+		t.Errorf("parts advanced to %v and %v, want 2 and 12", a.n, b.n)
+	}
+	if got, want := d.Get(), 2.0+12+5+2; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := SD().Get(); got != 0 {
+		t.Errorf("empty sum: got %v, want 0", got)
+	}
+}
@@ -0,0 +1,228 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// ManifestFile is the name of the manifest written by bulk_data_gen in its
+// output directory.
+const ManifestFile = "manifest.json"
+
+// Manifest describes a generated dataset, for the tools consuming it to check
+// they are configured for it.
+type Manifest struct {
+	GeneratorVersion string    `json:"generator_version"`
+	Seed             int64     `json:"seed"`
+	UseCase          string    `json:"use_case"`
+	ScaleVar         int64     `json:"scale_var"`
+	ScaleVarOffset   int64     `json:"scale_var_offset"`
+	Start            time.Time `json:"timestamp_start"`
+	End              time.Time `json:"timestamp_end"`
+	Format           string    `json:"format"`
+	// Precision of the timestamps, for the formats where it can be chosen.
+	Precision string `json:"precision,omitempty"`
+
+	// Simulation settings, for the use cases supporting them.
+	SamplingInterval     Duration            `json:"sampling_interval,omitempty"`
+	MeasurementIntervals map[string]Duration `json:"measurement_intervals,omitempty"`
+	ChurnRate            float64             `json:"churn_rate,omitempty"`
+	ChurnPeriod          Duration            `json:"churn_period,omitempty"`
+	Diurnal              bool                `json:"diurnal,omitempty"`
+	// Incidents injected, as written to the -incidents-output file.
+	Incidents json.RawMessage `json:"incidents,omitempty"`
+
+	Points       int64              `json:"points"`
+	Values       int64              `json:"values"`
+	Measurements []MeasurementStats `json:"measurements"`
+	Tags         []TagStats         `json:"tags"`
+
+	// Checksum is the SHA-256 of the serialized points, in generation order
+	// and before compression. Dataset size markers are not included, so
+	// that it does not depend on how the output was split.
+	Checksum string `json:"checksum"`
+}
+
+// Duration is a time.Duration written as a string, e.g. "1h0m0s".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+type MeasurementStats struct {
+	Name   string `json:"name"`
+	Points int64  `json:"points"`
+	Values int64  `json:"values"`
+}
+
+type TagStats struct {
+	Key         string `json:"key"`
+	Cardinality int    `json:"cardinality"`
+}
+
+// ReadManifest reads a manifest file.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("cannot parse manifest %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// WriteManifest writes a manifest file.
+func WriteManifest(path string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// CheckQueries returns an error if queries generated for the given use case,
+// scale and time range would not match the dataset.
+func (m *Manifest) CheckQueries(useCase string, scaleVar int64, start, end time.Time) error {
+	if useCase != m.UseCase {
+		return fmt.Errorf("use case '%s' does not match the '%s' dataset", useCase, m.UseCase)
+	}
+	if scaleVar != m.ScaleVar {
+		return fmt.Errorf("scale var %d does not match the scale var of the dataset, %d", scaleVar, m.ScaleVar)
+	}
+	if start.Before(m.Start) || end.After(m.End) {
+		return fmt.Errorf("time range %s - %s is not within the time range of the dataset, %s - %s",
+			start.Format(time.RFC3339), end.Format(time.RFC3339), m.Start.Format(time.RFC3339), m.End.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// CheckChurn returns an error if the given host churn does not match the one
+// of the dataset.
+func (m *Manifest) CheckChurn(rate float64, period time.Duration) error {
+	if rate != m.ChurnRate {
+		return fmt.Errorf("churn rate %v does not match the churn rate of the dataset, %v", rate, m.ChurnRate)
+	}
+	if rate != 0 && period != time.Duration(m.ChurnPeriod) {
+		return fmt.Errorf("churn period %v does not match the churn period of the dataset, %v", period, time.Duration(m.ChurnPeriod))
+	}
+	return nil
+}
+
+// CheckFormat returns an error if the dataset is in none of the given formats.
+func (m *Manifest) CheckFormat(formats ...string) error {
+	for _, f := range formats {
+		if m.Format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("dataset format '%s' is not supported (expected: %v)", m.Format, formats)
+}
+
+// CheckPrecision returns an error if the timestamps of the dataset are not of
+// the given precision. Datasets without precision have the default one.
+func (m *Manifest) CheckPrecision(precision, defaultPrecision string) error {
+	p := m.Precision
+	if p == "" {
+		p = defaultPrecision
+	}
+	if p != precision {
+		return fmt.Errorf("precision '%s' does not match the precision of the dataset, '%s'", precision, p)
+	}
+	return nil
+}
+
+// DatasetStats counts the points, values and tag values of a dataset, for
+// its manifest.
+type DatasetStats struct {
+	measurements map[string]*MeasurementStats
+	tagValues    map[string]map[string]struct{}
+}
+
+func NewDatasetStats() *DatasetStats {
+	return &DatasetStats{
+		measurements: make(map[string]*MeasurementStats),
+		tagValues:    make(map[string]map[string]struct{}),
+	}
+}
+
+// Add accounts for a point.
+func (s *DatasetStats) Add(p *Point) {
+	ms, ok := s.measurements[string(p.MeasurementName)]
+	if !ok {
+		ms = &MeasurementStats{Name: string(p.MeasurementName)}
+		s.measurements[ms.Name] = ms
+	}
+	ms.Points++
+	ms.Values += int64(len(p.FieldValues))
+
+	for i, key := range p.TagKeys {
+		values, ok := s.tagValues[string(key)]
+		if !ok {
+			values = make(map[string]struct{})
+			s.tagValues[string(key)] = values
+		}
+		if _, ok := values[string(p.TagValues[i])]; !ok {
+			values[string(p.TagValues[i])] = struct{}{}
+		}
+	}
+}
+
+// Merge adds the counts of other.
+func (s *DatasetStats) Merge(other *DatasetStats) {
+	for name, o := range other.measurements {
+		ms, ok := s.measurements[name]
+		if !ok {
+			ms = &MeasurementStats{Name: name}
+			s.measurements[name] = ms
+		}
+		ms.Points += o.Points
+		ms.Values += o.Values
+	}
+	for key, o := range other.tagValues {
+		values, ok := s.tagValues[key]
+		if !ok {
+			values = make(map[string]struct{}, len(o))
+			s.tagValues[key] = values
+		}
+		for v := range o {
+			values[v] = struct{}{}
+		}
+	}
+}
+
+// Fill sets the counts of the manifest, sorted by measurement name and tag
+// key.
+func (s *DatasetStats) Fill(m *Manifest) {
+	m.Points, m.Values = 0, 0
+	m.Measurements = make([]MeasurementStats, 0, len(s.measurements))
+	for _, ms := range s.measurements {
+		m.Points += ms.Points
+		m.Values += ms.Values
+		m.Measurements = append(m.Measurements, *ms)
+	}
+	sort.Slice(m.Measurements, func(i, j int) bool {
+		return m.Measurements[i].Name < m.Measurements[j].Name
+	})
+	m.Tags = make([]TagStats, 0, len(s.tagValues))
+	for key, values := range s.tagValues {
+		m.Tags = append(m.Tags, TagStats{Key: key, Cardinality: len(values)})
+	}
+	sort.Slice(m.Tags, func(i, j int) bool {
+		return m.Tags[i].Key < m.Tags[j].Key
+	})
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManifestSettingsRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ManifestFile)
+
+	want := &Manifest{
+		UseCase:              "devops",
+		ScaleVar:             10,
+		Start:                time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:                  time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC),
+		SamplingInterval:     Duration(10 * time.Second),
+		MeasurementIntervals: map[string]Duration{"disk": Duration(time.Minute)},
+		ChurnRate:            0.1,
+		ChurnPeriod:          Duration(time.Hour),
+		Diurnal:              true,
+		Incidents:            json.RawMessage(`[{"kind":"gap"}]`),
+	}
+	if err := WriteManifest(path, want); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"sampling_interval": "10s"`, `"disk": "1m0s"`, `"churn_period": "1h0m0s"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("manifest without %s:\n%s", s, data)
+		}
+	}
+	got, err := ReadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the incidents are indented:
+	var gotIncidents, wantIncidents interface{}
+	if err := json.Unmarshal(got.Incidents, &gotIncidents); err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal(want.Incidents, &wantIncidents)
+	if !reflect.DeepEqual(gotIncidents, wantIncidents) {
+		t.Errorf("incidents %s, want %s", got.Incidents, want.Incidents)
+	}
+	got.Incidents, want.Incidents = nil, nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("manifest %+v, want %+v", got, want)
+	}
+}
+
+func TestManifestCheckChurn(t *testing.T) {
+	m := &Manifest{ChurnRate: 0.1, ChurnPeriod: Duration(time.Hour)}
+	for _, tc := range []struct {
+		rate   float64
+		period time.Duration
+		ok     bool
+	}{
+		{0.1, time.Hour, true},
+		{0.2, time.Hour, false},
+		{0, time.Hour, false},
+		{0.1, 2 * time.Hour, false},
+	} {
+		if err := m.CheckChurn(tc.rate, tc.period); (err == nil) != tc.ok {
+			t.Errorf("rate %v, period %v: error %v", tc.rate, tc.period, err)
+		}
+	}
+	// without churn, the period does not matter:
+	if err := (&Manifest{}).CheckChurn(0, 3*time.Hour); err != nil {
+		t.Error(err)
+	}
+}
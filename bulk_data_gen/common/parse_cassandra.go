@@ -0,0 +1,79 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// parseCassandraLine parses an INSERT statement of the Cassandra query
+// format (see SerializerCassandra). The type of its value is that of its
+// table.
+func parseCassandraLine(line []byte) (*fieldLine, error) {
+	const tablePrefix = "INSERT INTO measurements.series_"
+	const valuesPrefix = " (series_id, timestamp_ns, value) VALUES ('"
+	if !bytes.HasPrefix(line, []byte(tablePrefix)) || !bytes.HasSuffix(line, []byte(")")) {
+		return nil, fmt.Errorf("not an insert into a series table")
+	}
+	line = line[len(tablePrefix) : len(line)-1]
+	i := bytes.Index(line, []byte(valuesPrefix))
+	if i < 0 {
+		return nil, fmt.Errorf("invalid columns")
+	}
+	table, line := string(line[:i]), line[i+len(valuesPrefix):]
+
+	// the series id is <series key>#<field>#<day>:
+	i = bytes.Index(line, []byte("', "))
+	if i < 0 {
+		return nil, fmt.Errorf("unterminated series id")
+	}
+	id, line := line[:i], line[i+3:]
+	parts := bytes.Split(id, []byte{'#'})
+	if len(parts) != 3 || len(parts[1]) == 0 {
+		return nil, fmt.Errorf("invalid series id '%s'", id)
+	}
+	i = bytes.Index(line, []byte(", "))
+	if i < 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	ns, err := strconv.ParseInt(string(line[:i]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp '%s'", line[:i])
+	}
+	value, err := parseCassandraValue(table, line[i+2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of '%s': %v", id, err)
+	}
+
+	return &fieldLine{
+		series:    parts[0],
+		field:     parts[1],
+		value:     value,
+		timestamp: time.Unix(0, ns).UTC(),
+	}, nil
+}
+
+// parseCassandraValue parses a value written by fastFormatAppendCassandra to
+// a table of the given type (see typeNameForCassandra).
+func parseCassandraValue(table string, v []byte) (interface{}, error) {
+	switch table {
+	case "bigint":
+		return strconv.ParseInt(string(v), 10, 64)
+	case "double":
+		return strconv.ParseFloat(string(v), 64)
+	case "float":
+		f, err := strconv.ParseFloat(string(v), 32)
+		return float32(f), err
+	case "boolean":
+		return strconv.ParseBool(string(v))
+	case "blob":
+		const prefix, suffix = "textasblob('", "')"
+		if !bytes.HasPrefix(v, []byte(prefix)) || !bytes.HasSuffix(v, []byte(suffix)) || len(v) < len(prefix)+len(suffix) {
+			return nil, fmt.Errorf("invalid blob")
+		}
+		return v[len(prefix) : len(v)-len(suffix)], nil
+	default:
+		return nil, fmt.Errorf("unknown table type '%s'", table)
+	}
+}
@@ -0,0 +1,132 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ParseInfluxLine parses a line of InfluxDB line protocol (without its line
+// break) into p, setting its timestamp to ts, read in units of unit:
+//
+// <measurement>[,<tag key>=<tag value>...] <field key>=<field value>[,...] <timestamp>
+//
+// Integer fields become int64 values, unsigned ones uint64, and strings
+// []byte values. The names and values set in p may point into line, which
+// must not be modified while p is in use.
+func ParseInfluxLine(line []byte, unit time.Duration, p *Point, ts *time.Time) error {
+	keyEnd := indexUnescaped(line, ' ', false)
+	if keyEnd < 0 {
+		return fmt.Errorf("missing fields")
+	}
+	key := line[:keyEnd]
+	rest := bytes.TrimLeft(line[keyEnd:], " ")
+	fieldsEnd := indexUnescaped(rest, ' ', true)
+	if fieldsEnd < 0 {
+		return fmt.Errorf("missing timestamp")
+	}
+	fields := rest[:fieldsEnd]
+	timestamp := bytes.TrimSpace(rest[fieldsEnd:])
+
+	series := splitUnescaped(key, ',', false)
+	if len(series[0]) == 0 {
+		return fmt.Errorf("missing measurement")
+	}
+	p.SetMeasurementName(unescapeInflux(series[0], ", "))
+	for _, tag := range series[1:] {
+		i := indexUnescaped(tag, '=', false)
+		if i <= 0 || i == len(tag)-1 {
+			return fmt.Errorf("invalid tag '%s'", tag)
+		}
+		p.AppendTag(unescapeInflux(tag[:i], ",= "), unescapeInflux(tag[i+1:], ",= "))
+	}
+
+	for _, field := range splitUnescaped(fields, ',', true) {
+		i := indexUnescaped(field, '=', false)
+		if i <= 0 || i == len(field)-1 {
+			return fmt.Errorf("invalid field '%s'", field)
+		}
+		v, err := parseInfluxValue(field[i+1:])
+		if err != nil {
+			return fmt.Errorf("invalid value of field '%s': %v", field[:i], err)
+		}
+		p.AppendField(unescapeInflux(field[:i], ",= "), v)
+	}
+
+	n, err := strconv.ParseInt(string(timestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp '%s'", timestamp)
+	}
+	*ts = time.Unix(0, n*int64(unit)).UTC()
+	p.SetTimestamp(ts)
+	return nil
+}
+
+func parseInfluxValue(v []byte) (interface{}, error) {
+	switch last := v[len(v)-1]; {
+	case v[0] == '"':
+		if len(v) < 2 || last != '"' {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		return unescapeInflux(v[1:len(v)-1], "\"\\"), nil
+	case last == 'i':
+		return strconv.ParseInt(string(v[:len(v)-1]), 10, 64)
+	case last == 'u':
+		return strconv.ParseUint(string(v[:len(v)-1]), 10, 64)
+	}
+	switch string(v) {
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+	return strconv.ParseFloat(string(v), 64)
+}
+
+// indexUnescaped returns the index of the first c in b which is neither
+// escaped with a backslash nor, if quotes is set, within double quotes, or
+// -1.
+func indexUnescaped(b []byte, c byte, quotes bool) int {
+	quoted := false
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '\\':
+			i++
+		case quotes && b[i] == '"':
+			quoted = !quoted
+		case b[i] == c && !quoted:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitUnescaped splits b around the separators found by indexUnescaped.
+func splitUnescaped(b []byte, sep byte, quotes bool) [][]byte {
+	var parts [][]byte
+	for {
+		i := indexUnescaped(b, sep, quotes)
+		if i < 0 {
+			return append(parts, b)
+		}
+		parts = append(parts, b[:i])
+		b = b[i+1:]
+	}
+}
+
+// unescapeInflux removes the backslashes escaping the given special
+// characters. Other backslashes are kept.
+func unescapeInflux(b []byte, specials string) []byte {
+	if bytes.IndexByte(b, '\\') < 0 {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\\' && i+1 < len(b) && bytes.IndexByte([]byte(specials), b[i+1]) >= 0 {
+			i++
+		}
+		out = append(out, b[i])
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// parseOpenTSDBLine parses a JSON line of the OpenTSDB bulk load format (see
+// SerializerOpenTSDB). Its series is a series key with the tags sorted by
+// key, as the JSON object of tags has no order. Values are always floats.
+func parseOpenTSDBLine(line []byte) (*fieldLine, error) {
+	var wp struct {
+		Metric    string            `json:"metric"`
+		Timestamp int64             `json:"timestamp"`
+		Tags      map[string]string `json:"tags"`
+		Value     *float64          `json:"value"`
+	}
+	if err := json.Unmarshal(line, &wp); err != nil {
+		return nil, err
+	}
+	i := bytes.IndexByte([]byte(wp.Metric), '.')
+	if i <= 0 || i == len(wp.Metric)-1 {
+		return nil, fmt.Errorf("invalid metric '%s'", wp.Metric)
+	}
+	if wp.Value == nil {
+		return nil, fmt.Errorf("missing value of metric '%s'", wp.Metric)
+	}
+
+	keys := make([]string, 0, len(wp.Tags))
+	for key := range wp.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	series := make([]byte, 0, 256)
+	series = append(series, wp.Metric[:i]...)
+	for _, key := range keys {
+		series = append(series, ',')
+		series = append(series, key...)
+		series = append(series, '=')
+		series = append(series, wp.Tags[key]...)
+	}
+
+	return &fieldLine{
+		series:    series,
+		field:     []byte(wp.Metric[i+1:]),
+		value:     *wp.Value,
+		timestamp: time.Unix(0, wp.Timestamp*int64(time.Millisecond)).UTC(),
+	}, nil
+}
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// parseTSDBLine parses a line of the TSDB format (see SerializerTSDB):
+//
+// <measurement>_<field>#measurement <measurement> <tag key> <tag value>...#<value>#<timestamp>
+//
+// String values may hold '#', so the value is what lies between the labels
+// and the last '#'.
+func parseTSDBLine(line []byte) (*fieldLine, error) {
+	parts := bytes.SplitN(line, []byte{'#'}, 3)
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("missing labels or value")
+	}
+	metric, labels, rest := parts[0], parts[1], parts[2]
+	i := bytes.LastIndexByte(rest, '#')
+	if i < 0 {
+		return nil, fmt.Errorf("missing timestamp")
+	}
+
+	words := bytes.SplitN(labels, []byte{' '}, 3)
+	if len(words) < 2 || string(words[0]) != "measurement" || len(words[1]) == 0 {
+		return nil, fmt.Errorf("missing measurement label in '%s'", labels)
+	}
+	measurement := words[1]
+	if len(metric) <= len(measurement)+1 || !bytes.HasPrefix(metric, measurement) || metric[len(measurement)] != '_' {
+		return nil, fmt.Errorf("metric '%s' is not of measurement '%s'", metric, measurement)
+	}
+	l := &fieldLine{
+		series: labels,
+		field:  metric[len(measurement)+1:],
+	}
+
+	var err error
+	if l.value, err = parseTSDBValue(rest[:i]); err != nil {
+		return nil, fmt.Errorf("invalid value of metric '%s': %v", metric, err)
+	}
+	ms, err := strconv.ParseInt(string(rest[i+1:]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp '%s'", rest[i+1:])
+	}
+	l.timestamp = time.Unix(0, ms*int64(time.Millisecond)).UTC()
+	return l, nil
+}
+
+// parseTSDBSeries sets the measurement and tags of p from the labels of a
+// TSDB line, which start with the measurement label:
+//
+// measurement <measurement> <tag key> <tag value>...
+func parseTSDBSeries(labels []byte, p *Point) error {
+	words := bytes.Split(labels, []byte{' '})
+	if len(words)%2 != 0 {
+		return fmt.Errorf("odd number of label words in '%s'", labels)
+	}
+	p.SetMeasurementName(words[1])
+	for i := 2; i < len(words); i += 2 {
+		p.AppendTag(words[i], words[i+1])
+	}
+	return nil
+}
+
+// parseTSDBValue parses a value written by fastFormatAppend: floats always
+// have a decimal point, strings are single quoted.
+func parseTSDBValue(v []byte) (interface{}, error) {
+	if len(v) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	if v[0] == '\'' {
+		if len(v) < 2 || v[len(v)-1] != '\'' {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		return v[1 : len(v)-1], nil
+	}
+	switch string(v) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if bytes.IndexAny(v, ".eEIN") >= 0 {
+		return strconv.ParseFloat(string(v), 64)
+	}
+	return strconv.ParseInt(string(v), 10, 64)
+}
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Formats which can be read back into points:
+var ReadableFormatChoices = []string{"influx-bulk", "tsdb", "opentsdb", "cassandra"}
+
+// PointReader reads back the points of a serialized dataset.
+type PointReader interface {
+	// ReadPoint fills p, which must be reset, with the next point, or
+	// returns io.EOF after the last one. Every point gets buffers of its
+	// own, so points may be held while the next ones are read.
+	ReadPoint(p *Point) error
+}
+
+// NewPointReader returns a reader of the points of r, serialized in the
+// given format. unit is the precision of influx-bulk timestamps, the other
+// formats have a fixed precision (see FormatPrecision).
+func NewPointReader(r io.Reader, format string, unit time.Duration) (PointReader, error) {
+	lines := &lineReader{r: bufio.NewReaderSize(r, 4<<20)}
+	switch format {
+	case "influx-bulk":
+		if unit <= 0 {
+			return nil, fmt.Errorf("precision must be positive, got %v", unit)
+		}
+		return &influxPointReader{lines: lines, unit: unit}, nil
+	case "tsdb":
+		return &fieldPointReader{lines: lines, parseLine: parseTSDBLine, parseSeries: parseTSDBSeries}, nil
+	case "opentsdb":
+		return &fieldPointReader{lines: lines, parseLine: parseOpenTSDBLine, parseSeries: parseSeriesKey}, nil
+	case "cassandra":
+		return &fieldPointReader{lines: lines, parseLine: parseCassandraLine, parseSeries: parseSeriesKey}, nil
+	default:
+		return nil, fmt.Errorf("unreadable format '%s' (choices: %s)", format, strings.Join(ReadableFormatChoices, ", "))
+	}
+}
+
+// FormatPrecision returns the precision of the timestamps of a readable
+// format, unit being that of influx-bulk.
+func FormatPrecision(format string, unit time.Duration) time.Duration {
+	switch format {
+	case "influx-bulk":
+		return unit
+	case "tsdb", "opentsdb":
+		return time.Millisecond
+	default:
+		return time.Nanosecond
+	}
+}
+
+// lineReader reads the data lines of a dataset, skipping blank lines,
+// comments and dataset size markers.
+type lineReader struct {
+	r    *bufio.Reader
+	line int64
+}
+
+// next returns a fresh copy of the next data line, without its line break,
+// or io.EOF.
+func (r *lineReader) next() ([]byte, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return nil, err
+		}
+		r.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' || bytes.HasPrefix(line, []byte(DatasetSizeMarker)) {
+			continue
+		}
+		return line, nil
+	}
+}
+
+func (r *lineReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
+
+type influxPointReader struct {
+	lines *lineReader
+	unit  time.Duration
+}
+
+func (r *influxPointReader) ReadPoint(p *Point) error {
+	line, err := r.lines.next()
+	if err != nil {
+		return err
+	}
+	if err := ParseInfluxLine(line, r.unit, p, new(time.Time)); err != nil {
+		return r.lines.errorf("%v", err)
+	}
+	return nil
+}
+
+// fieldLine is a line of a format having a line per field.
+type fieldLine struct {
+	// series identifies the series of the line, for the parseSeries function
+	// of the format to set the measurement and tags of a point from it.
+	series    []byte
+	field     []byte
+	value     interface{}
+	timestamp time.Time
+}
+
+// fieldPointReader rebuilds points from a format having a line per field:
+// the consecutive lines of the same series and timestamp make a point.
+type fieldPointReader struct {
+	lines       *lineReader
+	parseLine   func(line []byte) (*fieldLine, error)
+	parseSeries func(series []byte, p *Point) error
+
+	// the line read ahead, starting the next point:
+	pending *fieldLine
+}
+
+func (r *fieldPointReader) ReadPoint(p *Point) error {
+	first := r.pending
+	r.pending = nil
+	if first == nil {
+		var err error
+		if first, err = r.next(); err != nil {
+			return err
+		}
+	}
+	if err := r.parseSeries(first.series, p); err != nil {
+		return r.lines.errorf("%v", err)
+	}
+	ts := first.timestamp
+	p.SetTimestamp(&ts)
+	p.AppendField(first.field, first.value)
+
+	for {
+		l, err := r.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !bytes.Equal(l.series, first.series) || !l.timestamp.Equal(first.timestamp) {
+			r.pending = l
+			return nil
+		}
+		p.AppendField(l.field, l.value)
+	}
+}
+
+func (r *fieldPointReader) next() (*fieldLine, error) {
+	line, err := r.lines.next()
+	if err != nil {
+		return nil, err
+	}
+	l, err := r.parseLine(line)
+	if err != nil {
+		return nil, r.lines.errorf("%v", err)
+	}
+	return l, nil
+}
+
+// parseSeriesKey sets the measurement and tags of p from a series key:
+//
+// <measurement>[,<tag key>=<tag value>...]
+func parseSeriesKey(key []byte, p *Point) error {
+	parts := bytes.Split(key, []byte{','})
+	if len(parts[0]) == 0 {
+		return fmt.Errorf("missing measurement in series '%s'", key)
+	}
+	p.SetMeasurementName(parts[0])
+	for _, tag := range parts[1:] {
+		i := bytes.IndexByte(tag, '=')
+		if i <= 0 {
+			return fmt.Errorf("invalid tag '%s' in series '%s'", tag, key)
+		}
+		p.AppendTag(tag[:i], tag[i+1:])
+	}
+	return nil
+}
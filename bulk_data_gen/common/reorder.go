@@ -0,0 +1,217 @@
+package common
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// ReorderConfig configures the simulation of out-of-order and late-arriving
+// points.
+type ReorderConfig struct {
+	// DelayFraction is the fraction of points delivered late, by a random
+	// lateness of up to MaxLateness.
+	DelayFraction float64
+	MaxLateness   time.Duration
+
+	// OutageProbability is the probability, for each point, that its source
+	// goes offline for up to MaxOutage. An offline source buffers its points
+	// and delivers them in a burst once it is back online.
+	OutageProbability float64
+	MaxOutage         time.Duration
+
+	// SourceTag is the key of the tag identifying the source of a point
+	// (e.g. hostname). Points without it share a single source.
+	SourceTag []byte
+}
+
+// Enabled reports whether any point gets reordered.
+func (c *ReorderConfig) Enabled() bool {
+	return c.DelayFraction > 0 || c.OutageProbability > 0
+}
+
+func (c *ReorderConfig) Validate() error {
+	if c.DelayFraction < 0 || c.OutageProbability < 0 || c.DelayFraction+c.OutageProbability > 1 {
+		return fmt.Errorf("delayed fraction and outage probability must be non-negative and add up to at most 1")
+	}
+	if c.DelayFraction > 0 && c.MaxLateness <= 0 {
+		return fmt.Errorf("delayed points need a positive maximum lateness")
+	}
+	if c.OutageProbability > 0 && c.MaxOutage <= 0 {
+		return fmt.Errorf("outages need a positive maximum duration")
+	}
+	return nil
+}
+
+// reorderedPoint is a point held back until the stream reaches its release
+// time.
+type reorderedPoint struct {
+	point     Point
+	timestamp time.Time
+	release   time.Time
+	seq       int64
+}
+
+// reorderQueue orders held points by release time, then by arrival, which
+// makes the points released together (e.g. after an outage) keep their
+// original order.
+type reorderQueue []*reorderedPoint
+
+func (q reorderQueue) Len() int { return len(q) }
+func (q reorderQueue) Less(i, j int) bool {
+	if q[i].release.Equal(q[j].release) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].release.Before(q[j].release)
+}
+func (q reorderQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *reorderQueue) Push(x interface{}) {
+	*q = append(*q, x.(*reorderedPoint))
+}
+
+func (q *reorderQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return x
+}
+
+// ReorderingSimulator delivers the points of a Simulator out of order.
+// Most points pass through in order, some are delayed, and all points of a
+// source in a simulated outage are delivered late, at once, when it ends.
+// A point is released once the wrapped simulator has generated a point at
+// or after its release time. The order only depends on the points generated
+// and on the given random source.
+// It fulfills the Simulator interface.
+type ReorderingSimulator struct {
+	sim    Simulator
+	config ReorderConfig
+	rand   *rand.Rand
+
+	queue      reorderQueue
+	free       []*reorderedPoint
+	last       *reorderedPoint
+	outageEnd  map[string]time.Time
+	streamTime time.Time
+	seq        int64
+
+	madePoints int64
+	madeValues int64
+}
+
+func NewReorderingSimulator(sim Simulator, config ReorderConfig, r *rand.Rand) *ReorderingSimulator {
+	return &ReorderingSimulator{
+		sim:       sim,
+		config:    config,
+		rand:      r,
+		outageEnd: make(map[string]time.Time),
+	}
+}
+
+func (s *ReorderingSimulator) SeenPoints() int64 {
+	return s.madePoints
+}
+
+func (s *ReorderingSimulator) SeenValues() int64 {
+	return s.madeValues
+}
+
+func (s *ReorderingSimulator) Total() int64 {
+	return s.sim.Total()
+}
+
+func (s *ReorderingSimulator) Finished() bool {
+	return s.sim.Finished() && len(s.queue) == 0
+}
+
+// Next fills p with the next point to deliver.
+func (s *ReorderingSimulator) Next(p *Point) {
+	if s.last != nil {
+		s.free = append(s.free, s.last)
+		s.last = nil
+	}
+
+	for len(s.queue) == 0 || s.queue[0].release.After(s.streamTime) {
+		if s.sim.Finished() {
+			break
+		}
+		s.pull()
+	}
+
+	rp := heap.Pop(&s.queue).(*reorderedPoint)
+	p.SetMeasurementName(rp.point.MeasurementName)
+	for i := range rp.point.TagKeys {
+		p.AppendTag(rp.point.TagKeys[i], rp.point.TagValues[i])
+	}
+	for i := range rp.point.FieldKeys {
+		p.AppendField(rp.point.FieldKeys[i], rp.point.FieldValues[i])
+	}
+	p.SetTimestamp(&rp.timestamp)
+	s.last = rp
+
+	s.madePoints++
+	s.madeValues += int64(len(p.FieldValues))
+}
+
+// pull generates the next point of the wrapped simulator and queues it.
+func (s *ReorderingSimulator) pull() {
+	var rp *reorderedPoint
+	if n := len(s.free); n > 0 {
+		rp = s.free[n-1]
+		s.free = s.free[:n-1]
+		rp.point.Reset()
+	} else {
+		rp = &reorderedPoint{point: *MakeUsablePoint()}
+	}
+
+	s.sim.Next(&rp.point)
+	// the simulator keeps updating the timestamp it points to:
+	rp.timestamp = *rp.point.Timestamp
+	rp.point.SetTimestamp(&rp.timestamp)
+	rp.release = s.releaseTime(&rp.point, rp.timestamp)
+	rp.seq = s.seq
+	s.seq++
+
+	if rp.timestamp.After(s.streamTime) {
+		s.streamTime = rp.timestamp
+	}
+	heap.Push(&s.queue, rp)
+}
+
+func (s *ReorderingSimulator) releaseTime(p *Point, ts time.Time) time.Time {
+	var source []byte
+	for i, key := range p.TagKeys {
+		if string(key) == string(s.config.SourceTag) {
+			source = p.TagValues[i]
+			break
+		}
+	}
+
+	if end, ok := s.outageEnd[string(source)]; ok {
+		if ts.Before(end) {
+			return end
+		}
+		delete(s.outageEnd, string(source))
+	}
+
+	x := s.rand.Float64()
+	switch {
+	case x < s.config.OutageProbability:
+		end := ts.Add(s.lateness(s.config.MaxOutage))
+		s.outageEnd[string(source)] = end
+		return end
+	case x < s.config.OutageProbability+s.config.DelayFraction:
+		return ts.Add(s.lateness(s.config.MaxLateness))
+	default:
+		return ts
+	}
+}
+
+// lateness returns a random duration in (0, max].
+func (s *ReorderingSimulator) lateness(max time.Duration) time.Duration {
+	return time.Duration(s.rand.Int63n(int64(max))) + 1
+}
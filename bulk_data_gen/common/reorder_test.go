@@ -0,0 +1,113 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+// testSimulator generates a point per source every 10 seconds, in time order.
+type testSimulator struct {
+	sources int
+	total   int64
+	made    int64
+	start   time.Time
+	ts      time.Time
+}
+
+func (s *testSimulator) Total() int64      { return s.total }
+func (s *testSimulator) SeenPoints() int64 { return s.made }
+func (s *testSimulator) SeenValues() int64 { return s.made }
+func (s *testSimulator) Finished() bool    { return s.made >= s.total }
+
+func (s *testSimulator) Next(p *Point) {
+	s.ts = s.start.Add(time.Duration(s.made/int64(s.sources)) * 10 * time.Second)
+	p.SetMeasurementName([]byte("cpu"))
+	p.AppendTag([]byte("hostname"), []byte(fmt.Sprintf("host_%d", s.made%int64(s.sources))))
+	p.AppendField([]byte("n"), s.made)
+	p.SetTimestamp(&s.ts)
+	s.made++
+}
+
+func serializeAll(t *testing.T, sim Simulator) ([]string, []time.Time) {
+	serializer := NewSerializerInflux()
+	var lines []string
+	var timestamps []time.Time
+	p := MakeUsablePoint()
+	for !sim.Finished() {
+		sim.Next(p)
+		var buf bytes.Buffer
+		if err := serializer.SerializePoint(&buf, p); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, buf.String())
+		timestamps = append(timestamps, *p.Timestamp)
+		p.Reset()
+	}
+	return lines, timestamps
+}
+
+func TestReorderingSimulatorKeepsPoints(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	newSim := func() *testSimulator {
+		return &testSimulator{sources: 5, total: 5000, start: start}
+	}
+	config := ReorderConfig{
+		DelayFraction:     0.1,
+		MaxLateness:       time.Minute,
+		OutageProbability: 0.01,
+		MaxOutage:         10 * time.Minute,
+		SourceTag:         []byte("hostname"),
+	}
+
+	want, _ := serializeAll(t, newSim())
+	reordering := NewReorderingSimulator(newSim(), config, rand.New(rand.NewSource(42)))
+	got, timestamps := serializeAll(t, reordering)
+
+	if reordering.SeenPoints() != int64(len(want)) {
+		t.Errorf("seen %d points, want %d", reordering.SeenPoints(), len(want))
+	}
+	outOfOrder := 0
+	var latest time.Time
+	for i, ts := range timestamps {
+		if ts.Before(latest) {
+			outOfOrder++
+			if lateness := latest.Sub(ts); lateness > config.MaxOutage+10*time.Second {
+				t.Errorf("point %d is %v late", i, lateness)
+			}
+		} else {
+			latest = ts
+		}
+	}
+	if outOfOrder == 0 {
+		t.Error("no point was delivered out of order")
+	}
+
+	sort.Strings(want)
+	sort.Strings(got)
+	if len(got) != len(want) {
+		t.Fatalf("got %d points, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("the reordered points differ from the generated ones: %q != %q", got[i], want[i])
+		}
+	}
+}
+
+func TestReorderingSimulatorDisabled(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	want, _ := serializeAll(t, &testSimulator{sources: 3, total: 300, start: start})
+	got, _ := serializeAll(t, NewReorderingSimulator(&testSimulator{sources: 3, total: 300, start: start}, ReorderConfig{}, rand.New(rand.NewSource(42))))
+	if len(got) != len(want) {
+		t.Fatalf("got %d points, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("point %d: %q != %q without reordering", i, got[i], want[i])
+		}
+	}
+}
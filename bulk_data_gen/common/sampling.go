@@ -0,0 +1,68 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// SampledMeasurement is a SimulatedMeasurement collected every Every-th
+// epoch only. It is advanced by the whole collection interval at once, so
+// its distributions step once per collected point.
+type SampledMeasurement struct {
+	SimulatedMeasurement
+	Every int64
+
+	epoch int64
+}
+
+func NewSampledMeasurement(sm SimulatedMeasurement, every int64) *SampledMeasurement {
+	return &SampledMeasurement{SimulatedMeasurement: sm, Every: every}
+}
+
+// Tick advances the measurement by one epoch of the given duration.
+func (m *SampledMeasurement) Tick(d time.Duration) {
+	m.epoch++
+	if m.Due() {
+		m.SimulatedMeasurement.Tick(d * time.Duration(m.Every))
+	}
+}
+
+// Due reports whether the measurement is collected in the current epoch.
+func (m *SampledMeasurement) Due() bool {
+	return m.epoch%m.Every == 0
+}
+
+// SampledPoints returns the number of points a measurement collected every
+// every-th epoch emits over the given number of epochs, starting with the
+// first one.
+func SampledPoints(epochs, every int64) int64 {
+	return (epochs + every - 1) / every
+}
+
+// SamplingMultiples converts per-measurement collection intervals to
+// multiples of the sampling interval (the epoch duration). Every interval
+// must be a positive multiple of the sampling interval and name one of the
+// known measurements.
+func SamplingMultiples(sampling time.Duration, intervals map[string]time.Duration, names [][]byte) (map[string]int64, error) {
+	if sampling <= 0 {
+		return nil, fmt.Errorf("sampling interval must be positive, got %v", sampling)
+	}
+	multiples := make(map[string]int64, len(intervals))
+	for name, interval := range intervals {
+		known := false
+		for _, n := range names {
+			if string(n) == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown measurement '%s' in measurement intervals", name)
+		}
+		if interval <= 0 || interval%sampling != 0 {
+			return nil, fmt.Errorf("interval %v of measurement '%s' is not a multiple of the sampling interval %v", interval, name, sampling)
+		}
+		multiples[name] = int64(interval / sampling)
+	}
+	return multiples, nil
+}
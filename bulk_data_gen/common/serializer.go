@@ -13,6 +13,14 @@ type Serializer interface {
 	SerializeToCSV(w io.Writer, p *Point) error
 }
 
+// HeaderSerializer is a Serializer whose files start with a header, e.g. the
+// column names of a CSV table. SerializeHeader is given the first point of
+// the file, or nil if unknown.
+type HeaderSerializer interface {
+	Serializer
+	SerializeHeader(w io.Writer, p *Point) error
+}
+
 const DatasetSizeMarker = "dataset-size:"
 
 var DatasetSizeMarkerRE = regexp.MustCompile(DatasetSizeMarker + `(\d+),(\d+)`)
@@ -37,6 +45,10 @@ func fastFormatAppend(v interface{}, buf []byte, singleQuotesForString bool) []b
 		return strconv.AppendInt(buf, int64(v.(int)), 10)
 	case int64:
 		return strconv.AppendInt(buf, v.(int64), 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v.(uint)), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v.(uint64), 10)
 	case float64:
 		return strconv.AppendFloat(buf, v.(float64), 'f', 16, 64)
 	case float32:
@@ -76,9 +76,11 @@ func fastFormatAppendCassandra(v interface{}, buf []byte, singleQuotesForString
 	}
 }
 
+// typeNameForCassandra returns the type of the table of a value. There is no
+// table of unsigned integers: they go to the bigint one.
 func typeNameForCassandra(v interface{}) string {
 	switch v.(type) {
-	case int, int64:
+	case int, int64, uint, uint64:
 		return "bigint"
 	case float64:
 		return "double"
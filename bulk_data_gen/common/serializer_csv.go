@@ -0,0 +1,252 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Layouts of CSV tables:
+const (
+	// CSVLayoutWide has a table per measurement, with a column per tag and
+	// field.
+	CSVLayoutWide = "wide"
+	// CSVLayoutNarrow has a single table, with a row per value: its series,
+	// field, timestamp and value.
+	CSVLayoutNarrow = "narrow"
+)
+
+var CSVLayoutChoices = []string{CSVLayoutWide, CSVLayoutNarrow}
+
+// CSVTimeColumn is the name of the timestamp column of CSV tables.
+const CSVTimeColumn = "ts"
+
+// csvColumns are the tag and field columns of a wide table, after the
+// timestamp.
+type csvColumns struct {
+	tagKeys   [][]byte
+	fieldKeys [][]byte
+}
+
+type SerializerCSV struct {
+	layout string
+	comma  byte
+
+	// columns of the wide table of each measurement, set by its header:
+	columns map[string]*csvColumns
+}
+
+// NewSerializerCSV returns a serializer to CSV tables of the given layout,
+// using comma to separate values (e.g. '\t' for TSV). With the wide layout,
+// each table must go to its own file, starting with the header serialized
+// for its first point.
+func NewSerializerCSV(layout string, comma byte) *SerializerCSV {
+	switch layout {
+	case CSVLayoutWide, CSVLayoutNarrow:
+	default:
+		panic(fmt.Sprintf("logic error: unknown csv layout '%s'", layout))
+	}
+	return &SerializerCSV{
+		layout:  layout,
+		comma:   comma,
+		columns: make(map[string]*csvColumns),
+	}
+}
+
+// SerializeHeader writes the column names of the table of p.
+//
+// The wide layout writes:
+// ts,<tag keys>,<field keys>
+//
+// The narrow layout writes:
+// series,field,ts,value
+func (s *SerializerCSV) SerializeHeader(w io.Writer, p *Point) error {
+	buf := scratchBufPool.Get().([]byte)
+	if s.layout == CSVLayoutNarrow {
+		buf = s.appendRow(buf, []byte("series"), []byte("field"), []byte(CSVTimeColumn), []byte("value"))
+	} else {
+		c := s.tableColumns(p)
+		buf = appendCSVValue(buf, []byte(CSVTimeColumn), s.comma)
+		for _, key := range c.tagKeys {
+			buf = append(buf, s.comma)
+			buf = appendCSVValue(buf, key, s.comma)
+		}
+		for _, key := range c.fieldKeys {
+			buf = append(buf, s.comma)
+			buf = appendCSVValue(buf, key, s.comma)
+		}
+		buf = append(buf, '\n')
+	}
+	_, err := w.Write(buf)
+	scratchBufPool.Put(buf[:0])
+	return err
+}
+
+// tableColumns returns the columns of the wide table of p, which are the
+// tags and fields of the first point of its measurement.
+func (s *SerializerCSV) tableColumns(p *Point) *csvColumns {
+	c, ok := s.columns[string(p.MeasurementName)]
+	if !ok {
+		c = &csvColumns{}
+		for _, key := range p.TagKeys {
+			c.tagKeys = append(c.tagKeys, append([]byte{}, key...))
+		}
+		for _, key := range p.FieldKeys {
+			c.fieldKeys = append(c.fieldKeys, append([]byte{}, key...))
+		}
+		s.columns[string(p.MeasurementName)] = c
+	}
+	return c
+}
+
+// SerializePoint writes Point data to the given writer as CSV rows, with
+// RFC3339 timestamps.
+//
+// The wide layout writes a row per point, e.g.:
+// 2018-01-01T00:00:00Z,host_0,us-west-2,58.13,2.1
+// Tags and fields missing from the point are left empty. The point must not
+// have tags or fields missing from the header of its table.
+//
+// The narrow layout writes a row per field, with the series key of the
+// point, e.g.:
+// "cpu,hostname=host_0,region=us-west-2",usage_user,2018-01-01T00:00:00Z,58.13
+func (s *SerializerCSV) SerializePoint(w io.Writer, p *Point) (err error) {
+	ts := p.Timestamp.UTC().AppendFormat(make([]byte, 0, 32), time.RFC3339Nano)
+	buf := scratchBufPool.Get().([]byte)
+	if s.layout == CSVLayoutNarrow {
+		series := make([]byte, 0, 256)
+		series = append(series, p.MeasurementName...)
+		for i := range p.TagKeys {
+			series = append(series, ',')
+			series = append(series, p.TagKeys[i]...)
+			series = append(series, '=')
+			series = append(series, p.TagValues[i]...)
+		}
+		value := make([]byte, 0, 64)
+		for i := range p.FieldKeys {
+			value = appendCSVFieldValue(value[:0], p.FieldValues[i])
+			buf = s.appendRow(buf, series, p.FieldKeys[i], ts, value)
+		}
+	} else {
+		buf, err = s.appendWideRow(buf, p, ts)
+		if err != nil {
+			scratchBufPool.Put(buf[:0])
+			return err
+		}
+	}
+	_, err = w.Write(buf)
+	scratchBufPool.Put(buf[:0])
+	return err
+}
+
+func (s *SerializerCSV) appendWideRow(buf []byte, p *Point, ts []byte) ([]byte, error) {
+	c := s.tableColumns(p)
+	if len(p.TagKeys) > len(c.tagKeys) || len(p.FieldKeys) > len(c.fieldKeys) {
+		return buf, fmt.Errorf("point of measurement '%s' has more columns than its csv header", p.MeasurementName)
+	}
+	found := 0
+	buf = append(buf, ts...)
+	for i, key := range c.tagKeys {
+		buf = append(buf, s.comma)
+		if j := keyIndex(p.TagKeys, key, i); j >= 0 {
+			buf = appendCSVValue(buf, p.TagValues[j], s.comma)
+			found++
+		}
+	}
+	for i, key := range c.fieldKeys {
+		buf = append(buf, s.comma)
+		if j := keyIndex(p.FieldKeys, key, i); j >= 0 {
+			v := appendCSVFieldValue(nil, p.FieldValues[j])
+			buf = appendCSVValue(buf, v, s.comma)
+			found++
+		}
+	}
+	if found != len(p.TagKeys)+len(p.FieldKeys) {
+		return buf, fmt.Errorf("point of measurement '%s' has columns missing from its csv header", p.MeasurementName)
+	}
+	return append(buf, '\n'), nil
+}
+
+// keyIndex returns the index of key in keys, looking at index hint first,
+// or -1.
+func keyIndex(keys [][]byte, key []byte, hint int) int {
+	if hint < len(keys) && string(keys[hint]) == string(key) {
+		return hint
+	}
+	for i := range keys {
+		if string(keys[i]) == string(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *SerializerCSV) appendRow(buf []byte, values ...[]byte) []byte {
+	for i, v := range values {
+		if i > 0 {
+			buf = append(buf, s.comma)
+		}
+		buf = appendCSVValue(buf, v, s.comma)
+	}
+	return append(buf, '\n')
+}
+
+// appendCSVFieldValue appends the text of a field value, unquoted.
+func appendCSVFieldValue(buf []byte, v interface{}) []byte {
+	switch x := v.(type) {
+	case int:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case int64:
+		return strconv.AppendInt(buf, x, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(x), 10)
+	case uint64:
+		return strconv.AppendUint(buf, x, 10)
+	case float64:
+		return strconv.AppendFloat(buf, x, 'f', -1, 64)
+	case float32:
+		return strconv.AppendFloat(buf, float64(x), 'f', -1, 32)
+	case bool:
+		return strconv.AppendBool(buf, x)
+	case []byte:
+		return append(buf, x...)
+	case string:
+		return append(buf, x...)
+	default:
+		panic(fmt.Sprintf("unknown field type for %#v", v))
+	}
+}
+
+// appendCSVValue appends a value, quoted as RFC 4180 requires if it holds
+// the separator, quotes or line breaks.
+func appendCSVValue(buf []byte, v []byte, comma byte) []byte {
+	quote := false
+	for _, c := range v {
+		if c == comma || c == '"' || c == '\n' || c == '\r' {
+			quote = true
+			break
+		}
+	}
+	if !quote {
+		return append(buf, v...)
+	}
+	buf = append(buf, '"')
+	for _, c := range v {
+		if c == '"' {
+			buf = append(buf, '"')
+		}
+		buf = append(buf, c)
+	}
+	return append(buf, '"')
+}
+
+// SerializeSize writes nothing: CSV loaders would reject a dataset size
+// marker.
+func (s *SerializerCSV) SerializeSize(w io.Writer, points int64, values int64) error {
+	return nil
+}
+
+func (s *SerializerCSV) SerializeToCSV(w io.Writer, p *Point) error {
+	return nil
+}
@@ -0,0 +1,191 @@
+package common
+
+import (
+	"io"
+	"strconv"
+)
+
+// GraphitePathMapping maps the tags of a point onto a Graphite metric path:
+//
+// <values of the Prefix tags>.<measurement>.<values of the other tags>.<field>
+//
+// The Prefix tags come in the given order, the other tags in the order of
+// the point, but for the Dropped tags, which are left out.
+type GraphitePathMapping struct {
+	Prefix  [][]byte
+	Dropped [][]byte
+}
+
+// GraphiteDevopsPath lays out devops metrics by location first, e.g.
+// us-west-1.us-west-1a.host_3.disk._dev_sda1.ext4.used. The other host tags
+// (rack, os, service...) are constant for a host and would only make paths
+// longer, so they are dropped.
+var GraphiteDevopsPath = GraphitePathMapping{
+	Prefix: [][]byte{
+		[]byte("region"),
+		[]byte("datacenter"),
+		[]byte("hostname"),
+	},
+	Dropped: [][]byte{
+		[]byte("rack"),
+		[]byte("os"),
+		[]byte("arch"),
+		[]byte("team"),
+		[]byte("service"),
+		[]byte("service_version"),
+		[]byte("service_environment"),
+	},
+}
+
+type SerializerGraphite struct {
+	path   GraphitePathMapping
+	tagged bool
+}
+
+// NewSerializerGraphite returns a serializer to the hierarchical form of the
+// Graphite plaintext protocol, with the given path mapping.
+func NewSerializerGraphite(path GraphitePathMapping) *SerializerGraphite {
+	return &SerializerGraphite{path: path}
+}
+
+// NewSerializerGraphiteTagged returns a serializer to the tagged-series form
+// of the Graphite plaintext protocol.
+func NewSerializerGraphiteTagged() *SerializerGraphite {
+	return &SerializerGraphite{tagged: true}
+}
+
+// SerializePoint writes Point data to the given writer, conforming to the
+// Graphite plaintext protocol, one line per field. Timestamps are in
+// seconds. Graphite only supports numeric values: integers and booleans are
+// converted, string fields are skipped.
+//
+// The hierarchical form writes lines that look like:
+// us-west-1.us-west-1a.host_3.cpu.usage_user 58.13 1451606400
+//
+// The tagged-series form keeps all tags:
+// cpu.usage_user;hostname=host_3;region=us-west-1;datacenter=us-west-1a 58.13 1451606400
+func (s *SerializerGraphite) SerializePoint(w io.Writer, p *Point) (err error) {
+	var metric []byte
+	if s.tagged {
+		metric = s.taggedMetric(p)
+	} else {
+		metric = s.pathMetric(p)
+	}
+
+	timestampSecs := p.Timestamp.UTC().Unix()
+	buf := scratchBufPool.Get().([]byte)
+	for i := 0; i < len(p.FieldKeys); i++ {
+		value, ok := prometheusValue(p.FieldValues[i])
+		if !ok {
+			continue
+		}
+		if s.tagged {
+			// the field goes after the measurement name, before the tags:
+			n := len(p.MeasurementName)
+			buf = append(buf, metric[:n]...)
+			buf = append(buf, '.')
+			buf = appendGraphiteNode(buf, p.FieldKeys[i])
+			buf = append(buf, metric[n:]...)
+		} else {
+			buf = append(buf, metric...)
+			buf = appendGraphiteNode(buf, p.FieldKeys[i])
+		}
+		buf = append(buf, ' ')
+		buf = strconv.AppendFloat(buf, value, 'f', -1, 64)
+		buf = append(buf, ' ')
+		buf = strconv.AppendInt(buf, timestampSecs, 10)
+		buf = append(buf, '\n')
+	}
+
+	_, err = w.Write(buf)
+	scratchBufPool.Put(buf[:0])
+	return err
+}
+
+// pathMetric returns the path of the point, up to and including the dot
+// before the field name.
+func (s *SerializerGraphite) pathMetric(p *Point) []byte {
+	metric := make([]byte, 0, 128)
+	for _, key := range s.path.Prefix {
+		for i := range p.TagKeys {
+			if string(p.TagKeys[i]) == string(key) {
+				metric = appendGraphiteNode(metric, p.TagValues[i])
+				metric = append(metric, '.')
+				break
+			}
+		}
+	}
+	metric = appendGraphiteNode(metric, p.MeasurementName)
+	metric = append(metric, '.')
+	for i := range p.TagKeys {
+		if containsKey(s.path.Prefix, p.TagKeys[i]) || containsKey(s.path.Dropped, p.TagKeys[i]) {
+			continue
+		}
+		metric = appendGraphiteNode(metric, p.TagValues[i])
+		metric = append(metric, '.')
+	}
+	return metric
+}
+
+// taggedMetric returns the measurement name followed by the tags of the
+// point.
+func (s *SerializerGraphite) taggedMetric(p *Point) []byte {
+	metric := make([]byte, 0, 256)
+	metric = appendGraphiteNode(metric, p.MeasurementName)
+	for i := range p.TagKeys {
+		metric = append(metric, ';')
+		metric = appendGraphiteTag(metric, p.TagKeys[i])
+		metric = append(metric, '=')
+		metric = appendGraphiteTag(metric, p.TagValues[i])
+	}
+	return metric
+}
+
+func containsKey(keys [][]byte, key []byte) bool {
+	for _, k := range keys {
+		if string(k) == string(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendGraphiteNode appends a node of a metric path, replacing the dots,
+// slashes and whitespace it may contain with underscores.
+func appendGraphiteNode(buf []byte, node []byte) []byte {
+	for _, c := range node {
+		switch c {
+		case '.', '/', ' ', '\t', '\n', ';':
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendGraphiteTag appends a tag key or value of a tagged series, replacing
+// the characters it may not contain with underscores.
+func appendGraphiteTag(buf []byte, tag []byte) []byte {
+	if len(tag) == 0 {
+		// empty tag values are not allowed:
+		return append(buf, '_')
+	}
+	for _, c := range tag {
+		switch c {
+		case ';', '~', '=', '!', '^', ' ', '\t', '\n':
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// SerializeSize writes nothing: carbon-compatible backends would reject a
+// dataset size marker.
+func (s *SerializerGraphite) SerializeSize(w io.Writer, points int64, values int64) error {
+	return nil
+}
+
+func (s *SerializerGraphite) SerializeToCSV(w io.Writer, p *Point) error {
+	return nil
+}
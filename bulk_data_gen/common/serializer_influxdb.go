@@ -1,14 +1,84 @@
 package common
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Timestamp precisions of the InfluxDB line protocol, as given to the
+// precision parameter of the write endpoint:
+var InfluxPrecisionChoices = []string{"ns", "us", "ms", "s"}
+
+// InfluxOptions tune the line protocol written by the InfluxDB serializer.
+type InfluxOptions struct {
+	// Precision of the timestamps, one of InfluxPrecisionChoices.
+	Precision string
+	// FloatDigits is the number of digits after the decimal point of float
+	// values, or -1 for the fewest digits representing them exactly.
+	FloatDigits int
+	// Unsigned writes integer fields as unsigned integers (with the 'u'
+	// suffix), which must then never be negative.
+	Unsigned bool
+}
+
+// DefaultInfluxOptions write nanosecond timestamps, floats with 16 digits
+// and signed integers.
+var DefaultInfluxOptions = InfluxOptions{
+	Precision:   "ns",
+	FloatDigits: 16,
+}
+
+func (o *InfluxOptions) Validate() error {
+	if _, err := InfluxPrecisionUnit(o.Precision); err != nil {
+		return err
+	}
+	if o.FloatDigits < -1 {
+		return fmt.Errorf("float digits must be -1 or more, got %d", o.FloatDigits)
+	}
+	return nil
+}
+
+// InfluxPrecisionUnit returns the duration of the unit of timestamps of the
+// given precision.
+func InfluxPrecisionUnit(precision string) (time.Duration, error) {
+	switch precision {
+	case "ns":
+		return time.Nanosecond, nil
+	case "us":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	default:
+		return 0, fmt.Errorf("invalid precision '%s' (choices: %s)", precision, strings.Join(InfluxPrecisionChoices, ", "))
+	}
+}
+
 type serializerInflux struct {
+	opts InfluxOptions
+	unit int64 // in nanoseconds
 }
 
 func NewSerializerInflux() *serializerInflux {
-	return &serializerInflux{}
+	return NewSerializerInfluxWithOptions(DefaultInfluxOptions)
+}
+
+// NewSerializerInfluxWithOptions returns an InfluxDB serializer writing the
+// line protocol as opts say. The options must be valid.
+func NewSerializerInfluxWithOptions(opts InfluxOptions) *serializerInflux {
+	if err := opts.Validate(); err != nil {
+		panic(fmt.Sprintf("logic error: %v", err))
+	}
+	unit, _ := InfluxPrecisionUnit(opts.Precision)
+	return &serializerInflux{
+		opts: opts,
+		unit: unit.Nanoseconds(),
+	}
 }
 
 // SerializeInfluxBulk writes Point data to the given writer, conforming to the
@@ -20,16 +90,20 @@ func NewSerializerInflux() *serializerInflux {
 // For example:
 // foo,tag0=bar baz=-1.0 100\n
 //
+// Timestamps are truncated to the precision of the options. Integers are
+// suffixed with 'i', or 'u' for unsigned ones. Names, tag values and strings
+// are escaped as the protocol requires.
+//
 // TODO(rw): Speed up this function. The bulk of time is spent in strconv.
 func (s *serializerInflux) SerializePoint(w io.Writer, p *Point) (err error) {
 	buf := scratchBufPool.Get().([]byte)
-	buf = append(buf, p.MeasurementName...)
+	buf = appendInfluxEscaped(buf, p.MeasurementName, ", ")
 
 	for i := 0; i < len(p.TagKeys); i++ {
 		buf = append(buf, ',')
-		buf = append(buf, p.TagKeys[i]...)
+		buf = appendInfluxEscaped(buf, p.TagKeys[i], ",= ")
 		buf = append(buf, '=')
-		buf = append(buf, p.TagValues[i]...)
+		buf = appendInfluxEscaped(buf, p.TagValues[i], ",= ")
 	}
 
 	if len(p.FieldKeys) > 0 {
@@ -37,16 +111,39 @@ func (s *serializerInflux) SerializePoint(w io.Writer, p *Point) (err error) {
 	}
 
 	for i := 0; i < len(p.FieldKeys); i++ {
-		buf = append(buf, p.FieldKeys[i]...)
+		buf = appendInfluxEscaped(buf, p.FieldKeys[i], ",= ")
 		buf = append(buf, '=')
 
 		v := p.FieldValues[i]
-		buf = fastFormatAppend(v, buf, false)
-
-		// Influx uses 'i' to indicate integers:
-		switch v.(type) {
-		case int, int64:
-			buf = append(buf, 'i')
+		switch x := v.(type) {
+		case int:
+			buf, err = s.appendInteger(buf, int64(x), p.FieldKeys[i])
+		case int64:
+			buf, err = s.appendInteger(buf, x, p.FieldKeys[i])
+		case uint:
+			buf = strconv.AppendUint(buf, uint64(x), 10)
+			buf = append(buf, 'u')
+		case uint64:
+			buf = strconv.AppendUint(buf, x, 10)
+			buf = append(buf, 'u')
+		case float64:
+			buf = strconv.AppendFloat(buf, x, 'f', s.opts.FloatDigits, 64)
+		case float32:
+			buf = strconv.AppendFloat(buf, float64(x), 'f', s.opts.FloatDigits, 32)
+		case []byte:
+			buf = append(buf, '"')
+			buf = appendInfluxEscaped(buf, x, "\"\\")
+			buf = append(buf, '"')
+		case string:
+			buf = append(buf, '"')
+			buf = appendInfluxEscaped(buf, []byte(x), "\"\\")
+			buf = append(buf, '"')
+		default:
+			buf = fastFormatAppend(v, buf, false)
+		}
+		if err != nil {
+			scratchBufPool.Put(buf[:0])
+			return err
 		}
 
 		if i+1 < len(p.FieldKeys) {
@@ -55,7 +152,7 @@ func (s *serializerInflux) SerializePoint(w io.Writer, p *Point) (err error) {
 	}
 
 	buf = append(buf, ' ')
-	buf = fastFormatAppend(p.Timestamp.UTC().UnixNano(), buf, true)
+	buf = fastFormatAppend(p.Timestamp.UTC().UnixNano()/s.unit, buf, true)
 	buf = append(buf, '\n')
 	_, err = w.Write(buf)
 
@@ -65,6 +162,34 @@ func (s *serializerInflux) SerializePoint(w io.Writer, p *Point) (err error) {
 	return err
 }
 
+// appendInfluxEscaped appends b, escaping the given special characters with
+// a backslash.
+func appendInfluxEscaped(buf []byte, b []byte, specials string) []byte {
+	if !bytes.ContainsAny(b, specials) {
+		return append(buf, b...)
+	}
+	for _, c := range b {
+		if strings.IndexByte(specials, c) >= 0 {
+			buf = append(buf, '\\')
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendInteger appends an integer field value, with its type suffix.
+func (s *serializerInflux) appendInteger(buf []byte, v int64, key []byte) ([]byte, error) {
+	buf = strconv.AppendInt(buf, v, 10)
+	if !s.opts.Unsigned {
+		// Influx uses 'i' to indicate integers:
+		return append(buf, 'i'), nil
+	}
+	if v < 0 {
+		return buf, fmt.Errorf("cannot write negative value %d of field '%s' as unsigned", v, key)
+	}
+	return append(buf, 'u'), nil
+}
+
 func (s *serializerInflux) SerializeSize(w io.Writer, points int64, values int64) error {
 	return serializeSizeInText(w, points, values)
 }
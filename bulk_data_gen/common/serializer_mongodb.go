@@ -81,13 +81,17 @@ func (s *SerializerMongo) SerializePoint(w io.Writer, p *Point) (err error) {
 		switch v := genericValue.(type) {
 		// (We can't switch on sets of types (e.g. int, int64) because
 		// that does not make v concrete.)
-		case int, int64:
+		case int, int64, uint, uint64:
 			mongo_serialization.ItemAddValueType(builder, mongo_serialization.ValueTypeLong)
 			switch v2 := v.(type) {
 			case int:
 				mongo_serialization.ItemAddLongValue(builder, int64(v2))
 			case int64:
 				mongo_serialization.ItemAddLongValue(builder, v2)
+			case uint:
+				mongo_serialization.ItemAddLongValue(builder, int64(v2))
+			case uint64:
+				mongo_serialization.ItemAddLongValue(builder, int64(v2))
 			}
 		case float64:
 			mongo_serialization.ItemAddValueType(builder, mongo_serialization.ValueTypeDouble)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 )
 
 type SerializerOpenTSDB struct {
@@ -21,7 +22,8 @@ func NewSerializerOpenTSDB() *SerializerOpenTSDB {
 //
 // N.B. OpenTSDB only supports millisecond or second resolution timestamps.
 // N.B. OpenTSDB millisecond timestamps must be 13 digits long.
-// N.B. OpenTSDB only supports floating-point field values.
+// N.B. OpenTSDB only supports floating-point field values: integers and
+// booleans are converted, string fields are an error.
 //
 // This function writes JSON lines that looks like:
 // { <metric>, <timestamp>, <value>, <tags> }
@@ -60,18 +62,11 @@ func (m *SerializerOpenTSDB) SerializePoint(w io.Writer, p *Point) (err error) {
 	// for each Value, generate a new line in the output:
 	for i := 0; i < len(p.FieldKeys); i++ {
 		wp.Metric = metricBase + "." + string(p.FieldKeys[i])
-		switch x := p.FieldValues[i].(type) {
-		case int:
-			wp.Value = float64(x)
-		case int64:
-			wp.Value = float64(x)
-		case float32:
-			wp.Value = float64(x)
-		case float64:
-			wp.Value = float64(x)
-		default:
-			panic("bad numeric value for OpenTSDB serialization")
+		value, ok := prometheusValue(p.FieldValues[i])
+		if !ok {
+			return fmt.Errorf("field '%s' of measurement '%s': OpenTSDB does not support %T values", p.FieldKeys[i], p.MeasurementName, p.FieldValues[i])
 		}
+		wp.Value = value
 
 		err := encoder.Encode(wp)
 		if err != nil {
@@ -90,3 +85,94 @@ func (s *SerializerOpenTSDB) SerializeSize(w io.Writer, points int64, values int
 func (s *SerializerOpenTSDB) SerializeToCSV(w io.Writer, p *Point) error {
 	return nil
 }
+
+// OpenTSDBTelnetDevopsDropped are the devops host tags left out of telnet
+// put lines, which keep hostname, region, datacenter, service and
+// service_environment. OpenTSDB accepts at most 8 tags per data point by
+// default: this leaves room for the tags of the measurements (at most 2).
+// The tags dropped are constant for a host.
+var OpenTSDBTelnetDevopsDropped = [][]byte{
+	[]byte("rack"),
+	[]byte("os"),
+	[]byte("arch"),
+	[]byte("team"),
+	[]byte("service_version"),
+}
+
+type SerializerOpenTSDBTelnet struct {
+	dropped [][]byte
+}
+
+// NewSerializerOpenTSDBTelnet returns a serializer to the OpenTSDB telnet
+// put form, leaving out the given tags.
+func NewSerializerOpenTSDBTelnet(dropped [][]byte) *SerializerOpenTSDBTelnet {
+	return &SerializerOpenTSDBTelnet{dropped: dropped}
+}
+
+// SerializePoint writes Point data to the given writer, conforming to the
+// OpenTSDB telnet put command, one line per field, with millisecond
+// timestamps. As with the bulk load protocol, only numeric values are
+// supported: integers and booleans are converted, string fields are skipped.
+//
+// This function writes lines that look like:
+// put <measurement>.<field> <timestamp> <value> <tagk1=tagv1 ...>
+//
+// For example:
+// put cpu.usage_user 1451606400000 99.5170917755353770 hostname=host_01 region=ap-southeast-2 datacenter=ap-southeast-2a
+func (s *SerializerOpenTSDBTelnet) SerializePoint(w io.Writer, p *Point) (err error) {
+	tags := make([]byte, 0, 256)
+	for i := range p.TagKeys {
+		if containsKey(s.dropped, p.TagKeys[i]) {
+			continue
+		}
+		tags = append(tags, ' ')
+		tags = appendOpenTSDBName(tags, p.TagKeys[i])
+		tags = append(tags, '=')
+		tags = appendOpenTSDBName(tags, p.TagValues[i])
+	}
+
+	timestampMillis := p.Timestamp.UTC().UnixNano() / 1e6
+	buf := scratchBufPool.Get().([]byte)
+	for i := 0; i < len(p.FieldKeys); i++ {
+		value, ok := prometheusValue(p.FieldValues[i])
+		if !ok {
+			continue
+		}
+		buf = append(buf, "put "...)
+		buf = appendOpenTSDBName(buf, p.MeasurementName)
+		buf = append(buf, '.')
+		buf = appendOpenTSDBName(buf, p.FieldKeys[i])
+		buf = append(buf, ' ')
+		buf = strconv.AppendInt(buf, timestampMillis, 10)
+		buf = append(buf, ' ')
+		buf = fastFormatAppend(value, buf, false)
+		buf = append(buf, tags...)
+		buf = append(buf, '\n')
+	}
+
+	_, err = w.Write(buf)
+	scratchBufPool.Put(buf[:0])
+	return err
+}
+
+// appendOpenTSDBName appends a metric name, tag key or tag value, replacing
+// the characters OpenTSDB does not allow in them with underscores.
+func appendOpenTSDBName(buf []byte, name []byte) []byte {
+	for _, c := range name {
+		valid := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '/'
+		if !valid {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+func (s *SerializerOpenTSDBTelnet) SerializeSize(w io.Writer, points int64, values int64) error {
+	return nil
+}
+
+func (s *SerializerOpenTSDBTelnet) SerializeToCSV(w io.Writer, p *Point) error {
+	return nil
+}
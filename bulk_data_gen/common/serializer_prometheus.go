@@ -0,0 +1,207 @@
+package common
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/golang/snappy"
+)
+
+const prometheusMetricNameLabel = "__name__"
+
+type SerializerPrometheus struct {
+}
+
+func NewSerializerPrometheus() *SerializerPrometheus {
+	return &SerializerPrometheus{}
+}
+
+// SerializePoint writes Point data to the given writer, conforming to the
+// Prometheus remote-write protocol. Every field of the Point becomes one
+// series named <measurement>_<field name>, labeled with the Point's tags. All
+// series of a Point are packed into a single WriteRequest, which is snappy
+// (block format) compressed, as remote-write receivers expect.
+//
+// Each WriteRequest is preceded by its compressed length, so that a loader
+// can split the stream back into HTTP request bodies:
+// <8 byte little endian length><snappy compressed WriteRequest>
+//
+// Prometheus only supports float64 sample values with millisecond
+// timestamps. Integers, unsigned or not, and booleans are converted, string
+// fields are skipped.
+func (s *SerializerPrometheus) SerializePoint(w io.Writer, p *Point) (err error) {
+	labels := make([]prometheusLabel, 0, len(p.TagKeys)+1)
+	labels = append(labels, prometheusLabel{name: []byte(prometheusMetricNameLabel)})
+	for i := 0; i < len(p.TagKeys); i++ {
+		labels = append(labels, prometheusLabel{name: sanitizePrometheusName(p.TagKeys[i]), value: p.TagValues[i]})
+	}
+
+	// Remote-write receivers require the labels of a series to be sorted by name:
+	sort.Sort(prometheusLabelsByName(labels))
+	nameIdx := 0
+	for i := range labels {
+		if string(labels[i].name) == prometheusMetricNameLabel {
+			nameIdx = i
+			break
+		}
+	}
+
+	timestampMillis := p.Timestamp.UTC().UnixNano() / 1e6
+
+	req := bufPool.Get().([]byte)
+	series := scratchBufPool.Get().([]byte)
+	for i := 0; i < len(p.FieldKeys); i++ {
+		value, ok := prometheusValue(p.FieldValues[i])
+		if !ok {
+			continue
+		}
+
+		metricName := make([]byte, 0, len(p.MeasurementName)+len(p.FieldKeys[i])+1)
+		metricName = append(metricName, p.MeasurementName...)
+		metricName = append(metricName, '_')
+		metricName = append(metricName, p.FieldKeys[i]...)
+		labels[nameIdx].value = sanitizePrometheusName(metricName)
+
+		series = series[:0]
+		for _, l := range labels {
+			series = appendPrometheusLabel(series, l)
+		}
+		series = appendPrometheusSample(series, value, timestampMillis)
+
+		// WriteRequest.timeseries (field 1, length-delimited):
+		req = appendProtoBytes(req, 1, series)
+	}
+
+	compressed := snappy.Encode(nil, req)
+
+	lenBuf := bufPool8.Get().([]byte)
+	binary.LittleEndian.PutUint64(lenBuf, uint64(len(compressed)))
+	_, err = w.Write(lenBuf)
+	if err == nil {
+		_, err = w.Write(compressed)
+	}
+
+	bufPool8.Put(lenBuf)
+	scratchBufPool.Put(series[:0])
+	bufPool.Put(req[:0])
+
+	return err
+}
+
+func (s *SerializerPrometheus) SerializeSize(w io.Writer, points int64, values int64) error {
+	//return serializeSizeInText(w, points, values)
+	return nil
+}
+
+func (s *SerializerPrometheus) SerializeToCSV(w io.Writer, p *Point) error {
+	return nil
+}
+
+type prometheusLabel struct {
+	name, value []byte
+}
+
+type prometheusLabelsByName []prometheusLabel
+
+func (l prometheusLabelsByName) Len() int           { return len(l) }
+func (l prometheusLabelsByName) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l prometheusLabelsByName) Less(i, j int) bool { return string(l[i].name) < string(l[j].name) }
+
+func prometheusValue(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// sanitizePrometheusName replaces every byte not allowed in a Prometheus
+// metric or label name with an underscore.
+func sanitizePrometheusName(name []byte) []byte {
+	var out []byte
+	for i, c := range name {
+		valid := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')
+		if valid {
+			continue
+		}
+		if out == nil {
+			out = append([]byte{}, name...)
+		}
+		out[i] = '_'
+	}
+	if out == nil {
+		return name
+	}
+	return out
+}
+
+// TimeSeries.labels (field 1) holding a Label{name = 1, value = 2}.
+func appendPrometheusLabel(buf []byte, l prometheusLabel) []byte {
+	size := protoBytesSize(1, len(l.name)) + protoBytesSize(2, len(l.value))
+	buf = appendProtoKey(buf, 1, 2)
+	buf = appendUvarint(buf, uint64(size))
+	buf = appendProtoBytes(buf, 1, l.name)
+	buf = appendProtoBytes(buf, 2, l.value)
+	return buf
+}
+
+// TimeSeries.samples (field 2) holding a Sample{value = 1, timestamp = 2}.
+func appendPrometheusSample(buf []byte, value float64, timestampMillis int64) []byte {
+	size := 1 + 8 + 1 + uvarintSize(uint64(timestampMillis))
+	buf = appendProtoKey(buf, 2, 2)
+	buf = appendUvarint(buf, uint64(size))
+	buf = appendProtoKey(buf, 1, 1)
+	var valueBuf [8]byte
+	binary.LittleEndian.PutUint64(valueBuf[:], math.Float64bits(value))
+	buf = append(buf, valueBuf[:]...)
+	buf = appendProtoKey(buf, 2, 0)
+	buf = appendUvarint(buf, uint64(timestampMillis))
+	return buf
+}
+
+func appendProtoKey(buf []byte, field int, wireType int) []byte {
+	return appendUvarint(buf, uint64(field<<3|wireType))
+}
+
+func appendProtoBytes(buf []byte, field int, b []byte) []byte {
+	buf = appendProtoKey(buf, field, 2)
+	buf = appendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	var varintBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(varintBuf[:], x)
+	return append(buf, varintBuf[:n]...)
+}
+
+func protoBytesSize(field int, n int) int {
+	return uvarintSize(uint64(field<<3|2)) + uvarintSize(uint64(n)) + n
+}
+
+func uvarintSize(x uint64) int {
+	n := 1
+	for x >= 0x80 {
+		x >>= 7
+		n++
+	}
+	return n
+}
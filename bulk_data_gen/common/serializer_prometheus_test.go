@@ -0,0 +1,134 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+)
+
+// protoFields decodes the fields of a protobuf message: the length-delimited
+// ones as []byte, the 64-bit ones as uint64 and the varints as uint64.
+func protoFields(t *testing.T, msg []byte) (fields []int, values []interface{}) {
+	t.Helper()
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			t.Fatalf("invalid key in %x", msg)
+		}
+		msg = msg[n:]
+		fields = append(fields, int(key>>3))
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(msg)
+			if n <= 0 {
+				t.Fatalf("invalid varint in %x", msg)
+			}
+			values = append(values, v)
+			msg = msg[n:]
+		case 1:
+			values = append(values, binary.LittleEndian.Uint64(msg))
+			msg = msg[8:]
+		case 2:
+			size, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < size {
+				t.Fatalf("invalid length in %x", msg)
+			}
+			values = append(values, msg[n:n+int(size)])
+			msg = msg[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return fields, values
+}
+
+type prometheusTestSeries struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+func TestSerializerPrometheusWriteRequest(t *testing.T) {
+	ts := time.Date(2018, 1, 1, 0, 0, 1, 500e6, time.UTC)
+	p := MakeUsablePoint()
+	p.SetMeasurementName([]byte("disk"))
+	p.SetTimestamp(&ts)
+	p.AppendTag([]byte("hostname"), []byte("host_0"))
+	p.AppendTag([]byte("path"), []byte("/dev/sda1"))
+	p.AppendField([]byte("free"), int64(-5))
+	p.AppendField([]byte("used_percent"), 42.5)
+	p.AppendField([]byte("inodes_total"), uint64(1<<40))
+	p.AppendField([]byte("ok"), true)
+	p.AppendField([]byte("label"), []byte("skipped"))
+
+	var buf bytes.Buffer
+	if err := NewSerializerPrometheus().SerializePoint(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	size := binary.LittleEndian.Uint64(data)
+	if int(size) != len(data)-8 {
+		t.Fatalf("length prefix %d, for %d bytes", size, len(data)-8)
+	}
+	req, err := snappy.Decode(nil, data[8:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []prometheusTestSeries
+	fields, values := protoFields(t, req)
+	for i, field := range fields {
+		if field != 1 {
+			t.Fatalf("WriteRequest field %d", field)
+		}
+		s := prometheusTestSeries{labels: make(map[string]string)}
+		var names []string
+		seriesFields, seriesValues := protoFields(t, values[i].([]byte))
+		for j, seriesField := range seriesFields {
+			f, v := protoFields(t, seriesValues[j].([]byte))
+			switch seriesField {
+			case 1:
+				name, value := string(v[0].([]byte)), string(v[1].([]byte))
+				if f[0] != 1 || f[1] != 2 {
+					t.Fatalf("label fields %v", f)
+				}
+				s.labels[name] = value
+				names = append(names, name)
+			case 2:
+				if f[0] != 1 || f[1] != 2 {
+					t.Fatalf("sample fields %v", f)
+				}
+				s.value = math.Float64frombits(v[0].(uint64))
+				s.timestamp = int64(v[1].(uint64))
+			}
+		}
+		for j := 1; j < len(names); j++ {
+			if names[j-1] >= names[j] {
+				t.Errorf("labels not sorted by name: %v", names)
+			}
+		}
+		got = append(got, s)
+	}
+
+	series := func(name string, value float64) prometheusTestSeries {
+		return prometheusTestSeries{
+			labels:    map[string]string{"__name__": name, "hostname": "host_0", "path": "/dev/sda1"},
+			value:     value,
+			timestamp: 1514764801500,
+		}
+	}
+	want := []prometheusTestSeries{
+		series("disk_free", -5),
+		series("disk_used_percent", 42.5),
+		series("disk_inodes_total", 1<<40),
+		series("disk_ok", 1),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("series %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,154 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var serializerTestTime = time.Date(2018, 1, 1, 0, 0, 10, 0, time.UTC)
+
+// serializerTestPoint returns a point of the given fields, in the order of
+// names.
+func serializerTestPoint(names []string, fields map[string]interface{}) *Point {
+	p := MakeUsablePoint()
+	p.SetMeasurementName([]byte("m"))
+	p.AppendTag([]byte("host"), []byte("host_1"))
+	p.AppendTag([]byte("region"), []byte("eu"))
+	for _, name := range names {
+		p.AppendField([]byte(name), fields[name])
+	}
+	p.SetTimestamp(&serializerTestTime)
+	return p
+}
+
+func pointFields(p *Point) map[string]interface{} {
+	fields := make(map[string]interface{}, len(p.FieldKeys))
+	for i := range p.FieldKeys {
+		fields[string(p.FieldKeys[i])] = p.FieldValues[i]
+	}
+	return fields
+}
+
+func TestParseInfluxLineTypes(t *testing.T) {
+	p := MakeUsablePoint()
+	var ts time.Time
+	line := `m,host=host_1 i=-3i,u=1099511627776u,f=1.25,b=true,s="a \"b\"" 1514764810000000000`
+	if err := ParseInfluxLine([]byte(line), time.Nanosecond, p, &ts); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"i": int64(-3),
+		"u": uint64(1 << 40),
+		"f": 1.25,
+		"b": true,
+		"s": []byte(`a "b"`),
+	}
+	if got := pointFields(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields %#v, want %#v", got, want)
+	}
+	if !p.Timestamp.Equal(serializerTestTime) {
+		t.Errorf("timestamp %v, want %v", p.Timestamp, serializerTestTime)
+	}
+}
+
+func TestSerializerRoundTrip(t *testing.T) {
+	names := []string{"i", "u", "f", "b", "s"}
+	fields := map[string]interface{}{
+		"i": int64(-3),
+		"u": uint64(1 << 40),
+		"f": 1.25,
+		"b": true,
+		"s": []byte("a b"),
+	}
+	numeric := []string{"i", "u", "f", "b"}
+
+	for _, tc := range []struct {
+		format     string
+		serializer Serializer
+		names      []string
+		// the fields read back:
+		want map[string]interface{}
+	}{
+		{"influx-bulk", NewSerializerInflux(), names, fields},
+		{"tsdb", NewSerializerTSDB(), names, map[string]interface{}{
+			"i": int64(-3), "u": int64(1 << 40), "f": 1.25, "b": true, "s": []byte("a b"),
+		}},
+		{"cassandra", NewSerializerCassandra(), names, map[string]interface{}{
+			"i": int64(-3), "u": int64(1 << 40), "f": 1.25, "b": true, "s": []byte("a b"),
+		}},
+		{"opentsdb", NewSerializerOpenTSDB(), numeric, map[string]interface{}{
+			"i": -3.0, "u": float64(1 << 40), "f": 1.25, "b": 1.0,
+		}},
+	} {
+		var buf bytes.Buffer
+		if err := tc.serializer.SerializePoint(&buf, serializerTestPoint(tc.names, fields)); err != nil {
+			t.Errorf("%s: %v", tc.format, err)
+			continue
+		}
+		r, err := NewPointReader(&buf, tc.format, time.Nanosecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := MakeUsablePoint()
+		if err := r.ReadPoint(p); err != nil {
+			t.Errorf("%s: %v", tc.format, err)
+			continue
+		}
+		if got := pointFields(p); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: fields %#v, want %#v", tc.format, got, tc.want)
+		}
+		if got := string(p.MeasurementName); got != "m" {
+			t.Errorf("%s: measurement %s, want m", tc.format, got)
+		}
+		if !p.Timestamp.Equal(serializerTestTime) {
+			t.Errorf("%s: timestamp %v, want %v", tc.format, p.Timestamp, serializerTestTime)
+		}
+		if err := r.ReadPoint(MakeUsablePoint()); err != io.EOF {
+			t.Errorf("%s: after the point: %v", tc.format, err)
+		}
+	}
+}
+
+func TestSerializersUnsigned(t *testing.T) {
+	p := serializerTestPoint([]string{"u"}, map[string]interface{}{"u": uint64(1 << 40)})
+	for _, tc := range []struct {
+		format     string
+		serializer Serializer
+		binary     bool
+	}{
+		{"es-bulk", NewSerializerElastic(), false},
+		{"cassandra", NewSerializerCassandra(), false},
+		{"mongo", NewSerializerMongo(), true},
+		{"opentsdb", NewSerializerOpenTSDB(), false},
+		{"opentsdb-telnet", NewSerializerOpenTSDBTelnet(nil), false},
+		{"graphite", NewSerializerGraphite(GraphitePathMapping{}), false},
+		{"timescaledb-sql", NewSerializerTimescaleSql(), false},
+		{"timescaledb-copyFrom", NewSerializerTimescaleBin(), true},
+		{"tsdb", NewSerializerTSDB(), false},
+		{"prometheus", NewSerializerPrometheus(), true},
+		{"csv", NewSerializerCSV(CSVLayoutNarrow, ','), false},
+	} {
+		var buf bytes.Buffer
+		if err := tc.serializer.SerializePoint(&buf, p); err != nil {
+			t.Errorf("%s: %v", tc.format, err)
+			continue
+		}
+		if buf.Len() == 0 {
+			t.Errorf("%s: unsigned field dropped", tc.format)
+		} else if !tc.binary && !strings.Contains(buf.String(), "1099511627776") {
+			t.Errorf("%s: unsigned value missing from %q", tc.format, buf.String())
+		}
+	}
+}
+
+func TestSerializerOpenTSDBStringField(t *testing.T) {
+	p := serializerTestPoint([]string{"f", "message"}, map[string]interface{}{"f": 1.5, "message": []byte("hello")})
+	err := NewSerializerOpenTSDB().SerializePoint(&bytes.Buffer{}, p)
+	if err == nil || !strings.Contains(err.Error(), "field 'message' of measurement 'm'") {
+		t.Errorf("error %v, want one naming the string field", err)
+	}
+}
@@ -117,6 +117,14 @@ func (t *SerializerTimescaleBin) SerializePoint(w io.Writer, p *Point) (err erro
 			v.Type = timescale_serialization.FlatPoint_INTEGER
 			v.IntVal = int64(p.FieldValues[i].(int))
 			break
+		case uint64:
+			v.Type = timescale_serialization.FlatPoint_INTEGER
+			v.IntVal = int64(p.FieldValues[i].(uint64))
+			break
+		case uint:
+			v.Type = timescale_serialization.FlatPoint_INTEGER
+			v.IntVal = int64(p.FieldValues[i].(uint))
+			break
 		case float64:
 			v.Type = timescale_serialization.FlatPoint_FLOAT
 			v.DoubleVal = p.FieldValues[i].(float64)
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// ShardIndexFile is the name of the index written by bulk_data_gen in its
+// output directory.
+const ShardIndexFile = "index.json"
+
+// ShardIndex lists the files of a dataset written to a directory. Every
+// shard ends with its own dataset size marker, so that each can be loaded on
+// its own, concurrently with the others.
+type ShardIndex struct {
+	Format      string `json:"format"`
+	Compression string `json:"compression"`
+	// SplitBy is how points are spread over the shards: by count, by time,
+	// by host or by measurement (empty if there is a single shard).
+	SplitBy string  `json:"split_by,omitempty"`
+	Shards  []Shard `json:"shards"`
+}
+
+type Shard struct {
+	// File is the name of the shard, relative to the index.
+	File   string `json:"file"`
+	Points int64  `json:"points"`
+	Values int64  `json:"values"`
+	// MinTime and MaxTime bound the timestamps of the points of the shard,
+	// when known.
+	MinTime *time.Time `json:"min_time,omitempty"`
+	MaxTime *time.Time `json:"max_time,omitempty"`
+}
+
+// Add accounts for a point written to the shard.
+func (s *Shard) Add(p *Point) {
+	s.Points++
+	s.Values += int64(len(p.FieldValues))
+	t := *p.Timestamp
+	if s.MinTime == nil || t.Before(*s.MinTime) {
+		s.MinTime = &t
+	}
+	if s.MaxTime == nil || t.After(*s.MaxTime) {
+		s.MaxTime = &t
+	}
+}
+
+// ReadShardIndex reads the index of the dataset in dir.
+func ReadShardIndex(dir string) (*ShardIndex, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ShardIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	index := &ShardIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("cannot parse shard index in %s: %v", dir, err)
+	}
+	return index, nil
+}
+
+// WriteShardIndex writes the index of the dataset in dir.
+func WriteShardIndex(dir string, index *ShardIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, ShardIndexFile), append(data, '\n'), 0644)
+}
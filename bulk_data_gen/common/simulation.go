@@ -33,3 +33,36 @@ func MakeUsablePoint() *Point {
 		Timestamp:       &time.Time{},
 	}
 }
+
+// SlicedSimulator is a Simulator owning only a slice of the simulated
+// entities (hosts, smart homes, ...) of a use case. It emits its points in
+// rounds: every entity contributes at most one point to a round (or, for
+// events happening at random, its points of a time window). All slices
+// of a use case go through the same rounds, so merging their output round by
+// round, in slice order, restores the point order of a single Simulator.
+type SlicedSimulator interface {
+	Simulator
+	// Round returns the index of the round the point last filled by Next belongs to.
+	Round() int64
+	// Rounds returns the total number of rounds of the simulation.
+	Rounds() int64
+}
+
+// SliceBounds splits the range [0, n) into at most slices contiguous ranges
+// of nearly equal length, returned as {start, end} pairs.
+func SliceBounds(n int, slices int) [][2]int {
+	if slices > n {
+		slices = n
+	}
+	bounds := make([][2]int, 0, slices)
+	start := 0
+	for i := 0; i < slices; i++ {
+		end := start + n/slices
+		if i < n%slices {
+			end++
+		}
+		bounds = append(bounds, [2]int{start, end})
+		start = end
+	}
+	return bounds
+}
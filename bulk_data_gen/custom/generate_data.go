@@ -0,0 +1,307 @@
+package custom
+
+import (
+	"fmt"
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// The sampling interval of schemas not setting one.
+var DefaultSamplingInterval = 10 * time.Second
+
+// Type CustomSimulatorConfig is used to create a CustomSimulator.
+type CustomSimulatorConfig struct {
+	Start time.Time
+	End   time.Time
+
+	SourceCount  int64
+	SourceOffset int64
+
+	Schema *Schema
+
+	// SamplingInterval and MeasurementIntervals override the intervals set
+	// by the schema when not empty.
+	SamplingInterval     time.Duration
+	MeasurementIntervals map[string]time.Duration
+
+	// Rand seeds the random sources of the simulated sources.
+	Rand *rand.Rand
+}
+
+// Validate checks the sampling configuration.
+func (d *CustomSimulatorConfig) Validate() error {
+	_, err := d.measurementEvery()
+	return err
+}
+
+// EffectiveSamplingInterval returns the sampling interval, the one of the
+// schema if unset, or DefaultSamplingInterval.
+func (d *CustomSimulatorConfig) EffectiveSamplingInterval() time.Duration {
+	switch {
+	case d.SamplingInterval != 0:
+		return d.SamplingInterval
+	case d.Schema.SamplingInterval != 0:
+		return d.Schema.SamplingInterval
+	default:
+		return DefaultSamplingInterval
+	}
+}
+
+// measurementEvery returns the collection interval, in epochs, of every
+// measurement of the schema.
+func (d *CustomSimulatorConfig) measurementEvery() ([]int64, error) {
+	intervals := make(map[string]time.Duration)
+	names := make([][]byte, len(d.Schema.Measurements))
+	for i, m := range d.Schema.Measurements {
+		names[i] = []byte(m.Name)
+		if m.Interval != 0 {
+			intervals[m.Name] = m.Interval
+		}
+	}
+	for name, interval := range d.MeasurementIntervals {
+		intervals[name] = interval
+	}
+	multiples, err := SamplingMultiples(d.EffectiveSamplingInterval(), intervals, names)
+	if err != nil {
+		return nil, err
+	}
+	every := make([]int64, len(names))
+	for i, name := range names {
+		every[i] = 1
+		if k, ok := multiples[string(name)]; ok {
+			every[i] = k
+		}
+	}
+	return every, nil
+}
+
+func (d *CustomSimulatorConfig) ToSimulator() *CustomSimulator {
+	return d.newSimulator(d.newSources())
+}
+
+// ToSimulatorSlices creates simulators for up to n contiguous slices of the
+// sources, to be run in parallel. Merged round by round, in slice order,
+// their points are identical to the points of the simulator made by
+// ToSimulator.
+func (d *CustomSimulatorConfig) ToSimulatorSlices(n int) []*CustomSimulator {
+	sources := d.newSources()
+	bounds := SliceBounds(len(sources), n)
+
+	sims := make([]*CustomSimulator, len(bounds))
+	for i, b := range bounds {
+		sims[i] = d.newSimulator(sources[b[0]:b[1]])
+	}
+	return sims
+}
+
+func (d *CustomSimulatorConfig) newSources() []*Source {
+	seed := d.Rand.Int63()
+	sources := make([]*Source, d.SourceCount)
+	for i := 0; i < len(sources); i++ {
+		sources[i] = NewSource(NewRand(seed, int64(i)+d.SourceOffset), i, int(d.SourceOffset), d.Start, d.Schema)
+	}
+	return sources
+}
+
+func (d *CustomSimulatorConfig) newSimulator(sources []*Source) *CustomSimulator {
+	every, err := d.measurementEvery()
+	if err != nil {
+		panic(err.Error())
+	}
+	epochs := d.End.Sub(d.Start).Nanoseconds() / d.EffectiveSamplingInterval().Nanoseconds()
+	var sourcePoints int64
+	for _, k := range every {
+		sourcePoints += SampledPoints(epochs, k)
+	}
+	return &CustomSimulator{
+		madePoints: 0,
+		madeValues: 0,
+		maxPoints:  int64(len(sources)) * sourcePoints,
+
+		measurementIndex: 0,
+		epoch:            0,
+		rounds:           epochs * int64(len(every)),
+
+		every:            every,
+		samplingInterval: d.EffectiveSamplingInterval(),
+
+		sourceIndex: 0,
+		sources:     sources,
+	}
+}
+
+// Source is a simulated entity emitting every measurement of a schema.
+type Source struct {
+	SimulatedMeasurements []*Measurement
+}
+
+func NewSource(r *rand.Rand, id int, offset int, start time.Time, schema *Schema) *Source {
+	tagKeys, tagValues := drawTags(r, id+offset, schema.Tags, nil, nil)
+	s := &Source{SimulatedMeasurements: make([]*Measurement, len(schema.Measurements))}
+	for i := range schema.Measurements {
+		s.SimulatedMeasurements[i] = NewMeasurement(r, id+offset, start, &schema.Measurements[i], tagKeys, tagValues)
+	}
+	return s
+}
+
+// drawTags appends the keys and drawn values of the given tags.
+func drawTags(r *rand.Rand, id int, specs []TagSpec, keys, values [][]byte) ([][]byte, [][]byte) {
+	for _, t := range specs {
+		format := t.Format
+		if format == "" {
+			format = t.Key + "_%d"
+		}
+		var v string
+		switch {
+		case len(t.Values) > 0:
+			v = t.Values[r.Intn(len(t.Values))]
+		case t.Cardinality > 0:
+			v = fmt.Sprintf(format, r.Intn(t.Cardinality))
+		default:
+			v = fmt.Sprintf(format, id)
+		}
+		keys = append(keys, []byte(t.Key))
+		values = append(values, []byte(v))
+	}
+	return keys, values
+}
+
+// Measurement simulates one measurement of a source, following its spec.
+type Measurement struct {
+	name      []byte
+	tagKeys   [][]byte
+	tagValues [][]byte
+	fieldKeys [][]byte
+	fieldInts []bool
+
+	timestamp     time.Time
+	distributions []Distribution
+}
+
+func NewMeasurement(r *rand.Rand, id int, start time.Time, spec *MeasurementSpec, sourceTagKeys, sourceTagValues [][]byte) *Measurement {
+	m := &Measurement{
+		name:      []byte(spec.Name),
+		timestamp: start,
+	}
+	m.tagKeys, m.tagValues = drawTags(r, id, spec.Tags, append([][]byte{}, sourceTagKeys...), append([][]byte{}, sourceTagValues...))
+	for _, f := range spec.Fields {
+		m.fieldKeys = append(m.fieldKeys, []byte(f.Key))
+		m.fieldInts = append(m.fieldInts, f.Type == FieldTypeInt)
+		m.distributions = append(m.distributions, f.Distribution.New(r, &m.timestamp))
+	}
+	return m
+}
+
+func (m *Measurement) Tick(d time.Duration) {
+	m.timestamp = m.timestamp.Add(d)
+	for _, dist := range m.distributions {
+		dist.Advance()
+	}
+}
+
+func (m *Measurement) ToPoint(p *Point) bool {
+	p.SetMeasurementName(m.name)
+	p.SetTimestamp(&m.timestamp)
+
+	for i := range m.tagKeys {
+		p.AppendTag(m.tagKeys[i], m.tagValues[i])
+	}
+	for i, dist := range m.distributions {
+		if m.fieldInts[i] {
+			p.AppendField(m.fieldKeys[i], int64(math.Floor(dist.Get())))
+		} else {
+			p.AppendField(m.fieldKeys[i], dist.Get())
+		}
+	}
+	return true
+}
+
+// A CustomSimulator generates the data declared by a schema.
+// It fulfills the Simulator interface.
+type CustomSimulator struct {
+	madePoints int64
+	madeValues int64
+	maxPoints  int64
+
+	measurementIndex int
+	epoch            int64
+	rounds           int64
+
+	// collection intervals of the measurements, in epochs
+	every            []int64
+	samplingInterval time.Duration
+
+	sourceIndex int
+	sources     []*Source
+}
+
+func (g *CustomSimulator) SeenPoints() int64 {
+	return g.madePoints
+}
+
+func (g *CustomSimulator) SeenValues() int64 {
+	return g.madeValues
+}
+
+func (g *CustomSimulator) Total() int64 {
+	return g.maxPoints
+}
+
+func (g *CustomSimulator) Finished() bool {
+	return g.madePoints >= g.maxPoints
+}
+
+// Round returns the round of the last generated point. Each round holds one
+// measurement of every source.
+func (g *CustomSimulator) Round() int64 {
+	return g.epoch*int64(len(g.every)) + int64(g.measurementIndex)
+}
+
+func (g *CustomSimulator) Rounds() int64 {
+	return g.rounds
+}
+
+// Next advances a Point to the next state in the generator.
+func (g *CustomSimulator) Next(p *Point) {
+	// switch to the next measurement if needed
+	if g.sourceIndex == len(g.sources) {
+		g.sourceIndex = 0
+		g.nextMeasurement()
+	}
+
+	g.sources[g.sourceIndex].SimulatedMeasurements[g.measurementIndex].ToPoint(p)
+
+	g.madePoints++
+	g.sourceIndex++
+	g.madeValues += int64(len(p.FieldValues))
+}
+
+// nextMeasurement moves on to the next measurement collected in the current
+// epoch, advancing to the next epoch when needed.
+func (g *CustomSimulator) nextMeasurement() {
+	for {
+		g.measurementIndex++
+		if g.measurementIndex == len(g.every) {
+			g.measurementIndex = 0
+			g.epoch++
+			g.tickAll()
+		}
+		if g.epoch%g.every[g.measurementIndex] == 0 {
+			return
+		}
+	}
+}
+
+// tickAll advances all sources to the next epoch. Measurements not collected
+// in this epoch wait, and get advanced by their whole interval once they are.
+func (g *CustomSimulator) tickAll() {
+	for _, s := range g.sources {
+		for j, m := range s.SimulatedMeasurements {
+			if g.epoch%g.every[j] == 0 {
+				m.Tick(g.samplingInterval * time.Duration(g.every[j]))
+			}
+		}
+	}
+}
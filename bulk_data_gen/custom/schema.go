@@ -0,0 +1,298 @@
+package custom
+
+import (
+	"fmt"
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"math/rand"
+	"time"
+)
+
+// Schema declares a custom use case. An example schema:
+//
+//	sampling_interval: 10s
+//	tags:
+//	  - key: hostname          # unique to each source: hostname_0, hostname_1, ...
+//	  - key: region
+//	    values: [us-east-1, us-west-1, eu-central-1]
+//	  - key: rack
+//	    cardinality: 50        # one of rack_0 ... rack_49
+//	measurements:
+//	  - name: temperature
+//	    interval: 1m           # a multiple of the sampling interval
+//	    tags:
+//	      - key: sensor
+//	        cardinality: 4
+//	        format: sensor-%02d
+//	    fields:
+//	      - key: celsius
+//	        distribution: {type: cwd, min: -20, max: 45, state: 20, step: {type: nd, mean: 0, stddev: 0.5}}
+//	      - key: errors
+//	        type: int
+//	        distribution: {type: mwd, step: {type: ud, low: 0, high: 2}}
+//
+// The simulator runs scale_var sources, each emitting every measurement once
+// per interval. The tag values of a source are drawn when it is created.
+type Schema struct {
+	SamplingInterval time.Duration     `yaml:"sampling_interval"`
+	Tags             []TagSpec         `yaml:"tags"`
+	Measurements     []MeasurementSpec `yaml:"measurements"`
+}
+
+// MeasurementSpec declares a measurement. Its points carry the tags of the
+// schema followed by its own tags.
+type MeasurementSpec struct {
+	Name     string        `yaml:"name"`
+	Interval time.Duration `yaml:"interval"`
+	Tags     []TagSpec     `yaml:"tags"`
+	Fields   []FieldSpec   `yaml:"fields"`
+}
+
+// TagSpec declares a tag key and its value pool: either the given Values,
+// or Cardinality values made from Format (<key>_%d by default). A tag without
+// values nor cardinality gets a value unique to each source.
+type TagSpec struct {
+	Key         string   `yaml:"key"`
+	Values      []string `yaml:"values"`
+	Cardinality int      `yaml:"cardinality"`
+	Format      string   `yaml:"format"`
+}
+
+// FieldSpec declares a field, of type float (the default) or int, and the
+// distribution of its values.
+type FieldSpec struct {
+	Key          string            `yaml:"key"`
+	Type         string            `yaml:"type"`
+	Distribution *DistributionSpec `yaml:"distribution"`
+}
+
+// DistributionSpec binds a field to one of the distributions of the common
+// package. The parameters used depend on the type:
+//
+//	nd:       mean, stddev
+//	ud:       low, high
+//	wd:       step, state
+//	cwd:      step, min, max, state
+//	mwd:      step, state
+//	mudwd:    step, min, max, state
+//	tsd:      low, high, state
+//	constant: value
+//	periodic: period, mean, amplitude, phase (radians), noise (optional)
+//	sum:      parts
+//
+// Periodic distributions follow the timestamps of their measurement, e.g. a
+// daily cycle peaking at 14:00 UTC, with some jitter:
+//
+//	{type: sum, parts: [{type: periodic, period: 24h, mean: 50, amplitude: 30, phase: -2.094}, {type: nd, stddev: 2}]}
+type DistributionSpec struct {
+	Type   string            `yaml:"type"`
+	Mean   float64           `yaml:"mean"`
+	StdDev float64           `yaml:"stddev"`
+	Low    float64           `yaml:"low"`
+	High   float64           `yaml:"high"`
+	Min    float64           `yaml:"min"`
+	Max    float64           `yaml:"max"`
+	State  float64           `yaml:"state"`
+	Value  float64           `yaml:"value"`
+	Step   *DistributionSpec `yaml:"step"`
+
+	Period    time.Duration      `yaml:"period"`
+	Amplitude float64            `yaml:"amplitude"`
+	Phase     float64            `yaml:"phase"`
+	Noise     *DistributionSpec  `yaml:"noise"`
+	Parts     []DistributionSpec `yaml:"parts"`
+}
+
+const (
+	FieldTypeFloat = "float"
+	FieldTypeInt   = "int"
+)
+
+// LoadSchema reads and validates a schema file.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{}
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, fmt.Errorf("cannot parse schema %s: %v", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %v", path, err)
+	}
+	return s, nil
+}
+
+func (s *Schema) Validate() error {
+	if s.SamplingInterval < 0 {
+		return fmt.Errorf("sampling interval must be positive, got %v", s.SamplingInterval)
+	}
+	if len(s.Measurements) == 0 {
+		return fmt.Errorf("no measurements")
+	}
+	if err := validateTags(s.Tags, map[string]bool{}); err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for _, m := range s.Measurements {
+		if m.Name == "" {
+			return fmt.Errorf("measurement without name")
+		}
+		if names[m.Name] {
+			return fmt.Errorf("duplicate measurement '%s'", m.Name)
+		}
+		names[m.Name] = true
+
+		if m.Interval < 0 {
+			return fmt.Errorf("measurement '%s': interval must be positive, got %v", m.Name, m.Interval)
+		}
+		keys := map[string]bool{}
+		for _, t := range s.Tags {
+			keys[t.Key] = true
+		}
+		if err := validateTags(m.Tags, keys); err != nil {
+			return fmt.Errorf("measurement '%s': %v", m.Name, err)
+		}
+		if len(m.Fields) == 0 {
+			return fmt.Errorf("measurement '%s': no fields", m.Name)
+		}
+		fields := map[string]bool{}
+		for _, f := range m.Fields {
+			if f.Key == "" {
+				return fmt.Errorf("measurement '%s': field without key", m.Name)
+			}
+			if fields[f.Key] {
+				return fmt.Errorf("measurement '%s': duplicate field '%s'", m.Name, f.Key)
+			}
+			fields[f.Key] = true
+			if f.Type != "" && f.Type != FieldTypeFloat && f.Type != FieldTypeInt {
+				return fmt.Errorf("measurement '%s', field '%s': unknown type '%s'", m.Name, f.Key, f.Type)
+			}
+			if f.Distribution == nil {
+				return fmt.Errorf("measurement '%s', field '%s': no distribution", m.Name, f.Key)
+			}
+			if err := f.Distribution.Validate(); err != nil {
+				return fmt.Errorf("measurement '%s', field '%s': %v", m.Name, f.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateTags checks tag specs, whose keys must not be in seen already.
+func validateTags(tags []TagSpec, seen map[string]bool) error {
+	for _, t := range tags {
+		if t.Key == "" {
+			return fmt.Errorf("tag without key")
+		}
+		if seen[t.Key] {
+			return fmt.Errorf("duplicate tag '%s'", t.Key)
+		}
+		seen[t.Key] = true
+		if t.Cardinality < 0 {
+			return fmt.Errorf("tag '%s': negative cardinality", t.Key)
+		}
+		if len(t.Values) > 0 && t.Cardinality > 0 {
+			return fmt.Errorf("tag '%s': both values and cardinality set", t.Key)
+		}
+	}
+	return nil
+}
+
+func (d *DistributionSpec) Validate() error {
+	switch d.Type {
+	case "nd":
+		if d.StdDev < 0 {
+			return fmt.Errorf("nd: negative stddev")
+		}
+	case "ud", "tsd":
+		if d.Low > d.High {
+			return fmt.Errorf("%s: low is above high", d.Type)
+		}
+	case "wd", "mwd":
+		return d.validateStep()
+	case "cwd", "mudwd":
+		if d.Min > d.Max {
+			return fmt.Errorf("%s: min is above max", d.Type)
+		}
+		return d.validateStep()
+	case "constant":
+	case "periodic":
+		if _, err := NewPeriodicDistribution(nil, d.Period, d.Mean, d.Amplitude, d.Phase, nil); err != nil {
+			return fmt.Errorf("periodic: %v", err)
+		}
+		if d.Noise != nil {
+			if err := d.Noise.Validate(); err != nil {
+				return fmt.Errorf("periodic noise: %v", err)
+			}
+		}
+	case "sum":
+		if len(d.Parts) == 0 {
+			return fmt.Errorf("sum: no parts")
+		}
+		for i := range d.Parts {
+			if err := d.Parts[i].Validate(); err != nil {
+				return fmt.Errorf("sum part %d: %v", i, err)
+			}
+		}
+	case "":
+		return fmt.Errorf("distribution without type")
+	default:
+		return fmt.Errorf("unknown distribution type '%s'", d.Type)
+	}
+	return nil
+}
+
+func (d *DistributionSpec) validateStep() error {
+	if d.Step == nil {
+		return fmt.Errorf("%s: no step distribution", d.Type)
+	}
+	if err := d.Step.Validate(); err != nil {
+		return fmt.Errorf("%s step: %v", d.Type, err)
+	}
+	return nil
+}
+
+// New makes the distribution, drawing its random values from r. Periodic
+// distributions read the time from clock. The stateless distributions draw
+// their first value, which is otherwise 0 until they are advanced.
+func (d *DistributionSpec) New(r *rand.Rand, clock *time.Time) Distribution {
+	switch d.Type {
+	case "nd":
+		dist := ND(r, d.Mean, d.StdDev)
+		dist.Advance()
+		return dist
+	case "ud":
+		dist := UD(r, d.Low, d.High)
+		dist.Advance()
+		return dist
+	case "wd":
+		return WD(d.Step.New(r, clock), d.State)
+	case "cwd":
+		return CWD(d.Step.New(r, clock), d.Min, d.Max, d.State)
+	case "mwd":
+		return MWD(d.Step.New(r, clock), d.State)
+	case "mudwd":
+		return MUDWD(d.Step.New(r, clock), d.Min, d.Max, d.State)
+	case "tsd":
+		return TSD(r, d.Low, d.High, d.State)
+	case "constant":
+		return &ConstantDistribution{State: d.Value}
+	case "periodic":
+		var noise Distribution
+		if d.Noise != nil {
+			noise = d.Noise.New(r, clock)
+		}
+		return PD(clock, d.Period, d.Mean, d.Amplitude, d.Phase, noise)
+	case "sum":
+		parts := make([]Distribution, len(d.Parts))
+		for i := range d.Parts {
+			parts[i] = d.Parts[i].New(r, clock)
+		}
+		return SD(parts...)
+	default:
+		panic(fmt.Sprintf("logic error: unknown distribution type '%s'", d.Type))
+	}
+}
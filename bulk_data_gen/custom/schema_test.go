@@ -0,0 +1,98 @@
+package custom
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+const boundsSchema = `
+sampling_interval: 10s
+tags:
+  - key: hostname
+measurements:
+  - name: m
+    fields:
+      - key: ud
+        distribution: {type: ud, low: 10, high: 20}
+      - key: ud_int
+        type: int
+        distribution: {type: ud, low: 10, high: 20}
+      - key: nd
+        distribution: {type: nd, mean: 50, stddev: 1}
+      - key: sum
+        distribution: {type: sum, parts: [{type: constant, value: 5}, {type: ud, low: 1, high: 2}]}
+      - key: periodic
+        distribution: {type: periodic, period: 1h, mean: 50, amplitude: 10, noise: {type: ud, low: 1, high: 2}}
+      - key: cwd
+        distribution: {type: cwd, min: 0, max: 10, state: 5, step: {type: ud, low: -1, high: 1}}
+`
+
+func loadTestSchema(t *testing.T, data string) *Schema {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "schema.yaml")
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := LoadSchema(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestFirstPointValuesWithinBounds(t *testing.T) {
+	bounds := map[string][2]float64{
+		"ud":       {10, 20},
+		"ud_int":   {10, 20},
+		"nd":       {40, 60},
+		"sum":      {6, 7},
+		"periodic": {41, 62},
+		"cwd":      {0, 10},
+	}
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := &CustomSimulatorConfig{
+		Start:       start,
+		End:         start.Add(time.Minute),
+		SourceCount: 20,
+		Schema:      loadTestSchema(t, boundsSchema),
+		Rand:        rand.New(rand.NewSource(42)),
+	}
+	sim := cfg.ToSimulator()
+	p := common.MakeUsablePoint()
+	first := 0
+	for !sim.Finished() {
+		sim.Next(p)
+		if !p.Timestamp.Equal(start) {
+			break
+		}
+		first++
+		for i, key := range p.FieldKeys {
+			var v float64
+			switch x := p.FieldValues[i].(type) {
+			case int64:
+				v = float64(x)
+			case float64:
+				v = x
+			}
+			b := bounds[string(key)]
+			if v < b[0] || v > b[1] {
+				t.Errorf("first point of %s: %s=%v, not within [%v, %v]", p.TagValues[0], key, v, b[0], b[1])
+			}
+		}
+		p.Reset()
+	}
+	if first != 20 {
+		t.Errorf("%d first points, want 20", first)
+	}
+}
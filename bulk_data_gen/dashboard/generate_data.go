@@ -3,6 +3,7 @@ package dashboard
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
 	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/devops"
+	"math/rand"
 	"time"
 )
 
@@ -14,6 +15,8 @@ type DashboardSimulator struct {
 	maxPoints  int64
 
 	simulatedMeasurementIndex int
+	round                     int64
+	rounds                    int64
 
 	hostIndex int
 	hosts     []Host
@@ -39,6 +42,16 @@ func (g *DashboardSimulator) Finished() bool {
 	return g.madePoints >= g.maxPoints
 }
 
+// Round returns the round of the last generated point. Each round holds one
+// measurement of every host.
+func (g *DashboardSimulator) Round() int64 {
+	return g.round
+}
+
+func (g *DashboardSimulator) Rounds() int64 {
+	return g.rounds
+}
+
 // Type DashboardSimulatorConfig is used to create a DashboardSimulator.
 type DashboardSimulatorConfig struct {
 	Start time.Time
@@ -46,25 +59,53 @@ type DashboardSimulatorConfig struct {
 
 	HostCount  int64
 	HostOffset int64
+
+	// Rand seeds the random sources of the hosts.
+	Rand *rand.Rand
 }
 
 func (d *DashboardSimulatorConfig) ToSimulator() *DashboardSimulator {
+	return d.newSimulator(d.newHosts())
+}
+
+// ToSimulatorSlices creates simulators for up to n contiguous slices of the
+// hosts, to be run in parallel. Merged round by round, in slice order, their
+// points are identical to the points of the simulator made by ToSimulator.
+func (d *DashboardSimulatorConfig) ToSimulatorSlices(n int) []*DashboardSimulator {
+	hosts := d.newHosts()
+	bounds := SliceBounds(len(hosts), n)
+
+	sims := make([]*DashboardSimulator, len(bounds))
+	for i, b := range bounds {
+		sims[i] = d.newSimulator(hosts[b[0]:b[1]])
+	}
+	return sims
+}
+
+func (d *DashboardSimulatorConfig) newHosts() []Host {
+	seed := d.Rand.Int63()
+	resetClusters()
 	hostInfos := make([]Host, d.HostCount)
 	for i := 0; i < len(hostInfos); i++ {
-		hostInfos[i] = NewHost(i, int(d.HostOffset), d.Start)
+		hostInfos[i] = NewHost(NewRand(seed, int64(i)+d.HostOffset), i, int(d.HostOffset), d.Start)
 	}
+	return hostInfos
+}
 
+func (d *DashboardSimulatorConfig) newSimulator(hosts []Host) *DashboardSimulator {
 	epochs := d.End.Sub(d.Start).Nanoseconds() / devops.EpochDuration.Nanoseconds()
-	maxPoints := epochs * (d.HostCount * NHostSims)
+	maxPoints := epochs * (int64(len(hosts)) * NHostSims)
 	dg := &DashboardSimulator{
 		madePoints: 0,
 		madeValues: 0,
 		maxPoints:  maxPoints,
 
 		simulatedMeasurementIndex: 0,
+		round:                     0,
+		rounds:                    epochs * NHostSims,
 
 		hostIndex: 0,
-		hosts:     hostInfos,
+		hosts:     hosts,
 
 		timestampNow:   d.Start,
 		timestampStart: d.Start,
@@ -80,14 +121,13 @@ func (d *DashboardSimulator) Next(p *Point) {
 	if d.hostIndex == len(d.hosts) {
 		d.hostIndex = 0
 		d.simulatedMeasurementIndex++
+		d.round++
 	}
 
 	if d.simulatedMeasurementIndex == NHostSims {
 		d.simulatedMeasurementIndex = 0
 
-		for i := 0; i < len(d.hosts); i++ {
-			d.hosts[i].TickAll(devops.EpochDuration)
-		}
+		d.tickAll()
 	}
 
 	host := &d.hosts[d.hostIndex]
@@ -113,3 +153,10 @@ func (d *DashboardSimulator) Next(p *Point) {
 
 	return
 }
+
+// tickAll advances all hosts to the next epoch.
+func (d *DashboardSimulator) tickAll() {
+	for i := 0; i < len(d.hosts); i++ {
+		d.hosts[i].TickAll(devops.EpochDuration)
+	}
+}
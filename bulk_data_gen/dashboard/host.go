@@ -23,19 +23,19 @@ type Host struct {
 	ClusterId, Service, ServiceVersion, ServiceEnvironment []byte
 }
 
-func NewHostMeasurements(start time.Time) []SimulatedMeasurement {
+func NewHostMeasurements(r *rand.Rand, start time.Time) []SimulatedMeasurement {
 	sm := []SimulatedMeasurement{
-		devops.NewCPUMeasurement(start),
-		devops.NewDiskIOMeasurement(start),
-		devops.NewDiskMeasurement(start),
-		devops.NewKernelMeasurement(start),
-		devops.NewMemMeasurement(start),
-		devops.NewNetMeasurement(start),
-		devops.NewNginxMeasurement(start),
-		devops.NewPostgresqlMeasurement(start),
-		devops.NewRedisMeasurement(start),
-		NewSystemMeasurement(start),
-		NewStatusMeasurement(start),
+		devops.NewCPUMeasurement(r, start),
+		devops.NewDiskIOMeasurement(r, start),
+		devops.NewDiskMeasurement(r, start),
+		devops.NewKernelMeasurement(r, start),
+		devops.NewMemMeasurement(r, start),
+		devops.NewNetMeasurement(r, start),
+		devops.NewNginxMeasurement(r, start),
+		devops.NewPostgresqlMeasurement(r, start),
+		devops.NewRedisMeasurement(r, start),
+		NewSystemMeasurement(r, start),
+		NewStatusMeasurement(r, start),
 	}
 
 	if len(sm) != NHostSims {
@@ -44,18 +44,26 @@ func NewHostMeasurements(start time.Time) []SimulatedMeasurement {
 	return sm
 }
 
+// cluster of the hosts being created, by NewHost:
 var (
 	curentClusterSize int
 	clusterId         int
 	currentHostIndex  int
 )
 
-func NewHost(i int, offset int, start time.Time) Host {
+// resetClusters makes NewHost start a new set of hosts from the first cluster.
+func resetClusters() {
+	curentClusterSize = 0
+	clusterId = 0
+	currentHostIndex = 0
+}
+
+func NewHost(r *rand.Rand, i int, offset int, start time.Time) Host {
 	var hostname []byte
 	if i > 0 {
 		if curentClusterSize == 0 || currentHostIndex == curentClusterSize {
 			currentHostIndex = 0
-			curentClusterSize = ClusterSizes[rand.Intn(len(ClusterSizes))]
+			curentClusterSize = ClusterSizes[r.Intn(len(ClusterSizes))]
 			clusterId++
 		}
 
@@ -67,22 +75,22 @@ func NewHost(i int, offset int, start time.Time) Host {
 	} else {
 		hostname = []byte(fmt.Sprintf("kapacitor_%d", 1+offset))
 	}
-	sm := NewHostMeasurements(start)
+	sm := NewHostMeasurements(r, start)
 
-	region := &devops.Regions[rand.Intn(len(devops.Regions))]
-	rackId := rand.Int63n(devops.MachineRackChoicesPerDatacenter)
-	serviceId := rand.Int63n(devops.MachineServiceChoices)
-	serviceVersionId := rand.Int63n(devops.MachineServiceVersionChoices)
-	serviceEnvironment := RandChoice(devops.MachineServiceEnvironmentChoices)
+	region := &devops.Regions[r.Intn(len(devops.Regions))]
+	rackId := r.Int63n(devops.MachineRackChoicesPerDatacenter)
+	serviceId := r.Int63n(devops.MachineServiceChoices)
+	serviceVersionId := r.Int63n(devops.MachineServiceVersionChoices)
+	serviceEnvironment := RandChoice(r, devops.MachineServiceEnvironmentChoices)
 
 	h := Host{
 		// Tag Values that are static throughout the life of a Host:
 		Name:               hostname,
 		Region:             []byte(fmt.Sprintf("%s", region.Name)),
-		Datacenter:         RandChoice(region.Datacenters),
+		Datacenter:         RandChoice(r, region.Datacenters),
 		Rack:               []byte(fmt.Sprintf("%d", rackId)),
-		Arch:               RandChoice(devops.MachineArchChoices),
-		OS:                 RandChoice(devops.MachineOSChoices),
+		Arch:               RandChoice(r, devops.MachineArchChoices),
+		OS:                 RandChoice(r, devops.MachineOSChoices),
 		Service:            []byte(fmt.Sprintf("%d", serviceId)),
 		ServiceVersion:     []byte(fmt.Sprintf("%d", serviceVersionId)),
 		ServiceEnvironment: serviceEnvironment,
@@ -2,6 +2,7 @@ package dashboard
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -16,9 +17,9 @@ type StatusMeasurement struct {
 	serviceUp Distribution
 }
 
-func NewStatusMeasurement(start time.Time) *StatusMeasurement {
+func NewStatusMeasurement(r *rand.Rand, start time.Time) *StatusMeasurement {
 	//state
-	serviceUp := TSD(0, 1, 0)
+	serviceUp := TSD(r, 0, 1, 0)
 
 	return &StatusMeasurement{
 		timestamp: start,
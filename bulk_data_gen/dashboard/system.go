@@ -28,17 +28,18 @@ type SystemMeasurement struct {
 	distributions []Distribution
 }
 
-func NewSystemMeasurement(start time.Time) *SystemMeasurement {
+func NewSystemMeasurement(r *rand.Rand, start time.Time) *SystemMeasurement {
 	distributions := make([]Distribution, len(LoadFieldKeys))
-	ncpus := CPUsCount[rand.Intn(len(CPUsCount))]
+	ncpus := CPUsCount[r.Intn(len(CPUsCount))]
 	for i := range distributions {
 		distributions[i] = &ClampedRandomWalkDistribution{
-			State: rand.Float64() * 100.0 * float64(ncpus),
+			State: r.Float64() * 100.0 * float64(ncpus),
 			Min:   0.0,
-			Max:   float64(ncpus) * (1 + rand.Float64()),
+			Max:   float64(ncpus) * (1 + r.Float64()),
 			Step: &NormalDistribution{
 				Mean:   0.0,
 				StdDev: 10.0,
+				Rand:   r,
 			},
 		}
 	}
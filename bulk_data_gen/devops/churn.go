@@ -0,0 +1,91 @@
+package devops
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultChurnPeriod is the host replacement period used when none is set.
+const DefaultChurnPeriod = time.Hour
+
+// HostChurn models autoscaled fleets, whose hosts keep being replaced: at
+// the start of every Period after Start, Rate of the HostCount hosts are
+// retired, the longest running ones first, and replaced by new hosts.
+//
+// The hosts run in HostCount slots. The initial host of slot s is
+// host_<HostOffset+s>; the i-th replacement overall (counting from 0) takes
+// slot i%HostCount and is named host_<HostOffset+HostCount+i>.
+type HostChurn struct {
+	Start      time.Time
+	HostCount  int64
+	HostOffset int64
+
+	Rate   float64
+	Period time.Duration
+}
+
+func (c *HostChurn) Enabled() bool {
+	return c.ReplacedPerPeriod() > 0
+}
+
+func (c *HostChurn) Validate() error {
+	if c.Rate < 0 || c.Rate > 1 {
+		return fmt.Errorf("churn rate must be between 0 and 1, got %v", c.Rate)
+	}
+	if c.Rate > 0 && c.Period <= 0 {
+		return fmt.Errorf("churn period must be positive, got %v", c.Period)
+	}
+	return nil
+}
+
+// ReplacedPerPeriod returns the number of hosts replaced every period.
+func (c *HostChurn) ReplacedPerPeriod() int64 {
+	n := int64(c.Rate*float64(c.HostCount) + 0.5)
+	if n > c.HostCount {
+		n = c.HostCount
+	}
+	return n
+}
+
+// PeriodAt returns the index of the period the given time falls in.
+func (c *HostChurn) PeriodAt(t time.Time) int64 {
+	if !t.After(c.Start) {
+		return 0
+	}
+	return int64(t.Sub(c.Start) / c.Period)
+}
+
+// HostId returns the number n of the host_<n> running in the given slot
+// during the given period.
+func (c *HostChurn) HostId(slot int64, period int64) int64 {
+	n := c.ReplacedPerPeriod()
+	// the last replacement done by the start of the period:
+	last := period*n - 1
+	if last < slot {
+		return c.HostOffset + slot
+	}
+	i := slot + (last-slot)/c.HostCount*c.HostCount
+	return c.HostOffset + c.HostCount + i
+}
+
+// LiveHosts returns the numbers of the hosts having data in the time
+// interval [start, end).
+func (c *HostChurn) LiveHosts(start, end time.Time) []int64 {
+	first, last := int64(0), int64(0)
+	if c.Enabled() {
+		first = c.PeriodAt(start)
+		last = c.PeriodAt(end.Add(-time.Nanosecond))
+	}
+	ids := make([]int64, 0, c.HostCount)
+	for slot := int64(0); slot < c.HostCount; slot++ {
+		prev := int64(-1)
+		for period := first; period <= last; period++ {
+			id := c.HostId(slot, period)
+			if id != prev {
+				ids = append(ids, id)
+				prev = id
+			}
+		}
+	}
+	return ids
+}
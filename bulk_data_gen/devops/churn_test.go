@@ -0,0 +1,110 @@
+package devops
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+var churnTestStart = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestHostChurnHostId(t *testing.T) {
+	// 2 of the 4 hosts are replaced every hour:
+	c := &HostChurn{Start: churnTestStart, HostCount: 4, HostOffset: 10, Rate: 0.5, Period: time.Hour}
+	want := [][]int64{
+		{10, 11, 12, 13},
+		{14, 15, 12, 13},
+		{14, 15, 16, 17},
+		{18, 19, 16, 17},
+	}
+	for period, ids := range want {
+		for slot, id := range ids {
+			if got := c.HostId(int64(slot), int64(period)); got != id {
+				t.Errorf("period %d, slot %d: host_%d, want host_%d", period, slot, got, id)
+			}
+		}
+	}
+
+	disabled := &HostChurn{Start: churnTestStart, HostCount: 4, HostOffset: 10, Period: time.Hour}
+	if disabled.Enabled() {
+		t.Error("churn without rate is enabled")
+	}
+	if got := disabled.HostId(3, 5); got != 13 {
+		t.Errorf("without churn, slot 3 runs host_%d, want host_13", got)
+	}
+}
+
+func TestHostChurnLiveHosts(t *testing.T) {
+	c := &HostChurn{Start: churnTestStart, HostCount: 4, HostOffset: 10, Rate: 0.5, Period: time.Hour}
+	for _, tc := range []struct {
+		start, end time.Duration
+		want       []int64
+	}{
+		{0, time.Hour, []int64{10, 11, 12, 13}},
+		{30 * time.Minute, 90 * time.Minute, []int64{10, 14, 11, 15, 12, 13}},
+		{time.Hour, 2 * time.Hour, []int64{14, 15, 12, 13}},
+		{0, 4 * time.Hour, []int64{10, 14, 18, 11, 15, 19, 12, 16, 13, 17}},
+	} {
+		got := c.LiveHosts(churnTestStart.Add(tc.start), churnTestStart.Add(tc.end))
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("live hosts from %v to %v: %v, want %v", tc.start, tc.end, got, tc.want)
+		}
+	}
+}
+
+// TestHostChurnSimulator checks that the hosts the simulator emits points of
+// in each period are the live hosts of the period.
+func TestHostChurnSimulator(t *testing.T) {
+	cfg := &DevopsSimulatorConfig{
+		Start:       churnTestStart,
+		End:         churnTestStart.Add(3 * time.Hour),
+		HostCount:   5,
+		HostOffset:  7,
+		ChurnRate:   0.4,
+		ChurnPeriod: time.Hour,
+		Rand:        rand.New(rand.NewSource(42)),
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	churn := cfg.HostChurn()
+
+	emitted := make(map[int64]map[string]bool)
+	sim := cfg.ToSimulator()
+	p := common.MakeUsablePoint()
+	for !sim.Finished() {
+		sim.Next(p)
+		period := churn.PeriodAt(*p.Timestamp)
+		if emitted[period] == nil {
+			emitted[period] = make(map[string]bool)
+		}
+		for i, key := range p.TagKeys {
+			if string(key) == string(MachineTagKeys[0]) {
+				emitted[period][string(p.TagValues[i])] = true
+			}
+		}
+		p.Reset()
+	}
+
+	for period := int64(0); period < 3; period++ {
+		start := churnTestStart.Add(time.Duration(period) * time.Hour)
+		var want []string
+		for _, id := range churn.LiveHosts(start, start.Add(time.Hour)) {
+			want = append(want, fmt.Sprintf("host_%d", id))
+		}
+		var got []string
+		for name := range emitted[period] {
+			got = append(got, name)
+		}
+		sort.Strings(want)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("period %d: points of %v, want %v", period, got, want)
+		}
+	}
+}
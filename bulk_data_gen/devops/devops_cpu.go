@@ -2,6 +2,7 @@ package devops
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math"
 	"math/rand"
 	"time"
 )
@@ -25,6 +26,10 @@ var (
 		[]byte("usage_guest"),
 		[]byte("usage_guest_nice"),
 	}
+
+	// Daily amplitudes of the 'cpu' fields of hosts following business
+	// hours. usage_idle is at its lowest when the others peak.
+	CPUDiurnalAmplitudes = []float64{25, 10, 25, 5, 5, 5, 5, 5, 5, 5}
 )
 
 type CPUMeasurement struct {
@@ -32,16 +37,17 @@ type CPUMeasurement struct {
 	distributions []Distribution
 }
 
-func NewCPUMeasurement(start time.Time) *CPUMeasurement {
+func NewCPUMeasurement(r *rand.Rand, start time.Time) *CPUMeasurement {
 	distributions := make([]Distribution, len(CPUFieldKeys))
 	for i := range distributions {
 		distributions[i] = &ClampedRandomWalkDistribution{
-			State: rand.Float64() * 100.0,
+			State: r.Float64() * 100.0,
 			Min:   0.0,
 			Max:   100.0,
 			Step: &NormalDistribution{
 				Mean:   0.0,
 				StdDev: 1.0,
+				Rand:   r,
 			},
 		}
 	}
@@ -51,6 +57,29 @@ func NewCPUMeasurement(start time.Time) *CPUMeasurement {
 	}
 }
 
+// NewDiurnalCPUMeasurement makes a CPUMeasurement following business hours:
+// each field is a daily sinusoid peaking at BusinessHoursPeak, plus a random
+// walk.
+func NewDiurnalCPUMeasurement(r *rand.Rand, start time.Time) *CPUMeasurement {
+	m := &CPUMeasurement{
+		timestamp:     start,
+		distributions: make([]Distribution, len(CPUFieldKeys)),
+	}
+	for i := range m.distributions {
+		amplitude := CPUDiurnalAmplitudes[i]
+		phase := PeakPhase(BusinessHoursPeak, Day)
+		if i == 2 {
+			phase += math.Pi
+		}
+		walk := 100.0 - 2*amplitude
+		m.distributions[i] = SD(
+			PD(&m.timestamp, Day, amplitude, amplitude, phase, nil),
+			CWD(ND(r, 0.0, 1.0), 0.0, walk, r.Float64()*walk),
+		)
+	}
+	return m
+}
+
 func (m *CPUMeasurement) Tick(d time.Duration) {
 	m.timestamp = m.timestamp.Add(d)
 	for i := range m.distributions {
@@ -38,15 +38,15 @@ type DiskMeasurement struct {
 	freeBytesDist Distribution
 }
 
-func NewDiskMeasurement(start time.Time) *DiskMeasurement {
-	path := []byte(fmt.Sprintf("/dev/sda%d", rand.Intn(10)))
-	fsType := DiskFSTypeChoices[rand.Intn(len(DiskFSTypeChoices))]
+func NewDiskMeasurement(r *rand.Rand, start time.Time) *DiskMeasurement {
+	path := []byte(fmt.Sprintf("/dev/sda%d", r.Intn(10)))
+	fsType := DiskFSTypeChoices[r.Intn(len(DiskFSTypeChoices))]
 	return &DiskMeasurement{
 		path:   path,
 		fsType: fsType,
 
 		timestamp:     start,
-		freeBytesDist: CWD(ND(50, 1), 0, OneTerabyte, OneTerabyte/2),
+		freeBytesDist: CWD(ND(r, 50, 1), 0, OneTerabyte, OneTerabyte/2),
 	}
 }
 
@@ -12,13 +12,13 @@ var (
 	SerialByteString = []byte("serial")
 
 	DiskIOFields = []LabeledDistributionMaker{
-		{[]byte("reads"), func() Distribution { return MWD(ND(50, 1), 0) }},
-		{[]byte("writes"), func() Distribution { return MWD(ND(50, 1), 0) }},
-		{[]byte("read_bytes"), func() Distribution { return MWD(ND(100, 1), 0) }},
-		{[]byte("write_bytes"), func() Distribution { return MWD(ND(100, 1), 0) }},
-		{[]byte("read_time"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("write_time"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("io_time"), func() Distribution { return MWD(ND(5, 1), 0) }},
+		{[]byte("reads"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+		{[]byte("writes"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+		{[]byte("read_bytes"), func(r *rand.Rand) Distribution { return MWD(ND(r, 100, 1), 0) }},
+		{[]byte("write_bytes"), func(r *rand.Rand) Distribution { return MWD(ND(r, 100, 1), 0) }},
+		{[]byte("read_time"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("write_time"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("io_time"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
 	}
 )
 
@@ -29,13 +29,13 @@ type DiskIOMeasurement struct {
 	distributions []Distribution
 }
 
-func NewDiskIOMeasurement(start time.Time) *DiskIOMeasurement {
+func NewDiskIOMeasurement(r *rand.Rand, start time.Time) *DiskIOMeasurement {
 	distributions := make([]Distribution, len(DiskIOFields))
 	for i := range DiskIOFields {
-		distributions[i] = DiskIOFields[i].DistributionMaker()
+		distributions[i] = DiskIOFields[i].DistributionMaker(r)
 	}
 
-	serial := []byte(fmt.Sprintf("%03d-%03d-%03d", rand.Intn(1000), rand.Intn(1000), rand.Intn(1000)))
+	serial := []byte(fmt.Sprintf("%03d-%03d-%03d", r.Intn(1000), r.Intn(1000), r.Intn(1000)))
 	return &DiskIOMeasurement{
 		serial: serial,
 
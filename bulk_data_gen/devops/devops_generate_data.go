@@ -1,7 +1,9 @@
 package devops
 
 import (
+	"fmt"
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -13,10 +15,24 @@ type DevopsSimulator struct {
 	maxPoints  int64
 
 	simulatedMeasurementIndex int
+	epoch                     int64
+	rounds                    int64
+
+	// the collection interval of each measurement, in epochs:
+	every            []int64
+	samplingInterval time.Duration
 
 	hostIndex int
 	hosts     []Host
 
+	// host replacement; hosts[i] runs in slot firstSlot+i:
+	churn      HostChurn
+	churnEvery int64 // in epochs
+	firstSlot  int64
+	seed       int64
+
+	diurnal bool
+
 	timestampNow   time.Time
 	timestampStart time.Time
 	timestampEnd   time.Time
@@ -38,6 +54,16 @@ func (g *DevopsSimulator) Finished() bool {
 	return g.madePoints >= g.maxPoints
 }
 
+// Round returns the round of the last generated point. Each round holds one
+// measurement of every host.
+func (g *DevopsSimulator) Round() int64 {
+	return g.epoch*NHostSims + int64(g.simulatedMeasurementIndex)
+}
+
+func (g *DevopsSimulator) Rounds() int64 {
+	return g.rounds
+}
+
 // Type DevopsSimulatorConfig is used to create a DevopsSimulator.
 type DevopsSimulatorConfig struct {
 	Start time.Time
@@ -45,25 +71,150 @@ type DevopsSimulatorConfig struct {
 
 	HostCount  int64
 	HostOffset int64
+
+	// SamplingInterval is the duration of an epoch (EpochDuration if zero).
+	SamplingInterval time.Duration
+	// MeasurementIntervals sets the collection interval of measurements by
+	// name, as multiples of SamplingInterval. The others are collected every
+	// epoch.
+	MeasurementIntervals map[string]time.Duration
+
+	// ChurnRate is the fraction of the hosts replaced by new ones every
+	// ChurnPeriod (DefaultChurnPeriod if zero), which must be a multiple of
+	// all measurement intervals.
+	ChurnRate   float64
+	ChurnPeriod time.Duration
+
+	// Diurnal makes the cpu and nginx measurements follow business hours,
+	// with daily (and, for nginx, weekly) cycles.
+	Diurnal bool
+
+	// Rand seeds the random sources of the hosts.
+	Rand *rand.Rand
+}
+
+// Validate checks the sampling and churn configuration.
+func (d *DevopsSimulatorConfig) Validate() error {
+	every, err := d.measurementEvery()
+	if err != nil {
+		return err
+	}
+	churn := d.HostChurn()
+	if err := churn.Validate(); err != nil {
+		return err
+	}
+	if churn.Enabled() {
+		for i, k := range every {
+			if churn.Period%(d.EffectiveSamplingInterval()*time.Duration(k)) != 0 {
+				return fmt.Errorf("churn period %v is not a multiple of the interval of measurement '%s'", churn.Period, HostMeasurementNames[i])
+			}
+		}
+	}
+	return nil
+}
+
+// HostChurn returns the host replacement model of the simulation.
+func (d *DevopsSimulatorConfig) HostChurn() HostChurn {
+	period := d.ChurnPeriod
+	if period == 0 {
+		period = DefaultChurnPeriod
+	}
+	return HostChurn{
+		Start:      d.Start,
+		HostCount:  d.HostCount,
+		HostOffset: d.HostOffset,
+		Rate:       d.ChurnRate,
+		Period:     period,
+	}
+}
+
+// EffectiveSamplingInterval returns the sampling interval, EpochDuration if
+// unset.
+func (d *DevopsSimulatorConfig) EffectiveSamplingInterval() time.Duration {
+	if d.SamplingInterval == 0 {
+		return EpochDuration
+	}
+	return d.SamplingInterval
+}
+
+// measurementEvery returns the collection interval, in epochs, of every
+// measurement in NewHostMeasurements order.
+func (d *DevopsSimulatorConfig) measurementEvery() ([]int64, error) {
+	multiples, err := SamplingMultiples(d.EffectiveSamplingInterval(), d.MeasurementIntervals, HostMeasurementNames)
+	if err != nil {
+		return nil, err
+	}
+	every := make([]int64, NHostSims)
+	for i, name := range HostMeasurementNames {
+		every[i] = 1
+		if m, ok := multiples[string(name)]; ok {
+			every[i] = m
+		}
+	}
+	return every, nil
 }
 
 func (d *DevopsSimulatorConfig) ToSimulator() *DevopsSimulator {
+	seed := d.Rand.Int63()
+	return d.newSimulator(d.newHosts(seed), 0, seed)
+}
+
+// ToSimulatorSlices creates simulators for up to n contiguous slices of the
+// hosts, to be run in parallel. Merged round by round, in slice order, their
+// points are identical to the points of the simulator made by ToSimulator.
+func (d *DevopsSimulatorConfig) ToSimulatorSlices(n int) []*DevopsSimulator {
+	seed := d.Rand.Int63()
+	hosts := d.newHosts(seed)
+	bounds := SliceBounds(len(hosts), n)
+
+	sims := make([]*DevopsSimulator, len(bounds))
+	for i, b := range bounds {
+		sims[i] = d.newSimulator(hosts[b[0]:b[1]], int64(b[0]), seed)
+	}
+	return sims
+}
+
+func (d *DevopsSimulatorConfig) newHosts(seed int64) []Host {
 	hostInfos := make([]Host, d.HostCount)
 	for i := 0; i < len(hostInfos); i++ {
-		hostInfos[i] = NewHost(i, int(d.HostOffset), d.Start)
+		hostInfos[i] = NewHost(NewRand(seed, int64(i)+d.HostOffset), i, int(d.HostOffset), d.Start, d.Diurnal)
 	}
+	return hostInfos
+}
 
-	epochs := d.End.Sub(d.Start).Nanoseconds() / EpochDuration.Nanoseconds()
-	maxPoints := epochs * (d.HostCount * NHostSims)
+func (d *DevopsSimulatorConfig) newSimulator(hosts []Host, firstSlot int64, seed int64) *DevopsSimulator {
+	every, err := d.measurementEvery()
+	if err != nil {
+		panic(err.Error())
+	}
+	churn := d.HostChurn()
+	epochs := d.End.Sub(d.Start).Nanoseconds() / d.EffectiveSamplingInterval().Nanoseconds()
+	var hostPoints int64
+	for _, k := range every {
+		hostPoints += SampledPoints(epochs, k)
+	}
+	maxPoints := int64(len(hosts)) * hostPoints
 	dg := &DevopsSimulator{
 		madePoints: 0,
 		madeValues: 0,
 		maxPoints:  maxPoints,
 
 		simulatedMeasurementIndex: 0,
+		epoch:                     0,
+		rounds:                    epochs * NHostSims,
+
+		every:            every,
+		samplingInterval: d.EffectiveSamplingInterval(),
 
 		hostIndex: 0,
-		hosts:     hostInfos,
+		hosts:     hosts,
+
+		churn:      churn,
+		churnEvery: int64(churn.Period / d.EffectiveSamplingInterval()),
+		firstSlot:  firstSlot,
+		seed:       seed,
+
+		diurnal: d.Diurnal,
 
 		timestampNow:   d.Start,
 		timestampStart: d.Start,
@@ -78,15 +229,7 @@ func (d *DevopsSimulator) Next(p *Point) {
 	// switch to the next metric if needed
 	if d.hostIndex == len(d.hosts) {
 		d.hostIndex = 0
-		d.simulatedMeasurementIndex++
-	}
-
-	if d.simulatedMeasurementIndex == NHostSims {
-		d.simulatedMeasurementIndex = 0
-
-		for i := 0; i < len(d.hosts); i++ {
-			d.hosts[i].TickAll(EpochDuration)
-		}
+		d.nextMeasurement()
 	}
 
 	host := &d.hosts[d.hostIndex]
@@ -112,3 +255,42 @@ func (d *DevopsSimulator) Next(p *Point) {
 
 	return
 }
+
+// nextMeasurement moves on to the next measurement collected in the current
+// epoch, advancing to the next epoch when needed.
+func (d *DevopsSimulator) nextMeasurement() {
+	for {
+		d.simulatedMeasurementIndex++
+		if d.simulatedMeasurementIndex == NHostSims {
+			d.simulatedMeasurementIndex = 0
+			d.epoch++
+			d.tickAll()
+		}
+		if d.epoch%d.every[d.simulatedMeasurementIndex] == 0 {
+			return
+		}
+	}
+}
+
+// tickAll advances all hosts to the next epoch. Measurements not collected
+// in this epoch wait, and get advanced by their whole interval once they are.
+// Hosts retired at this epoch are replaced by new ones instead.
+func (d *DevopsSimulator) tickAll() {
+	churning := d.churn.Enabled() && d.epoch%d.churnEvery == 0
+	for i := 0; i < len(d.hosts); i++ {
+		if churning {
+			period := d.epoch / d.churnEvery
+			slot := d.firstSlot + int64(i)
+			if id := d.churn.HostId(slot, period); id != d.churn.HostId(slot, period-1) {
+				start := d.timestampStart.Add(d.samplingInterval * time.Duration(d.epoch))
+				d.hosts[i] = NewHost(NewRand(d.seed, id), int(id), 0, start, d.diurnal)
+				continue
+			}
+		}
+		for j, sm := range d.hosts[i].SimulatedMeasurements {
+			if d.epoch%d.every[j] == 0 {
+				sm.Tick(d.samplingInterval * time.Duration(d.every[j]))
+			}
+		}
+	}
+}
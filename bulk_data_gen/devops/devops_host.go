@@ -109,10 +109,34 @@ var (
 	}
 )
 
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+
+	// Time of day (UTC) the load of hosts following business hours peaks at.
+	BusinessHoursPeak = 14 * time.Hour
+	// Time into the week the load peaks at: Wednesday noon, as weeks start on
+	// Thursday, the weekday of the Unix epoch.
+	WeeklyPeak = 6*Day + 12*time.Hour
+)
+
 var (
 	// The duration of a log epoch.
 	EpochDuration = 10 * time.Second
 
+	// Names of the measurements of a Host, in NewHostMeasurements order:
+	HostMeasurementNames = [][]byte{
+		CPUByteString,
+		DiskIOByteString,
+		DiskByteString,
+		KernelByteString,
+		MemoryByteString,
+		NetByteString,
+		NginxByteString,
+		PostgresqlByteString,
+		RedisByteString,
+	}
+
 	// Tag fields common to all hosts:
 	MachineTagKeys = [][]byte{
 		[]byte("hostname"),
@@ -137,17 +161,23 @@ type Host struct {
 	Team, Service, ServiceVersion, ServiceEnvironment []byte
 }
 
-func NewHostMeasurements(start time.Time) []SimulatedMeasurement {
+// NewHostMeasurements makes the measurements of a host. With diurnal set,
+// the cpu and nginx measurements follow business hours.
+func NewHostMeasurements(r *rand.Rand, start time.Time, diurnal bool) []SimulatedMeasurement {
+	newCPU, newNginx := NewCPUMeasurement, NewNginxMeasurement
+	if diurnal {
+		newCPU, newNginx = NewDiurnalCPUMeasurement, NewDiurnalNginxMeasurement
+	}
 	sm := []SimulatedMeasurement{
-		NewCPUMeasurement(start),
-		NewDiskIOMeasurement(start),
-		NewDiskMeasurement(start),
-		NewKernelMeasurement(start),
-		NewMemMeasurement(start),
-		NewNetMeasurement(start),
-		NewNginxMeasurement(start),
-		NewPostgresqlMeasurement(start),
-		NewRedisMeasurement(start),
+		newCPU(r, start),
+		NewDiskIOMeasurement(r, start),
+		NewDiskMeasurement(r, start),
+		NewKernelMeasurement(r, start),
+		NewMemMeasurement(r, start),
+		NewNetMeasurement(r, start),
+		newNginx(r, start),
+		NewPostgresqlMeasurement(r, start),
+		NewRedisMeasurement(r, start),
 	}
 
 	if len(sm) != NHostSims {
@@ -156,27 +186,27 @@ func NewHostMeasurements(start time.Time) []SimulatedMeasurement {
 	return sm
 }
 
-func NewHost(i int, offset int, start time.Time) Host {
-	sm := NewHostMeasurements(start)
+func NewHost(r *rand.Rand, i int, offset int, start time.Time, diurnal bool) Host {
+	sm := NewHostMeasurements(r, start, diurnal)
 
-	region := &Regions[rand.Intn(len(Regions))]
-	rackId := rand.Int63n(MachineRackChoicesPerDatacenter)
-	serviceId := rand.Int63n(MachineServiceChoices)
-	serviceVersionId := rand.Int63n(MachineServiceVersionChoices)
-	serviceEnvironment := RandChoice(MachineServiceEnvironmentChoices)
+	region := &Regions[r.Intn(len(Regions))]
+	rackId := r.Int63n(MachineRackChoicesPerDatacenter)
+	serviceId := r.Int63n(MachineServiceChoices)
+	serviceVersionId := r.Int63n(MachineServiceVersionChoices)
+	serviceEnvironment := RandChoice(r, MachineServiceEnvironmentChoices)
 
 	h := Host{
 		// Tag Values that are static throughout the life of a Host:
 		Name:               []byte(fmt.Sprintf("host_%d", i+offset)),
 		Region:             []byte(fmt.Sprintf("%s", region.Name)),
-		Datacenter:         RandChoice(region.Datacenters),
+		Datacenter:         RandChoice(r, region.Datacenters),
 		Rack:               []byte(fmt.Sprintf("%d", rackId)),
-		Arch:               RandChoice(MachineArchChoices),
-		OS:                 RandChoice(MachineOSChoices),
+		Arch:               RandChoice(r, MachineArchChoices),
+		OS:                 RandChoice(r, MachineOSChoices),
 		Service:            []byte(fmt.Sprintf("%d", serviceId)),
 		ServiceVersion:     []byte(fmt.Sprintf("%d", serviceVersionId)),
 		ServiceEnvironment: serviceEnvironment,
-		Team:               RandChoice(MachineTeamChoices),
+		Team:               RandChoice(r, MachineTeamChoices),
 
 		SimulatedMeasurements: sm,
 	}
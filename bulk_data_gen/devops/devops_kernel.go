@@ -10,11 +10,11 @@ var (
 	KernelByteString   = []byte("kernel") // heap optimization
 	BootTimeByteString = []byte("boot_time")
 	KernelFields       = []LabeledDistributionMaker{
-		{[]byte("interrupts"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("context_switches"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("processes_forked"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("disk_pages_in"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("disk_pages_out"), func() Distribution { return MWD(ND(5, 1), 0) }},
+		{[]byte("interrupts"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("context_switches"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("processes_forked"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("disk_pages_in"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("disk_pages_out"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
 	}
 )
 
@@ -26,13 +26,13 @@ type KernelMeasurement struct {
 	distributions []Distribution
 }
 
-func NewKernelMeasurement(start time.Time) *KernelMeasurement {
+func NewKernelMeasurement(r *rand.Rand, start time.Time) *KernelMeasurement {
 	distributions := make([]Distribution, len(KernelFields))
 	for i := range KernelFields {
-		distributions[i] = KernelFields[i].DistributionMaker()
+		distributions[i] = KernelFields[i].DistributionMaker(r)
 	}
 
-	bootTime := rand.Int63n(240)
+	bootTime := r.Int63n(240)
 	return &KernelMeasurement{
 		bootTime: bootTime,
 
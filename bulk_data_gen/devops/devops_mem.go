@@ -36,33 +36,36 @@ type MemMeasurement struct {
 	bytesUsedDist, bytesCachedDist, bytesBufferedDist Distribution
 }
 
-func NewMemMeasurement(start time.Time) *MemMeasurement {
-	bytesTotal := MemoryMaxBytesChoices[rand.Intn(len(MemoryMaxBytesChoices))]
+func NewMemMeasurement(r *rand.Rand, start time.Time) *MemMeasurement {
+	bytesTotal := MemoryMaxBytesChoices[r.Intn(len(MemoryMaxBytesChoices))]
 	bytesUsedDist := &ClampedRandomWalkDistribution{
-		State: rand.Float64() * float64(bytesTotal),
+		State: r.Float64() * float64(bytesTotal),
 		Min:   0.0,
 		Max:   float64(bytesTotal),
 		Step: &NormalDistribution{
 			Mean:   0.0,
 			StdDev: float64(bytesTotal) / 64,
+			Rand:   r,
 		},
 	}
 	bytesCachedDist := &ClampedRandomWalkDistribution{
-		State: rand.Float64() * float64(bytesTotal),
+		State: r.Float64() * float64(bytesTotal),
 		Min:   0.0,
 		Max:   float64(bytesTotal),
 		Step: &NormalDistribution{
 			Mean:   0.0,
 			StdDev: float64(bytesTotal) / 64,
+			Rand:   r,
 		},
 	}
 	bytesBufferedDist := &ClampedRandomWalkDistribution{
-		State: rand.Float64() * float64(bytesTotal),
+		State: r.Float64() * float64(bytesTotal),
 		Min:   0.0,
 		Max:   float64(bytesTotal),
 		Step: &NormalDistribution{
 			Mean:   0.0,
 			StdDev: float64(bytesTotal) / 64,
+			Rand:   r,
 		},
 	}
 	return &MemMeasurement{
@@ -14,14 +14,14 @@ var (
 	}
 
 	NetFields = []LabeledDistributionMaker{
-		{[]byte("bytes_sent"), func() Distribution { return MWD(ND(50, 1), 0) }},
-		{[]byte("bytes_recv"), func() Distribution { return MWD(ND(50, 1), 0) }},
-		{[]byte("packets_sent"), func() Distribution { return MWD(ND(50, 1), 0) }},
-		{[]byte("packets_recv"), func() Distribution { return MWD(ND(50, 1), 0) }},
-		{[]byte("err_in"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("err_out"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("drop_in"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("drop_out"), func() Distribution { return MWD(ND(5, 1), 0) }},
+		{[]byte("bytes_sent"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+		{[]byte("bytes_recv"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+		{[]byte("packets_sent"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+		{[]byte("packets_recv"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+		{[]byte("err_in"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("err_out"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("drop_in"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("drop_out"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
 	}
 )
 
@@ -33,13 +33,13 @@ type NetMeasurement struct {
 	distributions []Distribution
 }
 
-func NewNetMeasurement(start time.Time) *NetMeasurement {
+func NewNetMeasurement(r *rand.Rand, start time.Time) *NetMeasurement {
 	distributions := make([]Distribution, len(NetFields))
 	for i := range NetFields {
-		distributions[i] = NetFields[i].DistributionMaker()
+		distributions[i] = NetFields[i].DistributionMaker(r)
 	}
 
-	interfaceName := []byte(fmt.Sprintf("eth%d", rand.Intn(4)))
+	interfaceName := []byte(fmt.Sprintf("eth%d", r.Intn(4)))
 	return &NetMeasurement{
 		interfaceName: interfaceName,
 
@@ -16,16 +16,43 @@ var (
 	}
 
 	NginxFields = []LabeledDistributionMaker{
-		{[]byte("accepts"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("active"), func() Distribution { return CWD(ND(5, 1), 0, 100, 0) }},
-		{[]byte("handled"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("reading"), func() Distribution { return CWD(ND(5, 1), 0, 100, 0) }},
-		{[]byte("requests"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("waiting"), func() Distribution { return CWD(ND(5, 1), 0, 100, 0) }},
-		{[]byte("writing"), func() Distribution { return CWD(ND(5, 1), 0, 100, 0) }},
+		{[]byte("accepts"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("active"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 100, 0) }},
+		{[]byte("handled"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("reading"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 100, 0) }},
+		{[]byte("requests"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("waiting"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 100, 0) }},
+		{[]byte("writing"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 100, 0) }},
+	}
+
+	// Distributions of the NginxFields of hosts following business hours:
+	// the counters grow fastest and the gauges are highest at
+	// BusinessHoursPeak, and a little lower on weekends.
+	NginxDiurnalDistributionMakers = []func(r *rand.Rand, clock *time.Time) Distribution{
+		nginxDiurnalCounter,
+		nginxDiurnalGauge,
+		nginxDiurnalCounter,
+		nginxDiurnalGauge,
+		nginxDiurnalCounter,
+		nginxDiurnalGauge,
+		nginxDiurnalGauge,
 	}
 )
 
+func nginxDiurnalCounter(r *rand.Rand, clock *time.Time) Distribution {
+	return MWD(SD(
+		PD(clock, Day, 5, 4, PeakPhase(BusinessHoursPeak, Day), ND(r, 0, 1)),
+		PD(clock, Week, 0, 1, PeakPhase(WeeklyPeak, Week), nil),
+	), 0)
+}
+
+func nginxDiurnalGauge(r *rand.Rand, clock *time.Time) Distribution {
+	return SD(
+		PD(clock, Day, 50, 40, PeakPhase(BusinessHoursPeak, Day), ND(r, 0, 3)),
+		PD(clock, Week, 0, 5, PeakPhase(WeeklyPeak, Week), nil),
+	)
+}
+
 type NginxMeasurement struct {
 	timestamp time.Time
 
@@ -33,14 +60,14 @@ type NginxMeasurement struct {
 	distributions    []Distribution
 }
 
-func NewNginxMeasurement(start time.Time) *NginxMeasurement {
+func NewNginxMeasurement(r *rand.Rand, start time.Time) *NginxMeasurement {
 	distributions := make([]Distribution, len(NginxFields))
 	for i := range NginxFields {
-		distributions[i] = NginxFields[i].DistributionMaker()
+		distributions[i] = NginxFields[i].DistributionMaker(r)
 	}
 
-	serverName := []byte(fmt.Sprintf("nginx_%d", rand.Intn(100000)))
-	port := []byte(fmt.Sprintf("%d", rand.Intn(20000)+1024))
+	serverName := []byte(fmt.Sprintf("nginx_%d", r.Intn(100000)))
+	port := []byte(fmt.Sprintf("%d", r.Intn(20000)+1024))
 	return &NginxMeasurement{
 		port:       port,
 		serverName: serverName,
@@ -50,6 +77,16 @@ func NewNginxMeasurement(start time.Time) *NginxMeasurement {
 	}
 }
 
+// NewDiurnalNginxMeasurement makes a NginxMeasurement following business
+// hours.
+func NewDiurnalNginxMeasurement(r *rand.Rand, start time.Time) *NginxMeasurement {
+	m := NewNginxMeasurement(r, start)
+	for i := range m.distributions {
+		m.distributions[i] = NginxDiurnalDistributionMakers[i](r, &m.timestamp)
+	}
+	return m
+}
+
 func (m *NginxMeasurement) Tick(d time.Duration) {
 	m.timestamp = m.timestamp.Add(d)
 
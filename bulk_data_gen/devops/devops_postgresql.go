@@ -2,28 +2,29 @@ package devops
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
 var (
 	PostgresqlByteString = []byte("postgresl") // heap optimization
 	PostgresqlFields     = []LabeledDistributionMaker{
-		{[]byte("numbackends"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("xact_commit"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("xact_rollback"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("blks_read"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("blks_hit"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("tup_returned"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("tup_fetched"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("tup_inserted"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("tup_updated"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("tup_deleted"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("conflicts"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("temp_files"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("temp_bytes"), func() Distribution { return CWD(ND(1024, 1), 0, 1024*1024*1024, 0) }},
-		{[]byte("deadlocks"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("blk_read_time"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("blk_write_time"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
+		{[]byte("numbackends"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("xact_commit"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("xact_rollback"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("blks_read"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("blks_hit"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("tup_returned"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("tup_fetched"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("tup_inserted"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("tup_updated"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("tup_deleted"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("conflicts"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("temp_files"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("temp_bytes"), func(r *rand.Rand) Distribution { return CWD(ND(r, 1024, 1), 0, 1024*1024*1024, 0) }},
+		{[]byte("deadlocks"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("blk_read_time"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("blk_write_time"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
 	}
 )
 
@@ -32,10 +33,10 @@ type PostgresqlMeasurement struct {
 	distributions []Distribution
 }
 
-func NewPostgresqlMeasurement(start time.Time) *PostgresqlMeasurement {
+func NewPostgresqlMeasurement(r *rand.Rand, start time.Time) *PostgresqlMeasurement {
 	distributions := make([]Distribution, len(PostgresqlFields))
 	for i := range PostgresqlFields {
-		distributions[i] = PostgresqlFields[i].DistributionMaker()
+		distributions[i] = PostgresqlFields[i].DistributionMaker(r)
 	}
 
 	return &PostgresqlMeasurement{
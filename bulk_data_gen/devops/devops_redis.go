@@ -9,7 +9,7 @@ import (
 
 type LabeledDistributionMaker struct {
 	Label             []byte
-	DistributionMaker func() Distribution
+	DistributionMaker func(*rand.Rand) Distribution
 }
 
 var (
@@ -25,38 +25,38 @@ var (
 	}
 
 	RedisFields = []LabeledDistributionMaker{
-		{[]byte("total_connections_received"), func() Distribution { return MWD(ND(5, 1), 0) }},
-		{[]byte("expired_keys"), func() Distribution { return MWD(ND(50, 1), 0) }},
-		{[]byte("evicted_keys"), func() Distribution { return MWD(ND(50, 1), 0) }},
-		{[]byte("keyspace_hits"), func() Distribution { return MWD(ND(50, 1), 0) }},
-		{[]byte("keyspace_misses"), func() Distribution { return MWD(ND(50, 1), 0) }},
-
-		{[]byte("instantaneous_ops_per_sec"), func() Distribution { return WD(ND(1, 1), 0) }},
-		{[]byte("instantaneous_input_kbps"), func() Distribution { return WD(ND(1, 1), 0) }},
-		{[]byte("instantaneous_output_kbps"), func() Distribution { return WD(ND(1, 1), 0) }},
-		{[]byte("connected_clients"), func() Distribution { return CWD(ND(50, 1), 0, 10000, 0) }},
-		{[]byte("used_memory"), func() Distribution { return CWD(ND(50, 1), 0, SixteenGB, SixteenGB/2) }},
-		{[]byte("used_memory_rss"), func() Distribution { return CWD(ND(50, 1), 0, SixteenGB, SixteenGB/2) }},
-		{[]byte("used_memory_peak"), func() Distribution { return CWD(ND(50, 1), 0, SixteenGB, SixteenGB/2) }},
-		{[]byte("used_memory_lua"), func() Distribution { return CWD(ND(50, 1), 0, SixteenGB, SixteenGB/2) }},
-		{[]byte("rdb_changes_since_last_save"), func() Distribution { return CWD(ND(50, 1), 0, 10000, 0) }},
-
-		{[]byte("sync_full"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("sync_partial_ok"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("sync_partial_err"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("pubsub_channels"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("pubsub_patterns"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("latest_fork_usec"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("connected_slaves"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("master_repl_offset"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("repl_backlog_active"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("repl_backlog_size"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("repl_backlog_histlen"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("mem_fragmentation_ratio"), func() Distribution { return CWD(ND(5, 1), 0, 100, 0) }},
-		{[]byte("used_cpu_sys"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("used_cpu_user"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("used_cpu_sys_children"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
-		{[]byte("used_cpu_user_children"), func() Distribution { return CWD(ND(5, 1), 0, 1000, 0) }},
+		{[]byte("total_connections_received"), func(r *rand.Rand) Distribution { return MWD(ND(r, 5, 1), 0) }},
+		{[]byte("expired_keys"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+		{[]byte("evicted_keys"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+		{[]byte("keyspace_hits"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+		{[]byte("keyspace_misses"), func(r *rand.Rand) Distribution { return MWD(ND(r, 50, 1), 0) }},
+
+		{[]byte("instantaneous_ops_per_sec"), func(r *rand.Rand) Distribution { return WD(ND(r, 1, 1), 0) }},
+		{[]byte("instantaneous_input_kbps"), func(r *rand.Rand) Distribution { return WD(ND(r, 1, 1), 0) }},
+		{[]byte("instantaneous_output_kbps"), func(r *rand.Rand) Distribution { return WD(ND(r, 1, 1), 0) }},
+		{[]byte("connected_clients"), func(r *rand.Rand) Distribution { return CWD(ND(r, 50, 1), 0, 10000, 0) }},
+		{[]byte("used_memory"), func(r *rand.Rand) Distribution { return CWD(ND(r, 50, 1), 0, SixteenGB, SixteenGB/2) }},
+		{[]byte("used_memory_rss"), func(r *rand.Rand) Distribution { return CWD(ND(r, 50, 1), 0, SixteenGB, SixteenGB/2) }},
+		{[]byte("used_memory_peak"), func(r *rand.Rand) Distribution { return CWD(ND(r, 50, 1), 0, SixteenGB, SixteenGB/2) }},
+		{[]byte("used_memory_lua"), func(r *rand.Rand) Distribution { return CWD(ND(r, 50, 1), 0, SixteenGB, SixteenGB/2) }},
+		{[]byte("rdb_changes_since_last_save"), func(r *rand.Rand) Distribution { return CWD(ND(r, 50, 1), 0, 10000, 0) }},
+
+		{[]byte("sync_full"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("sync_partial_ok"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("sync_partial_err"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("pubsub_channels"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("pubsub_patterns"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("latest_fork_usec"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("connected_slaves"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("master_repl_offset"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("repl_backlog_active"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("repl_backlog_size"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("repl_backlog_histlen"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("mem_fragmentation_ratio"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 100, 0) }},
+		{[]byte("used_cpu_sys"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("used_cpu_user"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("used_cpu_sys_children"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
+		{[]byte("used_cpu_user_children"), func(r *rand.Rand) Distribution { return CWD(ND(r, 5, 1), 0, 1000, 0) }},
 	}
 )
 
@@ -68,14 +68,14 @@ type RedisMeasurement struct {
 	distributions    []Distribution
 }
 
-func NewRedisMeasurement(start time.Time) *RedisMeasurement {
+func NewRedisMeasurement(r *rand.Rand, start time.Time) *RedisMeasurement {
 	distributions := make([]Distribution, len(RedisFields))
 	for i := range RedisFields {
-		distributions[i] = RedisFields[i].DistributionMaker()
+		distributions[i] = RedisFields[i].DistributionMaker(r)
 	}
 
-	serverName := []byte(fmt.Sprintf("redis_%d", rand.Intn(100000)))
-	port := []byte(fmt.Sprintf("%d", rand.Intn(20000)+1024))
+	serverName := []byte(fmt.Sprintf("redis_%d", r.Intn(100000)))
+	port := []byte(fmt.Sprintf("%d", r.Intn(20000)+1024))
 	return &RedisMeasurement{
 		port:       port,
 		serverName: serverName,
@@ -0,0 +1,353 @@
+package devops
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Kinds of incidents:
+const (
+	// CPU usage of the hosts is pinned at 100%.
+	IncidentCPUSpike = "cpu-spike"
+	// Used memory grows until it reaches the total memory at the end.
+	IncidentMemoryLeak = "memory-leak"
+	// Free disk space shrinks until none is left at the end.
+	IncidentDiskFill = "disk-fill"
+	// Every field of the hosts keeps the value it had at the start.
+	IncidentFlatline = "flatline"
+	// The hosts send no points at all.
+	IncidentGap = "gap"
+)
+
+var IncidentKinds = []string{IncidentCPUSpike, IncidentMemoryLeak, IncidentDiskFill, IncidentFlatline, IncidentGap}
+
+// Bounds of the duration of random incidents.
+const (
+	MinRandomIncidentDuration = time.Minute
+	MaxRandomIncidentDuration = time.Hour
+)
+
+// Incident is an anomaly affecting the points of some hosts, timestamped
+// in [Start, End).
+type Incident struct {
+	Kind  string    `json:"kind"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Hosts []string  `json:"hosts"`
+	// Measurements lists the measurements altered, all of them if empty.
+	Measurements []string `json:"measurements,omitempty"`
+}
+
+func NewIncident(kind string, start time.Time, duration time.Duration, hosts []string) (Incident, error) {
+	inc := Incident{Kind: kind, Start: start, End: start.Add(duration), Hosts: hosts}
+	switch kind {
+	case IncidentCPUSpike:
+		inc.Measurements = []string{string(CPUByteString)}
+	case IncidentMemoryLeak:
+		inc.Measurements = []string{string(MemoryByteString)}
+	case IncidentDiskFill:
+		inc.Measurements = []string{string(DiskByteString)}
+	case IncidentFlatline, IncidentGap:
+	default:
+		return inc, fmt.Errorf("unknown incident kind '%s' (choices: %s)", kind, strings.Join(IncidentKinds, ", "))
+	}
+	if duration <= 0 {
+		return inc, fmt.Errorf("incident duration must be positive, got %v", duration)
+	}
+	if len(hosts) == 0 {
+		return inc, fmt.Errorf("incident without hosts")
+	}
+	for _, h := range hosts {
+		if h == "" {
+			return inc, fmt.Errorf("empty host name")
+		}
+	}
+	return inc, nil
+}
+
+// ParseIncidents parses a semicolon-separated list of incidents, each
+// written as <kind>@<start>/<duration>=<host>+<host>..., e.g.
+// 'cpu-spike@2018-01-01T01:00:00Z/15m=host_1+host_3;gap@2018-01-01T05:00:00Z/5m=host_2'.
+func ParseIncidents(s string) ([]Incident, error) {
+	var incidents []Incident
+	for _, spec := range strings.Split(s, ";") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		at := strings.Index(spec, "@")
+		slash := strings.LastIndex(spec, "/")
+		eq := strings.LastIndex(spec, "=")
+		if at < 0 || slash < at || eq < slash {
+			return nil, fmt.Errorf("invalid incident '%s', expected <kind>@<start>/<duration>=<hosts>", spec)
+		}
+		start, err := time.Parse(time.RFC3339, spec[at+1:slash])
+		if err != nil {
+			return nil, fmt.Errorf("invalid incident '%s': %v", spec, err)
+		}
+		duration, err := time.ParseDuration(spec[slash+1 : eq])
+		if err != nil {
+			return nil, fmt.Errorf("invalid incident '%s': %v", spec, err)
+		}
+		inc, err := NewIncident(spec[:at], start.UTC(), duration, strings.Split(spec[eq+1:], "+"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid incident '%s': %v", spec, err)
+		}
+		incidents = append(incidents, inc)
+	}
+	return incidents, nil
+}
+
+// RandomIncidents draws n incidents of random kinds, each lasting between
+// MinRandomIncidentDuration and MaxRandomIncidentDuration within [start,
+// end), and affecting one to three of the hosts of the churn model live
+// during the incident.
+func RandomIncidents(r *rand.Rand, n int, start, end time.Time, churn HostChurn) []Incident {
+	incidents := make([]Incident, 0, n)
+	span := end.Sub(start)
+	for i := 0; i < n; i++ {
+		kind := IncidentKinds[r.Intn(len(IncidentKinds))]
+		duration := MinRandomIncidentDuration + time.Duration(r.Int63n(int64(MaxRandomIncidentDuration-MinRandomIncidentDuration)))
+		duration = duration.Truncate(time.Second)
+		if duration > span {
+			duration = span
+		}
+		at := start
+		if span > duration {
+			at = start.Add(time.Duration(r.Int63n(int64(span - duration))).Truncate(time.Second))
+		}
+		live := churn.LiveHosts(at, at.Add(duration))
+		affected := 1 + r.Intn(3)
+		if affected > len(live) {
+			affected = len(live)
+		}
+		hosts := make([]string, 0, affected)
+		for _, h := range r.Perm(len(live))[:affected] {
+			hosts = append(hosts, fmt.Sprintf("host_%d", live[h]))
+		}
+		inc, err := NewIncident(kind, at, duration, hosts)
+		if err != nil {
+			panic("logic error: " + err.Error())
+		}
+		incidents = append(incidents, inc)
+	}
+	return incidents
+}
+
+// WriteIncidents writes the incidents as a JSON array, the ground truth
+// query results can be checked against.
+func WriteIncidents(w io.Writer, incidents []Incident) error {
+	if incidents == nil {
+		incidents = []Incident{}
+	}
+	data, err := json.MarshalIndent(incidents, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// IncidentSimulator injects incidents in the points of a devops simulator
+// (or of a slice of it).
+// It fulfills the SlicedSimulator interface.
+type IncidentSimulator struct {
+	sim    SlicedSimulator
+	byHost map[string][]*Incident
+	// values of the flatlined series, by incident and series:
+	frozen map[string][]interface{}
+
+	// the next point to deliver, read ahead to skip the points of gaps:
+	next          *Point
+	nextTimestamp time.Time
+	nextRound     int64
+	buffered      bool
+
+	timestamp time.Time
+	round     int64
+
+	madePoints int64
+	madeValues int64
+}
+
+func NewIncidentSimulator(sim SlicedSimulator, incidents []Incident) *IncidentSimulator {
+	s := &IncidentSimulator{
+		sim:    sim,
+		byHost: make(map[string][]*Incident),
+		frozen: make(map[string][]interface{}),
+		next:   MakeUsablePoint(),
+	}
+	for i := range incidents {
+		for _, h := range incidents[i].Hosts {
+			s.byHost[h] = append(s.byHost[h], &incidents[i])
+		}
+	}
+	return s
+}
+
+func (s *IncidentSimulator) SeenPoints() int64 {
+	return s.madePoints
+}
+
+func (s *IncidentSimulator) SeenValues() int64 {
+	return s.madeValues
+}
+
+func (s *IncidentSimulator) Total() int64 {
+	return s.sim.Total()
+}
+
+func (s *IncidentSimulator) Finished() bool {
+	s.fill()
+	return !s.buffered
+}
+
+func (s *IncidentSimulator) Round() int64 {
+	return s.round
+}
+
+func (s *IncidentSimulator) Rounds() int64 {
+	return s.sim.Rounds()
+}
+
+// Next fills p with the next point to deliver.
+func (s *IncidentSimulator) Next(p *Point) {
+	s.fill()
+	if !s.buffered {
+		panic("logic error: no point left")
+	}
+	n := s.next
+	p.SetMeasurementName(n.MeasurementName)
+	for i := range n.TagKeys {
+		p.AppendTag(n.TagKeys[i], n.TagValues[i])
+	}
+	for i := range n.FieldKeys {
+		p.AppendField(n.FieldKeys[i], n.FieldValues[i])
+	}
+	s.timestamp = s.nextTimestamp
+	p.SetTimestamp(&s.timestamp)
+	s.round = s.nextRound
+	s.buffered = false
+
+	s.madePoints++
+	s.madeValues += int64(len(p.FieldValues))
+}
+
+// fill reads ahead the next point not dropped by a gap, if any.
+func (s *IncidentSimulator) fill() {
+	for !s.buffered && !s.sim.Finished() {
+		s.next.Reset()
+		s.sim.Next(s.next)
+		s.nextTimestamp = *s.next.Timestamp
+		s.nextRound = s.sim.Round()
+		s.buffered = s.inject(s.next, s.nextTimestamp)
+	}
+}
+
+// inject applies the incidents active at the point, and returns whether it
+// is delivered.
+func (s *IncidentSimulator) inject(p *Point, t time.Time) bool {
+	var host []byte
+	for i, key := range p.TagKeys {
+		if string(key) == string(MachineTagKeys[0]) {
+			host = p.TagValues[i]
+			break
+		}
+	}
+	for _, inc := range s.byHost[string(host)] {
+		if t.Before(inc.Start) || !t.Before(inc.End) {
+			continue
+		}
+		progress := float64(t.Sub(inc.Start)) / float64(inc.End.Sub(inc.Start))
+		name := string(p.MeasurementName)
+		switch inc.Kind {
+		case IncidentGap:
+			return false
+		case IncidentFlatline:
+			s.flatline(p, inc)
+		case IncidentCPUSpike:
+			if name == string(CPUByteString) {
+				setField(p, CPUFieldKeys[0], 100)
+				setField(p, CPUFieldKeys[2], 0)
+			}
+		case IncidentMemoryLeak:
+			if name == string(MemoryByteString) {
+				total := getField(p, MemoryFieldKeys[0])
+				used := getField(p, MemoryFieldKeys[2])
+				leaked := used + (total-used)*progress
+				setField(p, MemoryFieldKeys[1], total-leaked)
+				setField(p, MemoryFieldKeys[2], leaked)
+				setField(p, MemoryFieldKeys[3], math.Max(0, getField(p, MemoryFieldKeys[3])-(leaked-used)))
+				setField(p, MemoryFieldKeys[6], 100*leaked/total)
+				setField(p, MemoryFieldKeys[7], 100*(total-leaked)/total)
+			}
+		case IncidentDiskFill:
+			if name == string(DiskByteString) {
+				total := getField(p, TotalByteString)
+				free := getField(p, FreeByteString) * (1 - progress)
+				setField(p, FreeByteString, free)
+				setField(p, UsedByteString, total-free)
+				setField(p, UsedPercentByteString, 100*(total-free)/total)
+				// the free inodes shrink as the free space:
+				inodesTotal := getField(p, INodesTotalByteString)
+				inodesFree := math.Floor(inodesTotal * free / total)
+				setField(p, INodesFreeByteString, inodesFree)
+				setField(p, INodesUsedByteString, inodesTotal-inodesFree)
+			}
+		}
+	}
+	return true
+}
+
+// flatline replaces the field values of the point by the ones of the first
+// point of its series during the incident.
+func (s *IncidentSimulator) flatline(p *Point, inc *Incident) {
+	key := fmt.Sprintf("%p,%s", inc, p.MeasurementName)
+	for _, v := range p.TagValues {
+		key += "," + string(v)
+	}
+	if values, ok := s.frozen[key]; ok {
+		copy(p.FieldValues, values)
+		return
+	}
+	s.frozen[key] = append([]interface{}{}, p.FieldValues...)
+}
+
+func getField(p *Point, key []byte) float64 {
+	for i, k := range p.FieldKeys {
+		if string(k) == string(key) {
+			switch v := p.FieldValues[i].(type) {
+			case int:
+				return float64(v)
+			case int64:
+				return float64(v)
+			case float64:
+				return v
+			}
+		}
+	}
+	return 0
+}
+
+// setField sets a numeric field, keeping the type of its value.
+func setField(p *Point, key []byte, value float64) {
+	for i, k := range p.FieldKeys {
+		if string(k) == string(key) {
+			switch p.FieldValues[i].(type) {
+			case int:
+				p.FieldValues[i] = int(math.Floor(value))
+			case int64:
+				p.FieldValues[i] = int64(math.Floor(value))
+			case float64:
+				p.FieldValues[i] = value
+			}
+			return
+		}
+	}
+}
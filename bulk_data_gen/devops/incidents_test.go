@@ -0,0 +1,207 @@
+package devops
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+var incidentTestStart = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestParseIncidents(t *testing.T) {
+	got, err := ParseIncidents("cpu-spike@2018-01-01T01:00:00Z/15m=host_1+host_3; gap@2018-01-01T05:00:00+02:00/5m=host_2;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Incident{
+		{
+			Kind:         IncidentCPUSpike,
+			Start:        incidentTestStart.Add(time.Hour),
+			End:          incidentTestStart.Add(time.Hour + 15*time.Minute),
+			Hosts:        []string{"host_1", "host_3"},
+			Measurements: []string{"cpu"},
+		},
+		{
+			Kind:  IncidentGap,
+			Start: incidentTestStart.Add(3 * time.Hour),
+			End:   incidentTestStart.Add(3*time.Hour + 5*time.Minute),
+			Hosts: []string{"host_2"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("incidents %+v, want %+v", got, want)
+	}
+}
+
+func TestParseIncidentsErrors(t *testing.T) {
+	for _, tc := range []struct {
+		spec, err string
+	}{
+		{"cpu-spike", "expected <kind>@<start>/<duration>=<hosts>"},
+		{"cpu-spike@2018-01-01T01:00:00Z=host_1", "expected <kind>@<start>/<duration>=<hosts>"},
+		{"cpu-spike@2018-01-01T01:00:00Z/15m", "expected <kind>@<start>/<duration>=<hosts>"},
+		{"cpu-spike@yesterday/15m=host_1", "cannot parse"},
+		{"cpu-spike@2018-01-01T01:00:00Z/soon=host_1", "invalid duration"},
+		{"meltdown@2018-01-01T01:00:00Z/15m=host_1", "unknown incident kind 'meltdown'"},
+		{"gap@2018-01-01T01:00:00Z/0s=host_1", "incident duration must be positive"},
+		{"gap@2018-01-01T01:00:00Z/-5m=host_1", "incident duration must be positive"},
+		{"gap@2018-01-01T01:00:00Z/5m=", "empty host name"},
+		{"gap@2018-01-01T01:00:00Z/5m=host_1+", "empty host name"},
+	} {
+		_, err := ParseIncidents(tc.spec)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: error %v, want %q", tc.spec, err, tc.err)
+		}
+	}
+}
+
+func TestRandomIncidents(t *testing.T) {
+	end := incidentTestStart.Add(6 * time.Hour)
+	churn := HostChurn{Start: incidentTestStart, HostCount: 10, HostOffset: 100, Rate: 0.3, Period: time.Hour}
+	incidents := RandomIncidents(rand.New(rand.NewSource(42)), 200, incidentTestStart, end, churn)
+	if len(incidents) != 200 {
+		t.Fatalf("%d incidents, want 200", len(incidents))
+	}
+	if again := RandomIncidents(rand.New(rand.NewSource(42)), 200, incidentTestStart, end, churn); !reflect.DeepEqual(again, incidents) {
+		t.Error("incidents differ for the same seed")
+	}
+	for _, inc := range incidents {
+		duration := inc.End.Sub(inc.Start)
+		if inc.Start.Before(incidentTestStart) || inc.End.After(end) ||
+			duration < MinRandomIncidentDuration || duration > MaxRandomIncidentDuration {
+			t.Errorf("incident from %v to %v", inc.Start, inc.End)
+		}
+		if len(inc.Hosts) < 1 || len(inc.Hosts) > 3 {
+			t.Errorf("incident of %d hosts", len(inc.Hosts))
+		}
+		live := make(map[string]bool)
+		for _, id := range churn.LiveHosts(inc.Start, inc.End) {
+			live[fmt.Sprintf("host_%d", id)] = true
+		}
+		for _, h := range inc.Hosts {
+			if !live[h] {
+				t.Errorf("incident from %v to %v of %s, which has no data then", inc.Start, inc.End, h)
+			}
+		}
+	}
+}
+
+// incidentTestPoints returns the points of a small devops simulation, with
+// the given incidents.
+func incidentTestPoints(t *testing.T, incidents []Incident) []*common.Point {
+	cfg := &DevopsSimulatorConfig{
+		Start:     incidentTestStart,
+		End:       incidentTestStart.Add(time.Hour),
+		HostCount: 3,
+		Rand:      rand.New(rand.NewSource(42)),
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	devops := cfg.ToSimulator()
+	var sim common.Simulator = devops
+	if incidents != nil {
+		sim = NewIncidentSimulator(devops, incidents)
+	}
+	var points []*common.Point
+	for !sim.Finished() {
+		p := common.MakeUsablePoint()
+		sim.Next(p)
+		ts := *p.Timestamp
+		p.SetTimestamp(&ts)
+		points = append(points, p)
+	}
+	return points
+}
+
+func pointHost(p *common.Point) string {
+	return string(p.TagValues[0])
+}
+
+// seriesKey identifies the series of a point.
+func seriesKey(p *common.Point) string {
+	key := string(p.MeasurementName)
+	for i := range p.TagKeys {
+		key += "," + string(p.TagKeys[i]) + "=" + string(p.TagValues[i])
+	}
+	return key
+}
+
+func TestIncidentSimulator(t *testing.T) {
+	at := func(minutes int) time.Time {
+		return incidentTestStart.Add(time.Duration(minutes) * time.Minute)
+	}
+	incidents := []Incident{
+		{Kind: IncidentGap, Start: at(10), End: at(20), Hosts: []string{"host_0"}},
+		{Kind: IncidentFlatline, Start: at(10), End: at(30), Hosts: []string{"host_1"}},
+		{Kind: IncidentMemoryLeak, Start: at(20), End: at(50), Hosts: []string{"host_2"}, Measurements: []string{"mem"}},
+	}
+	within := func(p *common.Point, inc *Incident) bool {
+		return pointHost(p) == inc.Hosts[0] && !p.Timestamp.Before(inc.Start) && p.Timestamp.Before(inc.End)
+	}
+
+	base := incidentTestPoints(t, nil)
+	got := incidentTestPoints(t, incidents)
+
+	// the gap drops the points of its host, and only those:
+	var kept []*common.Point
+	for _, p := range base {
+		if !within(p, &incidents[0]) {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(base) {
+		t.Fatal("no points in the gap")
+	}
+	if len(got) != len(kept) {
+		t.Fatalf("%d points, want %d", len(got), len(kept))
+	}
+	for i, p := range got {
+		if seriesKey(p) != seriesKey(kept[i]) || !p.Timestamp.Equal(*kept[i].Timestamp) {
+			t.Fatalf("point %d: %s at %v, want %s at %v", i, seriesKey(p), p.Timestamp, seriesKey(kept[i]), kept[i].Timestamp)
+		}
+	}
+
+	frozen := make(map[string][]interface{})
+	var leaked []float64
+	used := func(p *common.Point) float64 {
+		return getField(p, MemoryFieldKeys[2]) / getField(p, MemoryFieldKeys[0])
+	}
+	for i, p := range got {
+		switch {
+		case within(p, &incidents[1]):
+			// the flatline repeats the first values of each series:
+			key := seriesKey(p)
+			if values, ok := frozen[key]; !ok {
+				frozen[key] = p.FieldValues
+			} else if !reflect.DeepEqual(p.FieldValues, values) {
+				t.Fatalf("flatlined %s: %v, then %v", key, values, p.FieldValues)
+			}
+		case within(p, &incidents[2]) && string(p.MeasurementName) == "mem":
+			if used(p) < used(kept[i]) {
+				t.Errorf("used memory at %v: %v of the total, %v without the leak", p.Timestamp, used(p), used(kept[i]))
+			}
+			leaked = append(leaked, used(p))
+		default:
+			if !reflect.DeepEqual(p.FieldValues, kept[i].FieldValues) {
+				t.Fatalf("%s at %v changed out of incidents", seriesKey(p), p.Timestamp)
+			}
+		}
+	}
+	if len(frozen) == 0 {
+		t.Error("no flatlined series")
+	}
+
+	// the used memory grows to the total memory:
+	if len(leaked) == 0 {
+		t.Fatal("no leaking memory")
+	}
+	if last := leaked[len(leaked)-1]; last < 0.99 || last > 1 {
+		t.Errorf("used memory at the end of the leak: %v of the total", last)
+	}
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"fmt"
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"strings"
+)
+
+var (
+	AppLogByteString = []byte("app_log") // heap optimization
+	LevelTagKey      = []byte("level")
+
+	// Field keys for 'app_log' points. The error is empty, except for
+	// error logs.
+	AppLogFieldKeys = [][]byte{
+		[]byte("logger"),
+		[]byte("message"),
+		[]byte("error"),
+	}
+)
+
+func (in *Instance) logToPoint(p *Point, level []byte) {
+	req := &in.request
+	r := in.r
+	p.SetMeasurementName(AppLogByteString)
+	p.SetTimestamp(&req.timestamp)
+
+	appendTags(p, in.Tags)
+	p.AppendTag(LevelTagKey, level)
+	p.AppendTag(TraceIdTagKey, req.traceId)
+
+	var logger, message, err string
+	switch string(level) {
+	case string(LevelError):
+		logger = LoggerChoices[r.Intn(len(LoggerChoices))]
+		message = fmt.Sprintf("%s %s failed with status %d after %.1f ms", req.method, req.path, req.status, req.latency)
+		err = ErrorChoices[r.Intn(len(ErrorChoices))]
+		if strings.Contains(err, "%d") {
+			err = fmt.Sprintf(err, r.Intn(256))
+		}
+	case string(LevelWarn):
+		logger = LoggerChoices[0]
+		message = fmt.Sprintf("%s %s rejected with status %d, user agent: %s", req.method, req.path, req.status, req.userAgent)
+	case string(LevelDebug):
+		logger = LoggerChoices[1+r.Intn(len(LoggerChoices)-1)]
+		message = fmt.Sprintf("cache lookup for %s took %.3f ms", req.path, req.latency*r.Float64()/10)
+	default:
+		logger = LoggerChoices[0]
+		message = fmt.Sprintf("%s %s completed with status %d in %.1f ms, %d bytes", req.method, req.path, req.status, req.latency, req.bytes)
+	}
+
+	p.AppendField(AppLogFieldKeys[0], logger)
+	p.AppendField(AppLogFieldKeys[1], message)
+	p.AppendField(AppLogFieldKeys[2], err)
+}
@@ -0,0 +1,154 @@
+package events
+
+import (
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
+	"time"
+)
+
+// EventWindow is the time window of a round: each round holds the events of
+// every instance in a window, instance after instance.
+const EventWindow = time.Second
+
+// Type EventsSimulatorConfig is used to create an EventsSimulator.
+type EventsSimulatorConfig struct {
+	Start time.Time
+	End   time.Time
+
+	InstanceCount  int64
+	InstanceOffset int64
+
+	// Rand seeds the random sources of the instances.
+	Rand *rand.Rand
+}
+
+func (d *EventsSimulatorConfig) ToSimulator() *EventsSimulator {
+	return d.newSimulator(d.newInstances())
+}
+
+// ToSimulatorSlices creates simulators for up to n contiguous slices of the
+// instances, to be run in parallel. Merged round by round, in slice order,
+// their points are identical to the points of the simulator made by
+// ToSimulator.
+func (d *EventsSimulatorConfig) ToSimulatorSlices(n int) []*EventsSimulator {
+	instances := d.newInstances()
+	bounds := SliceBounds(len(instances), n)
+
+	sims := make([]*EventsSimulator, len(bounds))
+	for i, b := range bounds {
+		sims[i] = d.newSimulator(instances[b[0]:b[1]])
+	}
+	return sims
+}
+
+func (d *EventsSimulatorConfig) newInstances() []*Instance {
+	seed := d.Rand.Int63()
+	instances := make([]*Instance, d.InstanceCount)
+	for i := 0; i < len(instances); i++ {
+		instances[i] = NewInstance(NewRand(seed, int64(i)+d.InstanceOffset), i, int(d.InstanceOffset), d.Start)
+	}
+	return instances
+}
+
+func (d *EventsSimulatorConfig) newSimulator(instances []*Instance) *EventsSimulator {
+	duration := d.End.Sub(d.Start)
+	var expectedPoints float64
+	for _, in := range instances {
+		expectedPoints += in.ExpectedEvents(duration)
+	}
+	g := &EventsSimulator{
+		expectedPoints: int64(expectedPoints),
+
+		windows: int64((duration + EventWindow - 1) / EventWindow),
+
+		instances: instances,
+
+		timestampStart: d.Start,
+		timestampEnd:   d.End,
+	}
+	g.windowEnd = g.windowEndAt(0)
+	g.seek()
+	return g
+}
+
+// An EventsSimulator generates the events of application instances: their
+// requests and logs, at irregular times.
+// It fulfills the Simulator interface.
+type EventsSimulator struct {
+	madePoints     int64
+	madeValues     int64
+	expectedPoints int64
+
+	// the window of the next point, and of the last one:
+	window    int64
+	windowEnd time.Time
+	round     int64
+	windows   int64
+
+	// the instance having the next point:
+	instanceIndex int
+	instances     []*Instance
+
+	timestampStart time.Time
+	timestampEnd   time.Time
+}
+
+func (g *EventsSimulator) SeenPoints() int64 {
+	return g.madePoints
+}
+
+func (g *EventsSimulator) SeenValues() int64 {
+	return g.madeValues
+}
+
+// Total returns the expected number of points: events happening at random,
+// the actual number is only known once the simulation is finished.
+func (g *EventsSimulator) Total() int64 {
+	return g.expectedPoints
+}
+
+func (g *EventsSimulator) Finished() bool {
+	return g.window >= g.windows
+}
+
+// Round returns the round of the last generated point, which is the index of
+// its window.
+func (g *EventsSimulator) Round() int64 {
+	return g.round
+}
+
+func (g *EventsSimulator) Rounds() int64 {
+	return g.windows
+}
+
+// Next advances a Point to the next state in the generator.
+func (g *EventsSimulator) Next(p *Point) {
+	g.instances[g.instanceIndex].NextEvent(p)
+	g.round = g.window
+	g.madePoints++
+	g.madeValues += int64(len(p.FieldValues))
+	g.seek()
+}
+
+// seek finds the instance having the next event, moving on to the next
+// windows as needed.
+func (g *EventsSimulator) seek() {
+	for g.window < g.windows {
+		for ; g.instanceIndex < len(g.instances); g.instanceIndex++ {
+			if g.instances[g.instanceIndex].HasEvent(g.windowEnd) {
+				return
+			}
+		}
+		g.instanceIndex = 0
+		g.window++
+		g.windowEnd = g.windowEndAt(g.window)
+	}
+}
+
+func (g *EventsSimulator) windowEndAt(window int64) time.Time {
+	end := g.timestampStart.Add(EventWindow * time.Duration(window+1))
+	if end.After(g.timestampEnd) {
+		return g.timestampEnd
+	}
+	return end
+}
@@ -0,0 +1,41 @@
+package events
+
+import (
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+var (
+	HttpRequestByteString = []byte("http_request") // heap optimization
+	MethodTagKey          = []byte("method")
+
+	// Field keys for 'http_request' points.
+	HttpRequestFieldKeys = [][]byte{
+		[]byte("path"),
+		[]byte("user_agent"),
+		[]byte("status"),
+		[]byte("latency_ms"),
+		[]byte("response_bytes"),
+	}
+)
+
+func (in *Instance) requestToPoint(p *Point) {
+	req := &in.request
+	p.SetMeasurementName(HttpRequestByteString)
+	p.SetTimestamp(&req.timestamp)
+
+	appendTags(p, in.Tags)
+	p.AppendTag(MethodTagKey, req.method)
+	p.AppendTag(TraceIdTagKey, req.traceId)
+
+	p.AppendField(HttpRequestFieldKeys[0], req.path)
+	p.AppendField(HttpRequestFieldKeys[1], req.userAgent)
+	p.AppendField(HttpRequestFieldKeys[2], req.status)
+	p.AppendField(HttpRequestFieldKeys[3], req.latency)
+	p.AppendField(HttpRequestFieldKeys[4], req.bytes)
+}
+
+func appendTags(p *Point, values [][]byte) {
+	for i, v := range values {
+		p.AppendTag(InstanceTagKeys[i], v)
+	}
+}
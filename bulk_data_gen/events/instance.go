@@ -0,0 +1,259 @@
+package events
+
+import (
+	"fmt"
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+var (
+	// Tag keys of the points of an application instance.
+	InstanceTagKeys = [][]byte{
+		[]byte("service"),
+		[]byte("instance"),
+		[]byte("region"),
+		[]byte("version"),
+	}
+
+	// TraceIdTagKey identifies the request an event belongs to. Its values
+	// are unique per request.
+	TraceIdTagKey = []byte("trace_id")
+)
+
+var (
+	ServiceChoices = [][]byte{
+		[]byte("api-gateway"),
+		[]byte("auth"),
+		[]byte("catalog"),
+		[]byte("cart"),
+		[]byte("checkout"),
+		[]byte("payments"),
+		[]byte("search"),
+		[]byte("recommendations"),
+		[]byte("notifications"),
+		[]byte("accounts"),
+	}
+
+	RegionChoices = [][]byte{
+		[]byte("us-east-1"),
+		[]byte("us-west-2"),
+		[]byte("eu-west-1"),
+		[]byte("eu-central-1"),
+		[]byte("ap-southeast-1"),
+	}
+
+	VersionChoices = [][]byte{
+		[]byte("v1.8.2"),
+		[]byte("v1.9.0"),
+		[]byte("v2.0.0-rc1"),
+	}
+
+	// Request methods, and how often they are used.
+	MethodChoices = [][]byte{
+		[]byte("GET"),
+		[]byte("POST"),
+		[]byte("PUT"),
+		[]byte("DELETE"),
+	}
+	MethodWeights = []float64{0.7, 0.18, 0.08, 0.04}
+
+	// Path templates, %d is replaced by a resource id.
+	PathChoices = []string{
+		"/",
+		"/health",
+		"/api/v1/items",
+		"/api/v1/items/%d",
+		"/api/v1/items/%d/reviews",
+		"/api/v1/users/%d",
+		"/api/v1/users/%d/orders",
+		"/api/v1/orders/%d",
+		"/api/v1/cart/%d/lines",
+		"/api/v2/search",
+		"/static/js/app.%d.js",
+	}
+
+	UserAgentChoices = []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/63.0.3239.84 Safari/537.36",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_13_2) AppleWebKit/604.4.7 (KHTML, like Gecko) Version/11.0.2 Safari/604.4.7",
+		"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:57.0) Gecko/20100101 Firefox/57.0",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 11_2_1 like Mac OS X) AppleWebKit/604.4.7 (KHTML, like Gecko) Version/11.0 Mobile/15C153 Safari/604.1",
+		"Mozilla/5.0 (Linux; Android 8.0.0; SM-G950F Build/R16NW) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/63.0.3239.111 Mobile Safari/537.36",
+		"curl/7.57.0",
+		"okhttp/3.9.1",
+		"Go-http-client/1.1",
+		"python-requests/2.18.4",
+		"Googlebot/2.1 (+http://www.google.com/bot.html)",
+	}
+
+	ClientErrorStatusChoices = []int64{400, 401, 403, 404, 404, 404, 409, 429}
+	ServerErrorStatusChoices = []int64{500, 502, 503, 504}
+
+	LoggerChoices = []string{
+		"http.server",
+		"http.client",
+		"db.pool",
+		"cache.client",
+		"auth.middleware",
+		"queue.producer",
+	}
+
+	ErrorChoices = []string{
+		"context deadline exceeded",
+		"upstream request timeout after %d ms",
+		"dial tcp 10.0.%d.12:5432: connect: connection refused",
+		"pq: deadlock detected",
+		"redis: connection pool timeout",
+		"runtime error: invalid memory address or nil pointer dereference",
+		"circuit breaker open for upstream %d",
+		"kafka: client has run out of available brokers",
+	}
+)
+
+// Log levels:
+var (
+	LevelDebug = []byte("debug")
+	LevelInfo  = []byte("info")
+	LevelWarn  = []byte("warn")
+	LevelError = []byte("error")
+)
+
+// Instance is a running instance of an application service, handling
+// requests which arrive at random (a Poisson process) and logging about
+// some of them.
+type Instance struct {
+	r *rand.Rand
+
+	// values of InstanceTagKeys:
+	Tags [][]byte
+
+	requestRate     float64 // requests per second
+	clientErrorRate float64
+	serverErrorRate float64
+	latency         float64 // median, in milliseconds
+
+	// arrival time of the next request:
+	next time.Time
+
+	// the last request, and the levels of the log lines it still has to emit:
+	request request
+	logs    [][]byte
+}
+
+type request struct {
+	timestamp time.Time
+	traceId   []byte
+	method    []byte
+	path      string
+	userAgent string
+	status    int64
+	latency   float64
+	bytes     int64
+}
+
+func NewInstance(r *rand.Rand, i int, offset int, start time.Time) *Instance {
+	service := ServiceChoices[r.Intn(len(ServiceChoices))]
+	in := &Instance{
+		r: r,
+		Tags: [][]byte{
+			service,
+			[]byte(fmt.Sprintf("%s-%d", service, i+offset)),
+			RegionChoices[r.Intn(len(RegionChoices))],
+			VersionChoices[r.Intn(len(VersionChoices))],
+		},
+
+		requestRate:     0.2 + 1.8*r.Float64(),
+		clientErrorRate: 0.02 + 0.06*r.Float64(),
+		serverErrorRate: 0.002 + 0.02*r.Float64(),
+		latency:         5 + 75*r.Float64(),
+
+		next: start,
+	}
+	in.next = in.next.Add(in.interval())
+	return in
+}
+
+// ExpectedEvents returns the average number of events (requests and log
+// lines) of the instance in d.
+func (in *Instance) ExpectedEvents(d time.Duration) float64 {
+	okRate := 1 - in.clientErrorRate - in.serverErrorRate
+	logsPerRequest := in.clientErrorRate + in.serverErrorRate + okRate*(0.2+0.05)
+	return in.requestRate * d.Seconds() * (1 + logsPerRequest)
+}
+
+// interval draws the time to the next request.
+func (in *Instance) interval() time.Duration {
+	return time.Duration(in.r.ExpFloat64() / in.requestRate * float64(time.Second))
+}
+
+// HasEvent returns whether the instance has an event before end.
+func (in *Instance) HasEvent(end time.Time) bool {
+	return len(in.logs) > 0 || in.next.Before(end)
+}
+
+// NextEvent fills p with the next event of the instance. The log lines of a
+// request come right after it, with its timestamp.
+func (in *Instance) NextEvent(p *Point) {
+	if len(in.logs) > 0 {
+		in.logToPoint(p, in.logs[0])
+		in.logs = in.logs[1:]
+		return
+	}
+	in.newRequest(in.next)
+	in.next = in.next.Add(in.interval())
+	in.requestToPoint(p)
+}
+
+func (in *Instance) newRequest(t time.Time) {
+	r := in.r
+	req := &in.request
+	req.timestamp = t
+	req.traceId = []byte(fmt.Sprintf("%016x%016x", r.Uint64(), r.Uint64()))
+	req.method = MethodChoices[weightedChoice(r, MethodWeights)]
+	req.path = PathChoices[r.Intn(len(PathChoices))]
+	if strings.Contains(req.path, "%d") {
+		req.path = fmt.Sprintf(req.path, r.Intn(100000))
+	}
+	req.userAgent = UserAgentChoices[r.Intn(len(UserAgentChoices))]
+
+	// log-normal latencies, with a long tail for failed requests:
+	req.latency = in.latency * math.Exp(r.NormFloat64()*0.6)
+	in.logs = in.logs[:0]
+	switch x := r.Float64(); {
+	case x < in.serverErrorRate:
+		req.status = ServerErrorStatusChoices[r.Intn(len(ServerErrorStatusChoices))]
+		req.latency *= 1 + 20*r.Float64()
+		req.bytes = 0
+		in.logs = append(in.logs, LevelError)
+	case x < in.serverErrorRate+in.clientErrorRate:
+		req.status = ClientErrorStatusChoices[r.Intn(len(ClientErrorStatusChoices))]
+		req.bytes = 64 + r.Int63n(512)
+		in.logs = append(in.logs, LevelWarn)
+	default:
+		req.status = 200
+		if req.method[0] == 'P' && r.Intn(2) == 0 {
+			req.status = 201
+		}
+		req.bytes = int64(math.Exp(6 + 3*r.Float64()))
+		if r.Float64() < 0.2 {
+			in.logs = append(in.logs, LevelInfo)
+		}
+		if r.Float64() < 0.05 {
+			in.logs = append(in.logs, LevelDebug)
+		}
+	}
+}
+
+// weightedChoice returns a random index of weights, which sum to 1.
+func weightedChoice(r *rand.Rand, weights []float64) int {
+	x := r.Float64()
+	for i, w := range weights {
+		if x < w {
+			return i
+		}
+		x -= w
+	}
+	return len(weights) - 1
+}
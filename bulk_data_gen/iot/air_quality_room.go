@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -24,14 +25,14 @@ type AirQualityRoomMeasurement struct {
 	distributions []Distribution
 }
 
-func NewAirQualityRoomMeasurement(start time.Time, id []byte) *AirQualityRoomMeasurement {
+func NewAirQualityRoomMeasurement(r *rand.Rand, start time.Time, id []byte) *AirQualityRoomMeasurement {
 	distributions := make([]Distribution, len(AirQualityRoomFieldKeys))
 	//co2_level
-	distributions[0] = MUDWD(ND(0, 1), 200, 3000, 300)
+	distributions[0] = MUDWD(ND(r, 0, 1), 200, 3000, 300)
 	//co_level
-	distributions[1] = MUDWD(ND(0.001, 0.0001), 0, 10, 0)
+	distributions[1] = MUDWD(ND(r, 0.001, 0.0001), 0, 10, 0)
 	//battery_voltage
-	distributions[2] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[2] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &AirQualityRoomMeasurement{
 		timestamp:     start,
@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -24,14 +25,14 @@ type AirConditionRoomMeasurement struct {
 	distributions []Distribution
 }
 
-func NewAirConditionRoomMeasurement(start time.Time, id []byte) *AirConditionRoomMeasurement {
+func NewAirConditionRoomMeasurement(r *rand.Rand, start time.Time, id []byte) *AirConditionRoomMeasurement {
 	distributions := make([]Distribution, len(AirConditionRoomFieldKeys))
 	//temperature
-	distributions[0] = MUDWD(ND(0, 1), 15, 28, 15)
+	distributions[0] = MUDWD(ND(r, 0, 1), 15, 28, 15)
 	//humidity
-	distributions[1] = MUDWD(ND(0, 1), 25, 60, 40)
+	distributions[1] = MUDWD(ND(r, 0, 1), 25, 60, 40)
 	//battery_voltage
-	distributions[2] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[2] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &AirConditionRoomMeasurement{
 		timestamp:     start,
@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -24,14 +25,14 @@ type AirConditionOutdoorMeasurement struct {
 	distributions []Distribution
 }
 
-func NewAirConditionOutdoorMeasurement(start time.Time, id []byte) *AirConditionOutdoorMeasurement {
+func NewAirConditionOutdoorMeasurement(r *rand.Rand, start time.Time, id []byte) *AirConditionOutdoorMeasurement {
 	distributions := make([]Distribution, len(AirConditionOutdoorFieldKeys))
 	//temperature
-	distributions[0] = MUDWD(ND(0, 1), -20, 28, 0)
+	distributions[0] = MUDWD(ND(r, 0, 1), -20, 28, 0)
 	//humidity
-	distributions[1] = MUDWD(ND(0, 1), 5, 95, 80)
+	distributions[1] = MUDWD(ND(r, 0, 1), 5, 95, 80)
 	//battery_voltage
-	distributions[2] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[2] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &AirConditionOutdoorMeasurement{
 		timestamp:     start,
@@ -55,34 +55,36 @@ type CameraDetectionMeasurement struct {
 	batteryDist Distribution
 	object      []byte
 	kind        []byte
+	rand        *rand.Rand
 }
 
-func NewCameraDetectionMeasurement(start time.Time, id []byte) *CameraDetectionMeasurement {
+func NewCameraDetectionMeasurement(r *rand.Rand, start time.Time, id []byte) *CameraDetectionMeasurement {
 
 	//battery_voltage
-	batteryDist := MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	batteryDist := MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	cd := &CameraDetectionMeasurement{
 		timestamp:   start,
 		batteryDist: batteryDist,
 		sensorId:    id,
+		rand:        r,
 	}
 	cd.newDetection()
 	return cd
 }
 
 func (m *CameraDetectionMeasurement) newDetection() {
-	object := rand.Int63n(int64(len(DetectionObjects)))
+	object := m.rand.Int63n(int64(len(DetectionObjects)))
 	m.object = DetectionObjects[object]
 	switch object {
 	case 0: //animal
-		m.kind = Animals[rand.Int63n(int64(len(Animals)))]
+		m.kind = Animals[m.rand.Int63n(int64(len(Animals)))]
 		break
 	case 1: //human
-		m.kind = Humans[rand.Int63n(int64(len(Humans)))]
+		m.kind = Humans[m.rand.Int63n(int64(len(Humans)))]
 		break
 	case 2: //vehicle
-		m.kind = Vehicles[rand.Int63n(int64(len(Vehicles)))]
+		m.kind = Vehicles[m.rand.Int63n(int64(len(Vehicles)))]
 		break
 	case 3: //uknown
 		m.kind = []byte("uknown")
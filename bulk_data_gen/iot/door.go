@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -25,12 +26,12 @@ type DoorMeasurement struct {
 	distributions []Distribution
 }
 
-func NewDoorMeasurement(start time.Time, doorId []byte, sendorId []byte) *DoorMeasurement {
+func NewDoorMeasurement(r *rand.Rand, start time.Time, doorId []byte, sendorId []byte) *DoorMeasurement {
 	distributions := make([]Distribution, len(DoorFieldKeys))
 	//state
-	distributions[0] = TSD(0, 1, 0)
+	distributions[0] = TSD(r, 0, 1, 0)
 	//battery_voltage
-	distributions[1] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[1] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &DoorMeasurement{
 		timestamp:     start,
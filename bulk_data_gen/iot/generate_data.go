@@ -3,6 +3,7 @@ package iot
 import (
 	"fmt"
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -13,26 +14,105 @@ type IotSimulatorConfig struct {
 
 	SmartHomeCount  int64
 	SmartHomeOffset int64
+
+	// SamplingInterval is the duration of an epoch (EpochDuration if zero).
+	SamplingInterval time.Duration
+	// MeasurementIntervals sets the collection interval of measurements by
+	// name, as multiples of SamplingInterval. The others are collected every
+	// epoch.
+	MeasurementIntervals map[string]time.Duration
+
+	// Rand seeds the random sources of the smart homes.
+	Rand *rand.Rand
+}
+
+// Validate checks the sampling configuration.
+func (d *IotSimulatorConfig) Validate() error {
+	_, err := d.measurementEvery()
+	return err
+}
+
+// EffectiveSamplingInterval returns the sampling interval, EpochDuration if
+// unset.
+func (d *IotSimulatorConfig) EffectiveSamplingInterval() time.Duration {
+	if d.SamplingInterval == 0 {
+		return EpochDuration
+	}
+	return d.SamplingInterval
+}
+
+// measurementEvery returns the collection interval, in epochs, of the
+// measurements not collected every epoch.
+func (d *IotSimulatorConfig) measurementEvery() (map[string]int64, error) {
+	return SamplingMultiples(d.EffectiveSamplingInterval(), d.MeasurementIntervals, MeasurementNames)
 }
 
 func (d *IotSimulatorConfig) ToSimulator() *IotSimulator {
-	homeInfos := make([]*SmartHome, d.SmartHomeCount)
-	var measNum int64
+	homes := d.newHomes()
+	return d.newSimulator(homes, maxMeasurements(homes))
+}
 
+// ToSimulatorSlices creates simulators for up to n contiguous slices of the
+// smart homes, to be run in parallel. Merged round by round, in slice order,
+// their points are identical to the points of the simulator made by
+// ToSimulator.
+func (d *IotSimulatorConfig) ToSimulatorSlices(n int) []*IotSimulator {
+	homes := d.newHomes()
+	roundsPerEpoch := maxMeasurements(homes)
+	bounds := SliceBounds(len(homes), n)
+
+	sims := make([]*IotSimulator, len(bounds))
+	for i, b := range bounds {
+		sims[i] = d.newSimulator(homes[b[0]:b[1]], roundsPerEpoch)
+	}
+	return sims
+}
+
+func (d *IotSimulatorConfig) newHomes() []*SmartHome {
+	every, err := d.measurementEvery()
+	if err != nil {
+		panic(err.Error())
+	}
+	seed := d.Rand.Int63()
+	// the sensor ids are numbered from 1 in each simulation:
+	LastSensorId = 0
+	homeInfos := make([]*SmartHome, d.SmartHomeCount)
 	for i := 0; i < len(homeInfos); i++ {
-		homeInfos[i] = NewSmartHome(i, int(d.SmartHomeOffset), d.Start)
-		measNum += int64(homeInfos[i].NumMeasurements())
+		homeInfos[i] = NewSmartHome(NewRand(seed, int64(i)+d.SmartHomeOffset), i, int(d.SmartHomeOffset), d.Start, every)
 	}
+	return homeInfos
+}
 
-	epochs := d.End.Sub(d.Start).Nanoseconds() / EpochDuration.Nanoseconds()
-	maxPoints := epochs * measNum
+// maxMeasurements returns the highest measurement count of the homes, which
+// is the number of rounds needed to emit one epoch.
+func maxMeasurements(homes []*SmartHome) int64 {
+	var max int64
+	for _, h := range homes {
+		if n := int64(h.NumMeasurements()); n > max {
+			max = n
+		}
+	}
+	return max
+}
+
+func (d *IotSimulatorConfig) newSimulator(homes []*SmartHome, roundsPerEpoch int64) *IotSimulator {
+	epochs := d.End.Sub(d.Start).Nanoseconds() / d.EffectiveSamplingInterval().Nanoseconds()
+	var maxPoints int64
+	for _, h := range homes {
+		maxPoints += h.NumPoints(epochs)
+	}
 	dg := &IotSimulator{
 		madePoints: 0,
 		madeValues: 0,
 		maxPoints:  maxPoints,
 
+		roundsPerEpoch: roundsPerEpoch,
+		rounds:         epochs * roundsPerEpoch,
+
 		currentHomeIndex: 0,
-		homes:            homeInfos,
+		homes:            homes,
+
+		samplingInterval: d.EffectiveSamplingInterval(),
 
 		timestampNow:   d.Start,
 		timestampStart: d.Start,
@@ -50,9 +130,18 @@ type IotSimulator struct {
 	madeValues    int64
 	skippedPoints int64
 
+	// Each round holds one measurement of every home having measurements left
+	// in the current epoch:
+	epoch          int64
+	epochRound     int64
+	roundsPerEpoch int64
+	rounds         int64
+
 	currentHomeIndex int
 	homes            []*SmartHome
 
+	samplingInterval time.Duration
+
 	timestampNow   time.Time
 	timestampStart time.Time
 	timestampEnd   time.Time
@@ -74,6 +163,15 @@ func (g *IotSimulator) Finished() bool {
 	return (g.madePoints + g.skippedPoints) >= g.maxPoints
 }
 
+// Round returns the round of the last generated point.
+func (g *IotSimulator) Round() int64 {
+	return g.epoch*g.roundsPerEpoch + g.epochRound
+}
+
+func (g *IotSimulator) Rounds() int64 {
+	return g.rounds
+}
+
 // Next advances a Point to the next state in the generator.
 func (g *IotSimulator) Next(p *Point) {
 	for {
@@ -83,6 +181,7 @@ func (g *IotSimulator) Next(p *Point) {
 		for homesSeen < len(g.homes) {
 			if g.currentHomeIndex == len(g.homes) {
 				g.currentHomeIndex = 0
+				g.epochRound++
 			}
 			if g.homes[g.currentHomeIndex].HasMoreMeasurements() {
 				homeFound = true
@@ -93,11 +192,10 @@ func (g *IotSimulator) Next(p *Point) {
 		}
 
 		if !homeFound {
-			for i := 0; i < len(g.homes); i++ {
-				g.homes[i].TickAll(EpochDuration)
-				g.homes[i].ResetMeasurementCounter()
-			}
+			g.tickAll()
 			g.currentHomeIndex = 0
+			g.epoch++
+			g.epochRound = 0
 		}
 		sm := g.homes[g.currentHomeIndex].NextMeasurement(p)
 		if sm == nil {
@@ -105,6 +203,11 @@ func (g *IotSimulator) Next(p *Point) {
 		}
 		g.currentHomeIndex++
 
+		if s, ok := sm.(*SampledMeasurement); ok && !s.Due() {
+			// not collected in this epoch, and not counted in maxPoints either
+			p.Reset()
+			continue
+		}
 		if !sm.ToPoint(p) {
 			p.Reset()
 			g.skippedPoints++
@@ -115,3 +218,11 @@ func (g *IotSimulator) Next(p *Point) {
 		break
 	}
 }
+
+// tickAll advances all homes to the next epoch.
+func (g *IotSimulator) tickAll() {
+	for i := 0; i < len(g.homes); i++ {
+		g.homes[i].TickAll(g.samplingInterval)
+		g.homes[i].ResetMeasurementCounter()
+	}
+}
@@ -24,16 +24,18 @@ type HomeConfigMeasurement struct {
 	timestamp      time.Time
 	config         []byte
 	updateValue    bool
+	rand           *rand.Rand
 }
 
-func NewHomeConfigMeasurement(start time.Time, id []byte) *HomeConfigMeasurement {
+func NewHomeConfigMeasurement(r *rand.Rand, start time.Time, id []byte) *HomeConfigMeasurement {
 
 	return &HomeConfigMeasurement{
 		timestamp:      start,
 		lastChange:     start,
 		sensorId:       id,
-		config:         genRandomString(),
-		changeInterval: time.Hour * time.Duration(rand.Int63n(12)+1),
+		config:         genRandomString(r),
+		changeInterval: time.Hour * time.Duration(r.Int63n(12)+1),
+		rand:           r,
 	}
 }
 
@@ -41,8 +43,8 @@ func (m *HomeConfigMeasurement) Tick(d time.Duration) {
 	m.timestamp = m.timestamp.Add(d)
 	//change config only in random 12 hours interval
 	if m.timestamp.Sub(m.lastChange) > m.changeInterval {
-		m.config = genRandomString()
-		m.changeInterval = time.Hour * time.Duration(rand.Int63n(12)+1)
+		m.config = genRandomString(m.rand)
+		m.changeInterval = time.Hour * time.Duration(m.rand.Int63n(12)+1)
 		m.updateValue = true
 		m.lastChange = m.timestamp
 	} else {
@@ -60,14 +62,14 @@ func (m *HomeConfigMeasurement) ToPoint(p *Point) bool {
 	return m.updateValue
 }
 
-func genRandomString() []byte {
+func genRandomString(r *rand.Rand) []byte {
 	//len 10-20k
-	len := int((rand.Int63n(10) + 10) * 1024)
+	len := int((r.Int63n(10) + 10) * 1024)
 	buff := make([]byte, len)
 	for i := 0; i < len; i++ {
-		buff[i] = byte(rand.Int63n(87) + 40)
+		buff[i] = byte(r.Int63n(87) + 40)
 		for buff[i] == 92 {
-			buff[i] = byte(rand.Int63n(87) + 40)
+			buff[i] = byte(r.Int63n(87) + 40)
 		}
 	}
 	return buff
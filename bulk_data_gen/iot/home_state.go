@@ -28,19 +28,21 @@ type HomeStateMeasurement struct {
 	sensorId  []byte
 	timestamp time.Time
 	state     int64
+	rand      *rand.Rand
 }
 
-func NewHomeStateMeasurement(start time.Time, id []byte) *HomeStateMeasurement {
+func NewHomeStateMeasurement(r *rand.Rand, start time.Time, id []byte) *HomeStateMeasurement {
 
 	return &HomeStateMeasurement{
 		timestamp: start,
 		sensorId:  id,
+		rand:      r,
 	}
 }
 
 func (m *HomeStateMeasurement) Tick(d time.Duration) {
 	m.timestamp = m.timestamp.Add(d)
-	m.state = rand.Int63n(int64(len(HomeStates)))
+	m.state = m.rand.Int63n(int64(len(HomeStates)))
 }
 
 func (m *HomeStateMeasurement) ToPoint(p *Point) bool {
@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -23,12 +24,12 @@ type LightLevelRoomMeasurement struct {
 	distributions []Distribution
 }
 
-func NewLightLevelRoomMeasurement(start time.Time, id []byte) *LightLevelRoomMeasurement {
+func NewLightLevelRoomMeasurement(r *rand.Rand, start time.Time, id []byte) *LightLevelRoomMeasurement {
 	distributions := make([]Distribution, len(LightLevelRoomFieldKeys))
 	//level
-	distributions[0] = MUDWD(ND(0, 1), 0.00001, 1e5, 10000)
+	distributions[0] = MUDWD(ND(r, 0, 1), 0.00001, 1e5, 10000)
 	//battery_voltage
-	distributions[1] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[1] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &LightLevelRoomMeasurement{
 		timestamp:     start,
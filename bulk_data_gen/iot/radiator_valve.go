@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -25,12 +26,12 @@ type RadiatorValveRoomMeasurement struct {
 	distributions []Distribution
 }
 
-func NewRadiatorValveRoomMeasurement(start time.Time, randiatorId []byte, sensorId []byte) *RadiatorValveRoomMeasurement {
+func NewRadiatorValveRoomMeasurement(r *rand.Rand, start time.Time, randiatorId []byte, sensorId []byte) *RadiatorValveRoomMeasurement {
 	distributions := make([]Distribution, len(RadiatorValveRoomFieldKeys))
 	//opening_level
-	distributions[0] = CWD(ND(0, 1), 0.0, 100, 0)
+	distributions[0] = CWD(ND(r, 0, 1), 0.0, 100, 0)
 	//battery_voltage
-	distributions[1] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[1] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &RadiatorValveRoomMeasurement{
 		timestamp:     start,
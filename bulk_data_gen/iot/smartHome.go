@@ -11,6 +11,23 @@ var (
 	// The duration of a log epoch.
 	EpochDuration = 60 * time.Second
 
+	// Names of all measurements of a smart home:
+	MeasurementNames = [][]byte{
+		AirConditionRoomByteString,
+		AirConditionOutdoorByteString,
+		AirQualityRoomByteString,
+		CameraDetectionByteString,
+		DoorByteString,
+		HomeConfigByteString,
+		HomeStateByteString,
+		LightLevelRoomByteString,
+		RadiatorValveRoomByteString,
+		WaterLeakageRoomByteString,
+		WaterLevelByteString,
+		WeatherOutdoorByteString,
+		WindowByteString,
+	}
+
 	// Tag fields common to all inside sensors:
 	RoomTagKey = []byte("room_id")
 
@@ -41,6 +58,10 @@ type SmartHome struct {
 	currentRoomIndex       int
 	currentMeasurement     int
 	totalMeasurementsGiven int
+	// random source of the home and all its sensors
+	rand *rand.Rand
+	// collection intervals, in epochs, of the measurements not collected every epoch
+	every map[string]int64
 }
 
 var LastSensorId = 0
@@ -52,8 +73,8 @@ func NewSensorId() []byte {
 
 const SmartHomeIdFormat = "%013d"
 
-func NewSmartHome(id int, offset int, start time.Time) *SmartHome {
-	h := &SmartHome{HomeId: []byte(fmt.Sprintf(SmartHomeIdFormat, id+offset))}
+func NewSmartHome(r *rand.Rand, id int, offset int, start time.Time, every map[string]int64) *SmartHome {
+	h := &SmartHome{HomeId: []byte(fmt.Sprintf(SmartHomeIdFormat, id+offset)), rand: r, every: every}
 	h.NewSmartHomeMeasurements(start)
 	return h
 }
@@ -95,41 +116,72 @@ func (h *SmartHome) NumMeasurements() int {
 
 func (h *SmartHome) NewRoom(id int, start time.Time) *room {
 	h.lastRoomId++
-	windowsNum := int(rand.Int63n(3) + 1)
+	windowsNum := int(h.rand.Int63n(3) + 1)
 	sm := make([]SimulatedMeasurement, 0, windowsNum*2+3)
 	for w := 0; w < windowsNum; w++ {
-		sm = append(sm, NewWindowMeasurement(start, []byte(fmt.Sprintf("%d", w+1)), NewSensorId()),
-			NewRadiatorValveRoomMeasurement(start, []byte(fmt.Sprintf("%d", w+1)), NewSensorId()))
+		sm = append(sm, h.sampled(WindowByteString, NewWindowMeasurement(h.rand, start, []byte(fmt.Sprintf("%d", w+1)), NewSensorId())),
+			h.sampled(RadiatorValveRoomByteString, NewRadiatorValveRoomMeasurement(h.rand, start, []byte(fmt.Sprintf("%d", w+1)), NewSensorId())))
 	}
-	sm = append(sm, NewAirConditionRoomMeasurement(start, NewSensorId()))
-	sm = append(sm, NewAirQualityRoomMeasurement(start, NewSensorId()))
-	sm = append(sm, NewLightLevelRoomMeasurement(start, NewSensorId()))
+	sm = append(sm, h.sampled(AirConditionRoomByteString, NewAirConditionRoomMeasurement(h.rand, start, NewSensorId())))
+	sm = append(sm, h.sampled(AirQualityRoomByteString, NewAirQualityRoomMeasurement(h.rand, start, NewSensorId())))
+	sm = append(sm, h.sampled(LightLevelRoomByteString, NewLightLevelRoomMeasurement(h.rand, start, NewSensorId())))
 
 	return &room{RoomId: []byte(fmt.Sprintf("%d", h.lastRoomId)), SimulatedMeasurements: sm}
 }
 
 func (h *SmartHome) NewSmartHomeMeasurements(start time.Time) {
 
-	roomsNum := rand.Int63n(6) + 4
+	roomsNum := h.rand.Int63n(6) + 4
 	h.Rooms = make([]*room, roomsNum)
 	for i := 0; i < int(roomsNum); i++ {
 		h.Rooms[i] = h.NewRoom(i+1, start)
 	}
-	doorsNum := rand.Int63n(3) + 1
+	doorsNum := h.rand.Int63n(3) + 1
 
 	h.SimulatedMeasurements = []SimulatedMeasurement{
-		NewAirConditionOutdoorMeasurement(start, NewSensorId()),
-		NewWeatherOutdoorMeasurement(start, NewSensorId()),
-		NewHomeStateMeasurement(start, NewSensorId()),
-		NewHomeConfigMeasurement(start, NewSensorId()),
-		NewCameraDetectionMeasurement(start, NewSensorId()),
-		NewWaterLevelMeasurement(start, NewSensorId()),
-		NewWaterLeakageRoomMeasurement(start, []byte(fmt.Sprintf("%d", rand.Int63n(roomsNum)+1)), NewSensorId()),
-		NewWaterLeakageRoomMeasurement(start, []byte(fmt.Sprintf("%d", rand.Int63n(roomsNum)+1)), NewSensorId()),
+		h.sampled(AirConditionOutdoorByteString, NewAirConditionOutdoorMeasurement(h.rand, start, NewSensorId())),
+		h.sampled(WeatherOutdoorByteString, NewWeatherOutdoorMeasurement(h.rand, start, NewSensorId())),
+		h.sampled(HomeStateByteString, NewHomeStateMeasurement(h.rand, start, NewSensorId())),
+		h.sampled(HomeConfigByteString, NewHomeConfigMeasurement(h.rand, start, NewSensorId())),
+		h.sampled(CameraDetectionByteString, NewCameraDetectionMeasurement(h.rand, start, NewSensorId())),
+		h.sampled(WaterLevelByteString, NewWaterLevelMeasurement(h.rand, start, NewSensorId())),
+		h.sampled(WaterLeakageRoomByteString, NewWaterLeakageRoomMeasurement(h.rand, start, []byte(fmt.Sprintf("%d", h.rand.Int63n(roomsNum)+1)), NewSensorId())),
+		h.sampled(WaterLeakageRoomByteString, NewWaterLeakageRoomMeasurement(h.rand, start, []byte(fmt.Sprintf("%d", h.rand.Int63n(roomsNum)+1)), NewSensorId())),
 	}
 	for i := 0; i < int(doorsNum); i++ {
-		h.SimulatedMeasurements = append(h.SimulatedMeasurements, NewDoorMeasurement(start, []byte(fmt.Sprintf("%d", i)), NewSensorId()))
+		h.SimulatedMeasurements = append(h.SimulatedMeasurements, h.sampled(DoorByteString, NewDoorMeasurement(h.rand, start, []byte(fmt.Sprintf("%d", i)), NewSensorId())))
+	}
+}
+
+// sampled wraps a measurement collected less often than every epoch.
+func (h *SmartHome) sampled(name []byte, sm SimulatedMeasurement) SimulatedMeasurement {
+	if every, ok := h.every[string(name)]; ok && every > 1 {
+		return NewSampledMeasurement(sm, every)
+	}
+	return sm
+}
+
+// NumPoints returns the number of measurement slots of the home over the
+// given number of epochs, leaving out the epochs a measurement is not
+// collected in.
+func (h *SmartHome) NumPoints(epochs int64) int64 {
+	var n int64
+	count := func(sm SimulatedMeasurement) {
+		if s, ok := sm.(*SampledMeasurement); ok {
+			n += SampledPoints(epochs, s.Every)
+		} else {
+			n += epochs
+		}
+	}
+	for _, room := range h.Rooms {
+		for _, sm := range room.SimulatedMeasurements {
+			count(sm)
+		}
+	}
+	for _, sm := range h.SimulatedMeasurements {
+		count(sm)
 	}
+	return n
 }
 
 // TickAll advances all Distributions of a Host.
@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -24,12 +25,12 @@ type WaterLeakageRoomMeasurement struct {
 	distributions []Distribution
 }
 
-func NewWaterLeakageRoomMeasurement(start time.Time, roomId []byte, sensorId []byte) *WaterLeakageRoomMeasurement {
+func NewWaterLeakageRoomMeasurement(r *rand.Rand, start time.Time, roomId []byte, sensorId []byte) *WaterLeakageRoomMeasurement {
 	distributions := make([]Distribution, len(WaterLeakageRoomFieldKeys))
 	//state
-	distributions[0] = TSD(0, 1, 0)
+	distributions[0] = TSD(r, 0, 1, 0)
 	//battery_voltage
-	distributions[1] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[1] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &WaterLeakageRoomMeasurement{
 		timestamp:     start,
@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -23,12 +24,12 @@ type WaterLevelMeasurement struct {
 	distributions []Distribution
 }
 
-func NewWaterLevelMeasurement(start time.Time, id []byte) *WaterLevelMeasurement {
+func NewWaterLevelMeasurement(r *rand.Rand, start time.Time, id []byte) *WaterLevelMeasurement {
 	distributions := make([]Distribution, len(WaterLevelFieldKeys))
 	//level
-	distributions[0] = MUDWD(ND(0, 1), 0.0, 8000, 5000)
+	distributions[0] = MUDWD(ND(r, 0, 1), 0.0, 8000, 5000)
 	//battery_voltage
-	distributions[1] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[1] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &WaterLevelMeasurement{
 		timestamp:     start,
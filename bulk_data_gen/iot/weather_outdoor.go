@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -26,18 +27,18 @@ type WeatherOutdoorMeasurement struct {
 	distributions []Distribution
 }
 
-func NewWeatherOutdoorMeasurement(start time.Time, id []byte) *WeatherOutdoorMeasurement {
+func NewWeatherOutdoorMeasurement(r *rand.Rand, start time.Time, id []byte) *WeatherOutdoorMeasurement {
 	distributions := make([]Distribution, len(WeatherOutdoorFieldKeys))
 	//pressure
-	distributions[0] = CWD(ND(0, 10), 900, 1200, 1000)
+	distributions[0] = CWD(ND(r, 0, 10), 900, 1200, 1000)
 	//wind_speed
-	distributions[1] = CWD(ND(0, 1), 0, 60, 0)
+	distributions[1] = CWD(ND(r, 0, 1), 0, 60, 0)
 	//wind_direction
-	distributions[2] = CWD(ND(0, 1), 0, 359, 90)
+	distributions[2] = CWD(ND(r, 0, 1), 0, 359, 90)
 	//precipitation
-	distributions[3] = MUDWD(ND(0, 1), 5, 95, 80)
+	distributions[3] = MUDWD(ND(r, 0, 1), 5, 95, 80)
 	//battery_voltage
-	distributions[4] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[4] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &WeatherOutdoorMeasurement{
 		timestamp:     start,
@@ -2,6 +2,7 @@ package iot
 
 import (
 	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
 	"time"
 )
 
@@ -25,12 +26,12 @@ type WindowMeasurement struct {
 	distributions []Distribution
 }
 
-func NewWindowMeasurement(start time.Time, windowId []byte, sensorId []byte) *WindowMeasurement {
+func NewWindowMeasurement(r *rand.Rand, start time.Time, windowId []byte, sensorId []byte) *WindowMeasurement {
 	distributions := make([]Distribution, len(WindowFieldKeys))
 	//state
-	distributions[0] = TSD(0, 1, 0)
+	distributions[0] = TSD(r, 0, 1, 0)
 	//battery_voltage
-	distributions[1] = MUDWD(ND(0.01, 0.005), 1, 3.2, 3.2)
+	distributions[1] = MUDWD(ND(r, 0.01, 0.005), 1, 3.2, 3.2)
 
 	return &WindowMeasurement{
 		timestamp:     start,
@@ -0,0 +1,263 @@
+package kubernetes
+
+import (
+	"fmt"
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"hash/fnv"
+	"math/rand"
+	"time"
+)
+
+var (
+	// The duration of a log epoch, the usual scrape interval of cAdvisor.
+	EpochDuration = 10 * time.Second
+
+	// Names of all measurements of a node:
+	MeasurementNames = [][]byte{
+		NodeByteString,
+		PodByteString,
+		ContainerCPUByteString,
+		ContainerMemoryByteString,
+	}
+
+	// Tag keys, from the outermost to the innermost object. Node points
+	// carry the node tags, pod points add the pod tags and container points
+	// the container tags.
+	NodeTagKeys = [][]byte{
+		[]byte("node_name"),
+		[]byte("zone"),
+		[]byte("instance_type"),
+	}
+	PodTagKeys = [][]byte{
+		[]byte("namespace"),
+		[]byte("deployment"),
+		[]byte("pod_name"),
+	}
+	ContainerTagKeys = [][]byte{
+		[]byte("container_name"),
+		[]byte("image"),
+	}
+
+	// Choices of availability zones of the nodes.
+	ZoneChoices = [][]byte{
+		[]byte("us-east-1a"),
+		[]byte("us-east-1b"),
+		[]byte("us-east-1c"),
+	}
+
+	// Choices of node machine types.
+	InstanceTypes = []InstanceType{
+		{[]byte("m5.xlarge"), 4, 16 << 30},
+		{[]byte("m5.2xlarge"), 8, 32 << 30},
+		{[]byte("c5.4xlarge"), 16, 32 << 30},
+		{[]byte("r5.2xlarge"), 8, 64 << 30},
+	}
+
+	// Workloads lists the deployments pods are created from.
+	Workloads = []Workload{
+		{[]byte("kube-system"), []byte("coredns"), []ContainerSpec{
+			{[]byte("coredns"), []byte("coredns/coredns:1.11.1"), 0.1, 170 << 20},
+		}},
+		{[]byte("kube-system"), []byte("metrics-server"), []ContainerSpec{
+			{[]byte("metrics-server"), []byte("metrics-server/metrics-server:v0.6.4"), 0.1, 200 << 20},
+		}},
+		{[]byte("monitoring"), []byte("prometheus"), []ContainerSpec{
+			{[]byte("prometheus"), []byte("prom/prometheus:v2.48.0"), 2, 8 << 30},
+			{[]byte("config-reloader"), []byte("prometheus-operator/prometheus-config-reloader:v0.70.0"), 0.05, 50 << 20},
+		}},
+		{[]byte("monitoring"), []byte("grafana"), []ContainerSpec{
+			{[]byte("grafana"), []byte("grafana/grafana:10.2.2"), 0.5, 512 << 20},
+		}},
+		{[]byte("ingress-nginx"), []byte("ingress-nginx-controller"), []ContainerSpec{
+			{[]byte("controller"), []byte("ingress-nginx/controller:v1.9.4"), 1, 1 << 30},
+		}},
+		{[]byte("payments"), []byte("payments-api"), []ContainerSpec{
+			{[]byte("api"), []byte("example/payments-api:2.4.1"), 1, 1 << 30},
+			{[]byte("envoy"), []byte("envoyproxy/envoy:v1.28.0"), 0.25, 256 << 20},
+		}},
+		{[]byte("payments"), []byte("payments-worker"), []ContainerSpec{
+			{[]byte("worker"), []byte("example/payments-worker:2.4.1"), 0.5, 512 << 20},
+		}},
+		{[]byte("checkout"), []byte("checkout-web"), []ContainerSpec{
+			{[]byte("web"), []byte("example/checkout-web:1.17.0"), 1, 768 << 20},
+			{[]byte("envoy"), []byte("envoyproxy/envoy:v1.28.0"), 0.25, 256 << 20},
+		}},
+		{[]byte("checkout"), []byte("cart"), []ContainerSpec{
+			{[]byte("cart"), []byte("example/cart:1.17.0"), 0.5, 512 << 20},
+			{[]byte("redis"), []byte("redis:7.2"), 0.25, 1 << 30},
+		}},
+		{[]byte("search"), []byte("search-api"), []ContainerSpec{
+			{[]byte("api"), []byte("example/search-api:3.0.2"), 2, 2 << 30},
+		}},
+		{[]byte("search"), []byte("indexer"), []ContainerSpec{
+			{[]byte("indexer"), []byte("example/search-indexer:3.0.2"), 4, 8 << 30},
+			{[]byte("envoy"), []byte("envoyproxy/envoy:v1.28.0"), 0.25, 256 << 20},
+		}},
+		{[]byte("analytics"), []byte("event-consumer"), []ContainerSpec{
+			{[]byte("consumer"), []byte("example/event-consumer:0.9.3"), 1, 2 << 30},
+		}},
+	}
+)
+
+// tagKeys lists the node, pod and container tag keys, in this order.
+var tagKeys = append(append(append([][]byte{}, NodeTagKeys...), PodTagKeys...), ContainerTagKeys...)
+
+// appendTags adds the tags of an object, given as the values of its
+// leading tagKeys, to a point.
+func appendTags(p *Point, tags [][]byte) {
+	for i, v := range tags {
+		p.AppendTag(tagKeys[i], v)
+	}
+}
+
+const (
+	NodeNameFormat = "node-%d"
+
+	// Bounds of the number of pods scheduled on a node.
+	MinPodsPerNode = 8
+	MaxPodsPerNode = 24
+)
+
+type InstanceType struct {
+	Name        []byte
+	CPUCores    int64
+	MemoryBytes int64
+}
+
+// Workload is a deployment and the containers of its pods.
+type Workload struct {
+	Namespace  []byte
+	Deployment []byte
+	Containers []ContainerSpec
+}
+
+type ContainerSpec struct {
+	Name        []byte
+	Image       []byte
+	CPULimit    float64 // cores
+	MemoryLimit int64   // bytes
+}
+
+// Type Node models a Kubernetes worker node and the pods scheduled on it,
+// as monitored by kube-state-metrics and cAdvisor.
+type Node struct {
+	// These are all assigned once, at Node creation:
+	SimulatedMeasurements []SimulatedMeasurement
+	Tags                  [][]byte
+
+	Name         []byte
+	Zone         []byte
+	InstanceType InstanceType
+
+	// index of the next measurement to emit in the current epoch
+	currentMeasurement int
+	// random source of the node and all its pods
+	rand *rand.Rand
+	// collection intervals, in epochs, of the measurements not collected every epoch
+	every map[string]int64
+}
+
+func NewNode(r *rand.Rand, i int, offset int, start time.Time, every map[string]int64) *Node {
+	n := &Node{
+		Name:         []byte(fmt.Sprintf(NodeNameFormat, i+offset)),
+		Zone:         RandChoice(r, ZoneChoices),
+		InstanceType: InstanceTypes[r.Intn(len(InstanceTypes))],
+		rand:         r,
+		every:        every,
+	}
+	n.Tags = [][]byte{n.Name, n.Zone, n.InstanceType.Name}
+
+	pods := MinPodsPerNode + r.Intn(MaxPodsPerNode-MinPodsPerNode+1)
+	n.SimulatedMeasurements = []SimulatedMeasurement{
+		n.sampled(NodeByteString, NewNodeMeasurement(r, start, n, pods)),
+	}
+	for i := 0; i < pods; i++ {
+		n.newPod(start, &Workloads[r.Intn(len(Workloads))])
+	}
+	return n
+}
+
+// newPod adds the measurements of a pod of the given workload.
+func (n *Node) newPod(start time.Time, w *Workload) {
+	tags := append(append([][]byte{}, n.Tags...), w.Namespace, w.Deployment, PodName(n.rand, w.Deployment))
+	pod := NewPodMeasurement(n.rand, start, tags, w)
+	n.SimulatedMeasurements = append(n.SimulatedMeasurements, n.sampled(PodByteString, pod))
+	for i := range w.Containers {
+		c := &w.Containers[i]
+		ctags := append(append([][]byte{}, tags...), c.Name, c.Image)
+		n.SimulatedMeasurements = append(n.SimulatedMeasurements,
+			n.sampled(ContainerCPUByteString, NewContainerCPUMeasurement(n.rand, start, ctags, c, pod)),
+			n.sampled(ContainerMemoryByteString, NewContainerMemoryMeasurement(n.rand, start, ctags, c, pod)))
+	}
+}
+
+// sampled wraps a measurement collected less often than every epoch.
+func (n *Node) sampled(name []byte, sm SimulatedMeasurement) SimulatedMeasurement {
+	if every, ok := n.every[string(name)]; ok && every > 1 {
+		return NewSampledMeasurement(sm, every)
+	}
+	return sm
+}
+
+// NumPoints returns the number of measurement slots of the node over the
+// given number of epochs, leaving out the epochs a measurement is not
+// collected in.
+func (n *Node) NumPoints(epochs int64) int64 {
+	var total int64
+	for _, sm := range n.SimulatedMeasurements {
+		if s, ok := sm.(*SampledMeasurement); ok {
+			total += SampledPoints(epochs, s.Every)
+		} else {
+			total += epochs
+		}
+	}
+	return total
+}
+
+// TickAll advances all Distributions of a Node. Pods are ticked before
+// their containers, which see their restarts in the same epoch.
+func (n *Node) TickAll(d time.Duration) {
+	for _, sm := range n.SimulatedMeasurements {
+		sm.Tick(d)
+	}
+	n.currentMeasurement = 0
+}
+
+func (n *Node) HasMoreMeasurements() bool {
+	return n.currentMeasurement < len(n.SimulatedMeasurements)
+}
+
+func (n *Node) NextMeasurement() SimulatedMeasurement {
+	if !n.HasMoreMeasurements() {
+		return nil
+	}
+	sm := n.SimulatedMeasurements[n.currentMeasurement]
+	n.currentMeasurement++
+	return sm
+}
+
+// Characters of generated object name suffixes, as used by Kubernetes
+// (no vowels, no confusable digits).
+var nameSuffixAlphabet = []byte("bcdfghjklmnpqrstvwxz2456789")
+
+// PodName returns a name like the ones of the pods of a deployment:
+// <deployment>-<replica set hash>-<random suffix>. The replica set hash is
+// the same for all pods of the deployment.
+func PodName(r *rand.Rand, deployment []byte) []byte {
+	h := fnv.New32a()
+	h.Write(deployment)
+	hash := h.Sum32()
+
+	name := make([]byte, 0, len(deployment)+17)
+	name = append(name, deployment...)
+	name = append(name, '-')
+	for i := 0; i < 10; i++ {
+		name = append(name, nameSuffixAlphabet[hash%uint32(len(nameSuffixAlphabet))])
+		hash = hash*31 + 7
+	}
+	name = append(name, '-')
+	for i := 0; i < 5; i++ {
+		name = append(name, nameSuffixAlphabet[r.Intn(len(nameSuffixAlphabet))])
+	}
+	return name
+}
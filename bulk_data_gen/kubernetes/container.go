@@ -0,0 +1,147 @@
+package kubernetes
+
+import (
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math"
+	"math/rand"
+	"time"
+)
+
+var (
+	ContainerCPUByteString    = []byte("container_cpu")    // heap optimization
+	ContainerMemoryByteString = []byte("container_memory") // heap optimization
+
+	// Field keys for 'container_cpu' points.
+	ContainerCPUFieldKeys = [][]byte{
+		[]byte("usage_cores"),
+		[]byte("usage_seconds_total"),
+		[]byte("throttled_periods_total"),
+		[]byte("limit_cores"),
+	}
+
+	// Field keys for 'container_memory' points.
+	ContainerMemoryFieldKeys = [][]byte{
+		[]byte("usage_bytes"),
+		[]byte("working_set_bytes"),
+		[]byte("rss_bytes"),
+		[]byte("limit_bytes"),
+	}
+)
+
+// The CFS period CPU limits are enforced in.
+const cfsPeriod = 100 * time.Millisecond
+
+// ContainerCPUMeasurement simulates the cAdvisor CPU metrics of a container.
+// Its counters start over when the pod restarts.
+type ContainerCPUMeasurement struct {
+	// these don't change:
+	tags  [][]byte
+	limit float64
+	pod   *PodMeasurement
+
+	// these change:
+	timestamp        time.Time
+	usageDist        Distribution
+	usageSeconds     float64
+	throttledPeriods int64
+	restarts         int64
+}
+
+func NewContainerCPUMeasurement(r *rand.Rand, start time.Time, tags [][]byte, c *ContainerSpec, pod *PodMeasurement) *ContainerCPUMeasurement {
+	return &ContainerCPUMeasurement{
+		tags:  tags,
+		limit: c.CPULimit,
+		pod:   pod,
+
+		timestamp: start,
+		usageDist: CWD(ND(r, 0, c.CPULimit/20), 0, c.CPULimit, r.Float64()*c.CPULimit),
+		restarts:  pod.Restarts(),
+	}
+}
+
+func (m *ContainerCPUMeasurement) Tick(d time.Duration) {
+	m.timestamp = m.timestamp.Add(d)
+
+	if r := m.pod.Restarts(); r != m.restarts {
+		m.restarts = r
+		m.usageSeconds = 0
+		m.throttledPeriods = 0
+	}
+
+	m.usageDist.Advance()
+	usage := m.usageDist.Get()
+	m.usageSeconds += usage * d.Seconds()
+	// a container close to its limit gets throttled in some periods:
+	if usage > 0.9*m.limit {
+		m.throttledPeriods += int64(d/cfsPeriod) / 4
+	}
+}
+
+func (m *ContainerCPUMeasurement) ToPoint(p *Point) bool {
+	p.SetMeasurementName(ContainerCPUByteString)
+	p.SetTimestamp(&m.timestamp)
+
+	appendTags(p, m.tags)
+
+	p.AppendField(ContainerCPUFieldKeys[0], m.usageDist.Get())
+	p.AppendField(ContainerCPUFieldKeys[1], m.usageSeconds)
+	p.AppendField(ContainerCPUFieldKeys[2], m.throttledPeriods)
+	p.AppendField(ContainerCPUFieldKeys[3], m.limit)
+	return true
+}
+
+// ContainerMemoryMeasurement simulates the cAdvisor memory metrics of a
+// container. Its usage drops when the pod restarts.
+type ContainerMemoryMeasurement struct {
+	// these don't change:
+	tags            [][]byte
+	limit           int64
+	workingSetRatio float64
+	pod             *PodMeasurement
+
+	// these change:
+	timestamp time.Time
+	usageDist *ClampedRandomWalkDistribution
+	restarts  int64
+}
+
+func NewContainerMemoryMeasurement(r *rand.Rand, start time.Time, tags [][]byte, c *ContainerSpec, pod *PodMeasurement) *ContainerMemoryMeasurement {
+	limit := float64(c.MemoryLimit)
+	return &ContainerMemoryMeasurement{
+		tags:            tags,
+		limit:           c.MemoryLimit,
+		workingSetRatio: 0.7 + 0.25*r.Float64(),
+		pod:             pod,
+
+		timestamp: start,
+		// memory mostly grows, until the container is restarted:
+		usageDist: CWD(ND(r, limit/20000, limit/200), 0, limit, (0.1+0.5*r.Float64())*limit),
+		restarts:  pod.Restarts(),
+	}
+}
+
+func (m *ContainerMemoryMeasurement) Tick(d time.Duration) {
+	m.timestamp = m.timestamp.Add(d)
+
+	if r := m.pod.Restarts(); r != m.restarts {
+		m.restarts = r
+		m.usageDist.State = 0.1 * float64(m.limit)
+	}
+
+	m.usageDist.Advance()
+}
+
+func (m *ContainerMemoryMeasurement) ToPoint(p *Point) bool {
+	p.SetMeasurementName(ContainerMemoryByteString)
+	p.SetTimestamp(&m.timestamp)
+
+	appendTags(p, m.tags)
+
+	usage := m.usageDist.Get()
+	workingSet := usage * m.workingSetRatio
+	p.AppendField(ContainerMemoryFieldKeys[0], int64(math.Floor(usage)))
+	p.AppendField(ContainerMemoryFieldKeys[1], int64(math.Floor(workingSet)))
+	p.AppendField(ContainerMemoryFieldKeys[2], int64(math.Floor(0.8*workingSet)))
+	p.AppendField(ContainerMemoryFieldKeys[3], m.limit)
+	return true
+}
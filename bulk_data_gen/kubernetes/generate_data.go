@@ -0,0 +1,203 @@
+package kubernetes
+
+import (
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
+	"time"
+)
+
+// Type KubernetesSimulatorConfig is used to create a KubernetesSimulator.
+type KubernetesSimulatorConfig struct {
+	Start time.Time
+	End   time.Time
+
+	NodeCount  int64
+	NodeOffset int64
+
+	// SamplingInterval is the duration of an epoch (EpochDuration if zero).
+	SamplingInterval time.Duration
+	// MeasurementIntervals sets the collection interval of measurements by
+	// name, as multiples of SamplingInterval. The others are collected every
+	// epoch.
+	MeasurementIntervals map[string]time.Duration
+
+	// Rand seeds the random sources of the nodes.
+	Rand *rand.Rand
+}
+
+// Validate checks the sampling configuration.
+func (d *KubernetesSimulatorConfig) Validate() error {
+	_, err := d.measurementEvery()
+	return err
+}
+
+// EffectiveSamplingInterval returns the sampling interval, EpochDuration if
+// unset.
+func (d *KubernetesSimulatorConfig) EffectiveSamplingInterval() time.Duration {
+	if d.SamplingInterval == 0 {
+		return EpochDuration
+	}
+	return d.SamplingInterval
+}
+
+// measurementEvery returns the collection interval, in epochs, of the
+// measurements not collected every epoch.
+func (d *KubernetesSimulatorConfig) measurementEvery() (map[string]int64, error) {
+	return SamplingMultiples(d.EffectiveSamplingInterval(), d.MeasurementIntervals, MeasurementNames)
+}
+
+func (d *KubernetesSimulatorConfig) ToSimulator() *KubernetesSimulator {
+	nodes := d.newNodes()
+	return d.newSimulator(nodes, maxMeasurements(nodes))
+}
+
+// ToSimulatorSlices creates simulators for up to n contiguous slices of the
+// nodes, to be run in parallel. Merged round by round, in slice order, their
+// points are identical to the points of the simulator made by ToSimulator.
+func (d *KubernetesSimulatorConfig) ToSimulatorSlices(n int) []*KubernetesSimulator {
+	nodes := d.newNodes()
+	roundsPerEpoch := maxMeasurements(nodes)
+	bounds := SliceBounds(len(nodes), n)
+
+	sims := make([]*KubernetesSimulator, len(bounds))
+	for i, b := range bounds {
+		sims[i] = d.newSimulator(nodes[b[0]:b[1]], roundsPerEpoch)
+	}
+	return sims
+}
+
+func (d *KubernetesSimulatorConfig) newNodes() []*Node {
+	every, err := d.measurementEvery()
+	if err != nil {
+		panic(err.Error())
+	}
+	seed := d.Rand.Int63()
+	nodes := make([]*Node, d.NodeCount)
+	for i := 0; i < len(nodes); i++ {
+		nodes[i] = NewNode(NewRand(seed, int64(i)+d.NodeOffset), i, int(d.NodeOffset), d.Start, every)
+	}
+	return nodes
+}
+
+// maxMeasurements returns the highest measurement count of the nodes, which
+// is the number of rounds needed to emit one epoch.
+func maxMeasurements(nodes []*Node) int64 {
+	var max int64
+	for _, n := range nodes {
+		if l := int64(len(n.SimulatedMeasurements)); l > max {
+			max = l
+		}
+	}
+	return max
+}
+
+func (d *KubernetesSimulatorConfig) newSimulator(nodes []*Node, roundsPerEpoch int64) *KubernetesSimulator {
+	epochs := d.End.Sub(d.Start).Nanoseconds() / d.EffectiveSamplingInterval().Nanoseconds()
+	var maxPoints int64
+	for _, n := range nodes {
+		maxPoints += n.NumPoints(epochs)
+	}
+	return &KubernetesSimulator{
+		madePoints: 0,
+		madeValues: 0,
+		maxPoints:  maxPoints,
+
+		roundsPerEpoch: roundsPerEpoch,
+		rounds:         epochs * roundsPerEpoch,
+
+		nodeIndex: 0,
+		nodes:     nodes,
+
+		samplingInterval: d.EffectiveSamplingInterval(),
+	}
+}
+
+// A KubernetesSimulator generates data similar to the metrics of
+// kube-state-metrics and cAdvisor scraped from a cluster.
+// It fulfills the Simulator interface.
+type KubernetesSimulator struct {
+	madePoints int64
+	maxPoints  int64
+	madeValues int64
+
+	// Each round holds one measurement of every node having measurements
+	// left in the current epoch:
+	epoch          int64
+	epochRound     int64
+	roundsPerEpoch int64
+	rounds         int64
+
+	nodeIndex int
+	nodes     []*Node
+
+	samplingInterval time.Duration
+}
+
+func (g *KubernetesSimulator) SeenPoints() int64 {
+	return g.madePoints
+}
+
+func (g *KubernetesSimulator) SeenValues() int64 {
+	return g.madeValues
+}
+
+func (g *KubernetesSimulator) Total() int64 {
+	return g.maxPoints
+}
+
+func (g *KubernetesSimulator) Finished() bool {
+	return g.madePoints >= g.maxPoints
+}
+
+// Round returns the round of the last generated point.
+func (g *KubernetesSimulator) Round() int64 {
+	return g.epoch*g.roundsPerEpoch + g.epochRound
+}
+
+func (g *KubernetesSimulator) Rounds() int64 {
+	return g.rounds
+}
+
+// Next advances a Point to the next state in the generator.
+func (g *KubernetesSimulator) Next(p *Point) {
+	for {
+		// find the next node with measurements left in this epoch:
+		nodeFound := false
+		for nodesSeen := 0; nodesSeen < len(g.nodes); nodesSeen++ {
+			if g.nodeIndex == len(g.nodes) {
+				g.nodeIndex = 0
+				g.epochRound++
+			}
+			if g.nodes[g.nodeIndex].HasMoreMeasurements() {
+				nodeFound = true
+				break
+			}
+			g.nodeIndex++
+		}
+
+		if !nodeFound {
+			g.tickAll()
+			g.nodeIndex = 0
+			g.epoch++
+			g.epochRound = 0
+		}
+		sm := g.nodes[g.nodeIndex].NextMeasurement()
+		g.nodeIndex++
+
+		if s, ok := sm.(*SampledMeasurement); ok && !s.Due() {
+			// not collected in this epoch, and not counted in maxPoints either
+			continue
+		}
+		sm.ToPoint(p)
+		g.madePoints++
+		g.madeValues += int64(len(p.FieldValues))
+		break
+	}
+}
+
+// tickAll advances all nodes to the next epoch.
+func (g *KubernetesSimulator) tickAll() {
+	for _, n := range g.nodes {
+		n.TickAll(g.samplingInterval)
+	}
+}
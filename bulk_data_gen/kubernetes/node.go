@@ -0,0 +1,79 @@
+package kubernetes
+
+import (
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math"
+	"math/rand"
+	"time"
+)
+
+var (
+	NodeByteString = []byte("kube_node") // heap optimization
+
+	// Field keys for 'kube_node' points.
+	NodeFieldKeys = [][]byte{
+		[]byte("cpu_capacity_cores"),
+		[]byte("memory_capacity_bytes"),
+		[]byte("pods"),
+		[]byte("ready"),
+		[]byte("cpu_usage_cores"),
+		[]byte("memory_usage_bytes"),
+		[]byte("network_rx_bytes"),
+		[]byte("network_tx_bytes"),
+	}
+)
+
+type NodeMeasurement struct {
+	// these don't change:
+	tags                         [][]byte
+	cpuCores, memoryBytes        int64
+	pods                         int64
+	cpuDist, memoryDist          Distribution
+	networkRxDist, networkTxDist Distribution
+
+	timestamp time.Time
+}
+
+func NewNodeMeasurement(r *rand.Rand, start time.Time, n *Node, pods int) *NodeMeasurement {
+	cpuCores := n.InstanceType.CPUCores
+	memoryBytes := n.InstanceType.MemoryBytes
+	return &NodeMeasurement{
+		tags:        n.Tags,
+		cpuCores:    cpuCores,
+		memoryBytes: memoryBytes,
+		pods:        int64(pods),
+
+		cpuDist:       CWD(ND(r, 0, float64(cpuCores)/50), 0, float64(cpuCores), r.Float64()*float64(cpuCores)),
+		memoryDist:    CWD(ND(r, 0, float64(memoryBytes)/100), 0, float64(memoryBytes), (0.3+0.5*r.Float64())*float64(memoryBytes)),
+		networkRxDist: MWD(ND(r, 5e6, 1e6), 0),
+		networkTxDist: MWD(ND(r, 3e6, 1e6), 0),
+
+		timestamp: start,
+	}
+}
+
+func (m *NodeMeasurement) Tick(d time.Duration) {
+	m.timestamp = m.timestamp.Add(d)
+
+	m.cpuDist.Advance()
+	m.memoryDist.Advance()
+	m.networkRxDist.Advance()
+	m.networkTxDist.Advance()
+}
+
+func (m *NodeMeasurement) ToPoint(p *Point) bool {
+	p.SetMeasurementName(NodeByteString)
+	p.SetTimestamp(&m.timestamp)
+
+	appendTags(p, m.tags)
+
+	p.AppendField(NodeFieldKeys[0], m.cpuCores)
+	p.AppendField(NodeFieldKeys[1], m.memoryBytes)
+	p.AppendField(NodeFieldKeys[2], m.pods)
+	p.AppendField(NodeFieldKeys[3], 1)
+	p.AppendField(NodeFieldKeys[4], m.cpuDist.Get())
+	p.AppendField(NodeFieldKeys[5], int64(math.Floor(m.memoryDist.Get())))
+	p.AppendField(NodeFieldKeys[6], int64(m.networkRxDist.Get()))
+	p.AppendField(NodeFieldKeys[7], int64(m.networkTxDist.Get()))
+	return true
+}
@@ -0,0 +1,89 @@
+package kubernetes
+
+import (
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"math/rand"
+	"time"
+)
+
+var (
+	PodByteString = []byte("kube_pod") // heap optimization
+
+	// Field keys for 'kube_pod' points.
+	PodFieldKeys = [][]byte{
+		[]byte("ready"),
+		[]byte("restarts_total"),
+		[]byte("containers"),
+		[]byte("cpu_requests_cores"),
+		[]byte("memory_requests_bytes"),
+	}
+
+	// PodRestartRate is the mean number of restarts of a pod per second
+	// (about one restart every three days).
+	PodRestartRate = 1.0 / (72 * 3600)
+)
+
+// PodMeasurement simulates the kube-state-metrics status of a pod. A
+// restarted pod is not ready until the next epoch.
+type PodMeasurement struct {
+	// these don't change:
+	tags           [][]byte
+	containers     int64
+	cpuRequests    float64
+	memoryRequests int64
+
+	// these change:
+	timestamp time.Time
+	restarts  int64
+	ready     bool
+
+	rand *rand.Rand
+}
+
+func NewPodMeasurement(r *rand.Rand, start time.Time, tags [][]byte, w *Workload) *PodMeasurement {
+	m := &PodMeasurement{
+		tags:       tags,
+		containers: int64(len(w.Containers)),
+		timestamp:  start,
+		ready:      true,
+		rand:       r,
+	}
+	// requests are half of the limits:
+	for _, c := range w.Containers {
+		m.cpuRequests += c.CPULimit / 2
+		m.memoryRequests += c.MemoryLimit / 2
+	}
+	return m
+}
+
+func (m *PodMeasurement) Tick(d time.Duration) {
+	m.timestamp = m.timestamp.Add(d)
+
+	m.ready = m.rand.Float64() >= PodRestartRate*d.Seconds()
+	if !m.ready {
+		m.restarts++
+	}
+}
+
+// Restarts returns the number of restarts of the pod so far.
+func (m *PodMeasurement) Restarts() int64 {
+	return m.restarts
+}
+
+func (m *PodMeasurement) ToPoint(p *Point) bool {
+	p.SetMeasurementName(PodByteString)
+	p.SetTimestamp(&m.timestamp)
+
+	appendTags(p, m.tags)
+
+	ready := 0
+	if m.ready {
+		ready = 1
+	}
+	p.AppendField(PodFieldKeys[0], ready)
+	p.AppendField(PodFieldKeys[1], m.restarts)
+	p.AppendField(PodFieldKeys[2], m.containers)
+	p.AppendField(PodFieldKeys[3], m.cpuRequests)
+	p.AppendField(PodFieldKeys[4], m.memoryRequests)
+	return true
+}
@@ -0,0 +1,207 @@
+package replay
+
+import (
+	"fmt"
+	. "github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Type ReplaySimulatorConfig is used to create a ReplaySimulator.
+type ReplaySimulatorConfig struct {
+	// File is the InfluxDB line protocol file to replay, possibly gzip or
+	// zstd compressed.
+	File string
+	// Precision is the unit of the timestamps of the file.
+	Precision time.Duration
+
+	// Start, if set, shifts all timestamps so that the first point is at
+	// Start. End, if set, drops the (shifted) points at or after End.
+	Start time.Time
+	End   time.Time
+
+	// Copies of each series to make, by suffixing the value of CopyTag with
+	// _<copy index>, starting at CopyOffset. Points without CopyTag are not
+	// copied.
+	Copies     int64
+	CopyOffset int64
+	CopyTag    []byte
+}
+
+func (c *ReplaySimulatorConfig) Validate() error {
+	if c.File == "" {
+		return fmt.Errorf("a file to replay must be given")
+	}
+	if c.Precision <= 0 {
+		return fmt.Errorf("precision must be positive, got %v", c.Precision)
+	}
+	if c.Copies < 1 {
+		return fmt.Errorf("copies must be at least 1, got %d", c.Copies)
+	}
+	if c.Copies > 1 && len(c.CopyTag) == 0 {
+		return fmt.Errorf("copying series needs a tag to suffix")
+	}
+	return nil
+}
+
+// ToSimulator opens the file to replay.
+func (c *ReplaySimulatorConfig) ToSimulator() (*ReplaySimulator, error) {
+	f, err := os.Open(c.File)
+	if err != nil {
+		return nil, err
+	}
+	r, _, err := NewDecompressingReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	points, err := NewPointReader(r, "influx-bulk", c.Precision)
+	if err != nil {
+		r.Close()
+		f.Close()
+		return nil, err
+	}
+	return &ReplaySimulator{
+		config: *c,
+		file:   f,
+		reader: r,
+		points: points,
+	}, nil
+}
+
+// A ReplaySimulator replays the points of a line protocol file.
+// It fulfills the Simulator interface.
+type ReplaySimulator struct {
+	config ReplaySimulatorConfig
+	file   *os.File
+	reader io.ReadCloser
+	points PointReader
+	done   bool
+	err    error
+
+	// the last point read, its copies to make, and the next copy:
+	pending    *Point
+	copyTag    int // index of the copy tag in pending, or -1
+	copies     int64
+	nextCopy   int64
+	shift      time.Duration
+	shiftKnown bool
+
+	minTime, maxTime time.Time
+
+	madePoints int64
+	madeValues int64
+}
+
+func (s *ReplaySimulator) SeenPoints() int64 {
+	return s.madePoints
+}
+
+func (s *ReplaySimulator) SeenValues() int64 {
+	return s.madeValues
+}
+
+// Total returns the number of points made so far: the total is only known
+// once the whole file is read.
+func (s *ReplaySimulator) Total() int64 {
+	return s.madePoints
+}
+
+// Finished reads ahead the next point if needed, and reports whether there
+// is none left (or the file could not be read, see Err).
+func (s *ReplaySimulator) Finished() bool {
+	if s.pending == nil || s.nextCopy == s.copies {
+		s.read()
+	}
+	return s.pending == nil
+}
+
+// Err returns the error which ended the replay early, if any.
+func (s *ReplaySimulator) Err() error {
+	return s.err
+}
+
+// TimeRange returns the timestamps of the first and last points replayed.
+func (s *ReplaySimulator) TimeRange() (time.Time, time.Time) {
+	return s.minTime, s.maxTime
+}
+
+// Next advances a Point to the next state in the generator.
+func (s *ReplaySimulator) Next(p *Point) {
+	if s.Finished() {
+		panic("logic error: no point left to replay")
+	}
+	q := s.pending
+	p.SetMeasurementName(q.MeasurementName)
+	for i := range q.TagKeys {
+		value := q.TagValues[i]
+		if i == s.copyTag {
+			value = append(append([]byte{}, value...), '_')
+			value = strconv.AppendInt(value, s.config.CopyOffset+s.nextCopy, 10)
+		}
+		p.AppendTag(q.TagKeys[i], value)
+	}
+	for i := range q.FieldKeys {
+		p.AppendField(q.FieldKeys[i], q.FieldValues[i])
+	}
+	p.SetTimestamp(q.Timestamp)
+	s.nextCopy++
+
+	s.madePoints++
+	s.madeValues += int64(len(p.FieldValues))
+}
+
+// read parses the next point to replay, leaving pending nil at the end of
+// the file or on error. Every point gets buffers of its own, as points may be
+// held (e.g. to be delivered late) while the next ones are read.
+func (s *ReplaySimulator) read() {
+	s.pending = nil
+	for !s.done {
+		p := MakeUsablePoint()
+		if err := s.points.ReadPoint(p); err != nil {
+			if err != io.EOF {
+				s.err = fmt.Errorf("%s: %v", s.config.File, err)
+			}
+			s.close()
+			return
+		}
+		ts := p.Timestamp
+		if !s.config.Start.IsZero() {
+			if !s.shiftKnown {
+				s.shift = s.config.Start.Sub(*ts)
+				s.shiftKnown = true
+			}
+			*ts = ts.Add(s.shift)
+		}
+		if !s.config.End.IsZero() && !ts.Before(s.config.End) {
+			continue
+		}
+
+		if s.minTime.IsZero() || ts.Before(s.minTime) {
+			s.minTime = *ts
+		}
+		if ts.After(s.maxTime) {
+			s.maxTime = *ts
+		}
+		s.pending = p
+		s.copyTag = -1
+		s.copies = 1
+		for i, key := range p.TagKeys {
+			if len(s.config.CopyTag) > 0 && string(key) == string(s.config.CopyTag) {
+				s.copyTag = i
+				s.copies = s.config.Copies
+				break
+			}
+		}
+		s.nextCopy = 0
+		return
+	}
+}
+
+func (s *ReplaySimulator) close() {
+	s.done = true
+	s.reader.Close()
+	s.file.Close()
+}
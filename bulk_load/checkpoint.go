@@ -0,0 +1,157 @@
+package bulk_load
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Checkpoint is the state of a load recorded in its checkpoint file: the
+// items of each input written by the workers, and the statistics of the load
+// until then, which a resumed load carries on.
+type Checkpoint struct {
+	DBType string
+	// Inputs are the positions of the inputs, by name.
+	Inputs map[string]InputPosition
+	// Took is the time spent writing the items, by all the runs of the load.
+	Took    time.Duration
+	Latency LatencyHistogram
+}
+
+// InputPosition is a position in an input.
+type InputPosition struct {
+	// Items is the number of items from the start of the input which were
+	// all written.
+	Items int64
+	// Bytes is the size of these items in the input.
+	Bytes int64
+}
+
+// ReadCheckpoint reads a checkpoint file.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkpoint{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// Write writes a checkpoint file. The file is replaced at once, so that it
+// is never left incomplete.
+func (c *Checkpoint) Write(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// checkpointCut is a position of an input which all the batches of the input
+// up to seq cover, the decoder having been flushed.
+type checkpointCut struct {
+	seq uint64
+	InputPosition
+}
+
+// inputCheckpoints records the batches of an input written by the workers.
+// The batches are numbered from 1 in the order they are sent, and are written
+// in any order, so only the cuts below the first batch not written yet are
+// safe.
+type inputCheckpoints struct {
+	// written is the number of the last batch which was written with all the
+	// ones before it; done holds the batches written after it.
+	written uint64
+	done    map[uint64]bool
+	cuts    []checkpointCut
+}
+
+// checkpointer writes a checkpoint each time a cut of an input is safe.
+type checkpointer struct {
+	r    *LoadRunner
+	path string
+
+	lock       sync.Mutex
+	checkpoint Checkpoint
+	// tookBefore is the time spent by the previous runs.
+	tookBefore time.Duration
+	inputs     []inputCheckpoints
+	names      []string
+}
+
+// newCheckpointer returns a checkpointer of the inputs, from the checkpoint
+// of the previous runs.
+func newCheckpointer(r *LoadRunner, path, dbType string, names []string, base Checkpoint) *checkpointer {
+	c := &checkpointer{
+		r:    r,
+		path: path,
+		checkpoint: Checkpoint{
+			DBType: dbType,
+			Inputs: make(map[string]InputPosition),
+		},
+		tookBefore: base.Took,
+		inputs:     make([]inputCheckpoints, len(names)),
+		names:      names,
+	}
+	for i, name := range names {
+		c.inputs[i].done = make(map[uint64]bool)
+		c.checkpoint.Inputs[name] = base.Inputs[name]
+	}
+	return c
+}
+
+// cut adds a cut of an input, after its batch seq.
+func (c *checkpointer) cut(input int, seq uint64, pos InputPosition) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	in := &c.inputs[input]
+	in.cuts = append(in.cuts, checkpointCut{seq: seq, InputPosition: pos})
+	c.save(input)
+}
+
+// ack records that the batch seq of an input was written.
+func (c *checkpointer) ack(input int, seq uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	in := &c.inputs[input]
+	in.done[seq] = true
+	for in.done[in.written+1] {
+		delete(in.done, in.written+1)
+		in.written++
+	}
+	c.save(input)
+}
+
+// save writes the checkpoint if a cut of an input became safe.
+func (c *checkpointer) save(input int) {
+	in := &c.inputs[input]
+	i := 0
+	for i < len(in.cuts) && in.cuts[i].seq <= in.written {
+		i++
+	}
+	if i == 0 {
+		return
+	}
+	c.checkpoint.Inputs[c.names[input]] = in.cuts[i-1].InputPosition
+	in.cuts = in.cuts[i:]
+
+	c.checkpoint.Took = c.tookBefore + c.r.elapsed()
+	c.r.latencyLock.Lock()
+	c.checkpoint.Latency.Reset()
+	c.checkpoint.Latency.Merge(&c.r.latency)
+	c.r.latencyLock.Unlock()
+	if err := c.checkpoint.Write(c.path); err != nil {
+		log.Fatalf("Error writing checkpoint: %s", err.Error())
+	}
+}
@@ -0,0 +1,67 @@
+package bulk_load
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckpointerCuts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	r := &LoadRunner{}
+	r.latency.Record(20 * time.Millisecond)
+	base := Checkpoint{
+		Inputs: map[string]InputPosition{"b": {Items: 5, Bytes: 50}},
+		Took:   time.Minute,
+	}
+	c := newCheckpointer(r, path, "test", []string{"a", "b"}, base)
+
+	// expect checks the position of input a in the checkpoint file, none if
+	// it was not written yet.
+	expect := func(step string, want *InputPosition) {
+		t.Helper()
+		got, err := ReadCheckpoint(path)
+		if want == nil {
+			if !os.IsNotExist(err) {
+				t.Fatalf("%s: checkpoint written too early (%v)", step, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if got.Inputs["a"] != *want {
+			t.Fatalf("%s: input a at %+v, want %+v", step, got.Inputs["a"], *want)
+		}
+		if got.Inputs["b"] != base.Inputs["b"] {
+			t.Fatalf("%s: input b at %+v, want %+v", step, got.Inputs["b"], base.Inputs["b"])
+		}
+		if got.DBType != "test" || got.Took < base.Took || got.Latency.Count() != 1 {
+			t.Fatalf("%s: checkpoint %+v", step, got)
+		}
+	}
+
+	c.cut(0, 2, InputPosition{Items: 20, Bytes: 200})
+	expect("cut before its batches are written", nil)
+	c.ack(0, 2)
+	expect("cut after batch 2, batch 1 not written", nil)
+	c.ack(1, 1)
+	expect("batch of another input written", nil)
+	c.cut(0, 4, InputPosition{Items: 40, Bytes: 400})
+	c.ack(0, 1)
+	expect("batches 1 and 2 written", &InputPosition{Items: 20, Bytes: 200})
+	c.ack(0, 4)
+	expect("batch 4 written before batch 3", &InputPosition{Items: 20, Bytes: 200})
+	c.ack(0, 3)
+	expect("batches 1 to 4 written", &InputPosition{Items: 40, Bytes: 400})
+	c.cut(0, 4, InputPosition{Items: 45, Bytes: 450})
+	expect("cut after written batches", &InputPosition{Items: 45, Bytes: 450})
+}
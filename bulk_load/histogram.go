@@ -0,0 +1,157 @@
+package bulk_load
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// histogramSubBuckets is the number of buckets of each power of two: it
+// bounds the relative error of the recorded values to 1/histogramSubBuckets.
+const (
+	histogramSubBucketBits = 7
+	histogramSubBuckets    = 1 << histogramSubBucketBits
+)
+
+// LatencyHistogram counts durations in the manner of HdrHistogram: values are
+// recorded with a microsecond resolution in log-linear buckets, so that
+// percentiles are exact to less than 1%, whatever the range of the values.
+type LatencyHistogram struct {
+	counts []int64
+	count  int64
+	max    int64
+}
+
+// histogramIndex returns the bucket of a value: values below
+// 2*histogramSubBuckets have a bucket each, then each power of two is split
+// into histogramSubBuckets buckets.
+func histogramIndex(v int64) int {
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - histogramSubBucketBits - 1
+	return shift*histogramSubBuckets + int(v>>uint(shift))
+}
+
+// histogramHighestValue returns the highest value counted in a bucket.
+func histogramHighestValue(i int) int64 {
+	if i < 2*histogramSubBuckets {
+		return int64(i)
+	}
+	shift := i/histogramSubBuckets - 1
+	top := int64(i - shift*histogramSubBuckets)
+	return (top+1)<<uint(shift) - 1
+}
+
+// Record counts a duration.
+func (h *LatencyHistogram) Record(d time.Duration) {
+	v := int64(d / time.Microsecond)
+	if v < 0 {
+		v = 0
+	}
+	i := histogramIndex(v)
+	if i >= len(h.counts) {
+		counts := make([]int64, i+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+	h.count++
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// Merge adds the counts of another histogram.
+func (h *LatencyHistogram) Merge(o *LatencyHistogram) {
+	if len(o.counts) > len(h.counts) {
+		counts := make([]int64, len(o.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.count += o.count
+	if o.max > h.max {
+		h.max = o.max
+	}
+}
+
+// Reset forgets all the recorded durations.
+func (h *LatencyHistogram) Reset() {
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+	h.count = 0
+	h.max = 0
+}
+
+// Count returns the number of recorded durations.
+func (h *LatencyHistogram) Count() int64 {
+	return h.count
+}
+
+// Max returns the highest recorded duration.
+func (h *LatencyHistogram) Max() time.Duration {
+	return time.Duration(h.max) * time.Microsecond
+}
+
+// Percentile returns the duration which p percent of the recorded durations
+// do not exceed, 0 if there are none.
+func (h *LatencyHistogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(p / 100 * float64(h.count)))
+	if target < 1 {
+		target = 1
+	}
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			v := histogramHighestValue(i)
+			if v > h.max {
+				v = h.max
+			}
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+	return h.Max()
+}
+
+// histogramJSON is the encoding of a LatencyHistogram in checkpoint files.
+type histogramJSON struct {
+	Counts []int64
+	Max    int64
+}
+
+func (h *LatencyHistogram) MarshalJSON() ([]byte, error) {
+	return json.Marshal(histogramJSON{Counts: h.counts, Max: h.max})
+}
+
+func (h *LatencyHistogram) UnmarshalJSON(data []byte) error {
+	var j histogramJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	h.counts = j.Counts
+	h.max = j.Max
+	h.count = 0
+	for _, c := range h.counts {
+		h.count += c
+	}
+	return nil
+}
+
+// String summarizes the percentiles reported by the loaders.
+func (h *LatencyHistogram) String() string {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return fmt.Sprintf("p50 %.2fms, p90 %.2fms, p99 %.2fms, p99.9 %.2fms, max %.2fms (%d batches)",
+		ms(h.Percentile(50)), ms(h.Percentile(90)), ms(h.Percentile(99)), ms(h.Percentile(99.9)), ms(h.Max()), h.count)
+}
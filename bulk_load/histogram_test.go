@@ -0,0 +1,87 @@
+package bulk_load
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+	check := func(v int64) {
+		i := histogramIndex(v)
+		if high := histogramHighestValue(i); high < v || high-v > v/histogramSubBuckets {
+			t.Fatalf("value %d in bucket %d, the highest value of which is %d", v, i, high)
+		}
+		// buckets are contiguous:
+		if i > 0 && histogramHighestValue(i-1) >= v {
+			t.Fatalf("value %d in bucket %d, but the previous one goes up to %d", v, i, histogramHighestValue(i-1))
+		}
+	}
+	for v := int64(0); v < 1<<16; v++ {
+		check(v)
+	}
+	for shift := uint(16); shift < 40; shift++ {
+		for _, v := range []int64{1<<shift - 1, 1 << shift, 1<<shift + 1, 3 << (shift - 1)} {
+			check(v)
+		}
+	}
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	var h LatencyHistogram
+	if h.Percentile(50) != 0 {
+		t.Errorf("percentile of an empty histogram: %v", h.Percentile(50))
+	}
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	for _, tc := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, time.Millisecond},
+		{50, 500 * time.Millisecond},
+		{90, 900 * time.Millisecond},
+		{99.9, 999 * time.Millisecond},
+		{100, 1000 * time.Millisecond},
+	} {
+		got := h.Percentile(tc.p)
+		if got < tc.want || got > tc.want+tc.want/histogramSubBuckets {
+			t.Errorf("p%v: %v, want %v to 1%%", tc.p, got, tc.want)
+		}
+	}
+	// the highest value of the last bucket is capped by the maximum:
+	if got := h.Percentile(100); got != h.Max() {
+		t.Errorf("p100: %v, max %v", got, h.Max())
+	}
+	if h.Count() != 1000 {
+		t.Errorf("count %d, want 1000", h.Count())
+	}
+}
+
+func TestHistogramMergeAndJSON(t *testing.T) {
+	var a, b LatencyHistogram
+	for i := 1; i <= 100; i++ {
+		a.Record(time.Duration(i) * time.Millisecond)
+		b.Record(time.Duration(i) * time.Second)
+	}
+	a.Merge(&b)
+	if a.Count() != 200 || a.Max() != 100*time.Second {
+		t.Errorf("merged histogram of %d durations up to %v", a.Count(), a.Max())
+	}
+	if got := a.Percentile(50); got < 100*time.Millisecond || got > 101*time.Millisecond {
+		t.Errorf("p50 of the merged histogram: %v", got)
+	}
+
+	data, err := json.Marshal(&a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c LatencyHistogram
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.String() != a.String() {
+		t.Errorf("decoded as %s, want %s", &c, &a)
+	}
+}
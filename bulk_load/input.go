@@ -0,0 +1,114 @@
+package bulk_load
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+// stdinInput names the standard input in the input lists.
+const stdinInput = "-"
+
+// expandInputs returns the files of a comma-separated list of inputs: files,
+// directories and glob patterns. The files of a directory are the shards
+// listed by its index, when it holds a dataset written by bulk_data_gen, or
+// else all its files in name order.
+func expandInputs(list string) ([]string, error) {
+	var inputs []string
+	for _, pattern := range strings.Split(list, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if pattern == stdinInput {
+			inputs = append(inputs, pattern)
+			continue
+		}
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern '%s': %v", pattern, err)
+		}
+		if paths == nil {
+			return nil, fmt.Errorf("no input matches '%s'", pattern)
+		}
+		for _, path := range paths {
+			files, err := expandInput(path)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, files...)
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no input in '%s'", list)
+	}
+	return inputs, nil
+}
+
+// expandInput returns a file, or the files of a directory.
+func expandInput(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	index, err := common.ReadShardIndex(path)
+	if err == nil {
+		for _, shard := range index.Shards {
+			files = append(files, filepath.Join(path, shard.File))
+		}
+		return files, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		// the metadata bulk_data_gen writes along with a dataset:
+		if info.Name() == common.ManifestFile || info.Name() == common.ShardIndexFile {
+			continue
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, filepath.Join(path, info.Name()))
+		}
+	}
+	return files, nil
+}
+
+// inputFile is an open input, decompressed when it is gzip or zstd compressed.
+type inputFile struct {
+	io.ReadCloser
+	file *os.File
+}
+
+// openInput opens an input of a list returned by expandInputs.
+func openInput(name string) (io.ReadCloser, error) {
+	file := os.Stdin
+	if name != stdinInput {
+		var err error
+		if file, err = os.Open(name); err != nil {
+			return nil, err
+		}
+	}
+	r, _, err := common.NewDecompressingReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading %s: %v", name, err)
+	}
+	return &inputFile{ReadCloser: r, file: file}, nil
+}
+
+func (in *inputFile) Close() error {
+	in.ReadCloser.Close()
+	return in.file.Close()
+}
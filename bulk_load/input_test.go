@@ -0,0 +1,53 @@
+package bulk_load
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+func TestExpandInputDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"data-00000", "data-00001", "data-00002", common.ManifestFile} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	join := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+		return paths
+	}
+
+	// without index, every file but the metadata:
+	files, err := expandInput(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := join("data-00000", "data-00001", "data-00002"); !reflect.DeepEqual(files, want) {
+		t.Errorf("files %v, want %v", files, want)
+	}
+
+	// with an index, its shards:
+	index := &common.ShardIndex{Shards: []common.Shard{{File: "data-00001"}, {File: "data-00000"}}}
+	if err := common.WriteShardIndex(dir, index); err != nil {
+		t.Fatal(err)
+	}
+	files, err = expandInput(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := join("data-00001", "data-00000"); !reflect.DeepEqual(files, want) {
+		t.Errorf("files %v, want %v", files, want)
+	}
+}
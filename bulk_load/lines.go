@@ -0,0 +1,169 @@
+package bulk_load
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+// DatasetSize is the size of a dataset, given by the marker line bulk_data_gen
+// writes at its end (see common.DatasetSizeMarker).
+type DatasetSize struct {
+	Points int64
+	Values int64
+	// Known tells whether a marker was read.
+	Known bool
+}
+
+// ParseMarker records the size given by a line, if it is a dataset size
+// marker, and tells whether it is one.
+func (s *DatasetSize) ParseMarker(line []byte) (bool, error) {
+	if !bytes.HasPrefix(line, []byte(common.DatasetSizeMarker)) {
+		return false, nil
+	}
+	parts := common.DatasetSizeMarkerRE.FindSubmatch(line)
+	if parts == nil {
+		return true, fmt.Errorf("invalid dataset size marker '%s'", line)
+	}
+	var err error
+	if s.Points, err = strconv.ParseInt(string(parts[1]), 10, 64); err != nil {
+		return true, err
+	}
+	if s.Values, err = strconv.ParseInt(string(parts[2]), 10, 64); err != nil {
+		return true, err
+	}
+	s.Known = true
+	return true, nil
+}
+
+// ReadLine appends the next line of r to buf, without its line break. It
+// returns io.EOF when there are no lines left.
+func ReadLine(r *bufio.Reader, buf []byte) ([]byte, int, error) {
+	n := 0
+	for {
+		chunk, err := r.ReadSlice('\n')
+		n += len(chunk)
+		buf = append(buf, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && n > 0 {
+			err = nil
+		}
+		if len(buf) > 0 && buf[len(buf)-1] == '\n' {
+			buf = buf[:len(buf)-1]
+		}
+		return buf, n, err
+	}
+}
+
+// linesBufPool holds the buffers of LinesBatch instances to reduce heap churn.
+var linesBufPool = sync.Pool{
+	New: func() interface{} {
+		return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
+	},
+}
+
+// LinesBatch is a batch of the lines of a text format, each one ending with a
+// line break.
+type LinesBatch struct {
+	Buf   *bytes.Buffer
+	items int
+}
+
+func (b *LinesBatch) Len() int {
+	return b.items
+}
+
+// Release returns the buffer of the batch to the pool.
+func (b *LinesBatch) Release() {
+	b.Buf.Reset()
+	linesBufPool.Put(b.Buf)
+	b.Buf = nil
+}
+
+// Lines calls f with each line of the batch, without its line break.
+func (b *LinesBatch) Lines(f func(line []byte) error) error {
+	data := b.Buf.Bytes()
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if err := f(data[:i]); err != nil {
+			return err
+		}
+		data = data[i+1:]
+	}
+	return nil
+}
+
+// LineDecoder decodes the text formats having a fixed number of lines per
+// item into LinesBatch instances. Dataset size markers are recorded, and not
+// copied to the batches.
+type LineDecoder struct {
+	batchSize    int
+	linesPerItem int
+
+	batch *LinesBatch
+	line  []byte
+}
+
+// NewLineDecoder returns a LineDecoder making batches of batchSize items.
+func NewLineDecoder(batchSize, linesPerItem int) *LineDecoder {
+	return &LineDecoder{
+		batchSize:    batchSize,
+		linesPerItem: linesPerItem,
+	}
+}
+
+func (d *LineDecoder) Decode(r *bufio.Reader, size *DatasetSize) (int, Batch, error) {
+	if d.batch == nil {
+		d.batch = &LinesBatch{Buf: linesBufPool.Get().(*bytes.Buffer)}
+	}
+	var bytesRead int
+	for i := 0; i < d.linesPerItem; {
+		var n int
+		var err error
+		d.line, n, err = ReadLine(r, d.line[:0])
+		bytesRead += n
+		if err == io.EOF && i > 0 {
+			return bytesRead, nil, fmt.Errorf("incomplete item: %d of %d lines", i, d.linesPerItem)
+		} else if err != nil {
+			return bytesRead, nil, err
+		}
+		if isMarker, err := size.ParseMarker(d.line); isMarker {
+			if err != nil {
+				return bytesRead, nil, err
+			}
+			continue
+		}
+		d.batch.Buf.Write(d.line)
+		d.batch.Buf.WriteByte('\n')
+		i++
+	}
+
+	d.batch.items++
+	if d.batch.items < d.batchSize {
+		return bytesRead, nil, nil
+	}
+	b := d.batch
+	d.batch = nil
+	return bytesRead, b, nil
+}
+
+func (d *LineDecoder) Flush() Batch {
+	if d.batch == nil {
+		return nil
+	}
+	if d.batch.items == 0 {
+		d.batch.Release()
+		d.batch = nil
+		return nil
+	}
+	b := d.batch
+	d.batch = nil
+	return b
+}
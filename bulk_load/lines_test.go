@@ -0,0 +1,137 @@
+package bulk_load
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// decodeAll decodes an input, returning the lines of each batch.
+func decodeAll(t *testing.T, d *LineDecoder, input string) ([][]string, DatasetSize) {
+	t.Helper()
+	r := bufio.NewReaderSize(strings.NewReader(input), 16)
+	var size DatasetSize
+	var batches [][]string
+	add := func(b Batch) {
+		lb := b.(*LinesBatch)
+		var lines []string
+		lb.Lines(func(line []byte) error {
+			lines = append(lines, string(line))
+			return nil
+		})
+		lb.Release()
+		batches = append(batches, lines)
+	}
+	bytesRead := 0
+	for {
+		n, b, err := d.Decode(r, &size)
+		bytesRead += n
+		if err != nil {
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			break
+		}
+		if b != nil {
+			add(b)
+		}
+	}
+	for b := d.Flush(); b != nil; b = d.Flush() {
+		add(b)
+	}
+	if bytesRead != len(input) {
+		t.Errorf("read %d bytes of %d", bytesRead, len(input))
+	}
+	return batches, size
+}
+
+func TestLineDecoder(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		linesPerItem int
+		input        string
+		want         [][]string
+		size         DatasetSize
+	}{
+		{
+			name:         "lines",
+			linesPerItem: 1,
+			input:        "a\nb\nc\n",
+			want:         [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name:         "size marker",
+			linesPerItem: 1,
+			input:        "a\nb\nc\nd\ndataset-size:4,12\n",
+			want:         [][]string{{"a", "b"}, {"c", "d"}},
+			size:         DatasetSize{Points: 4, Values: 12, Known: true},
+		},
+		{
+			name:         "multi-line items",
+			linesPerItem: 2,
+			input:        "h1\nv1\nh2\nv2\nh3\nv3\ndataset-size:3,3\n",
+			want:         [][]string{{"h1", "v1", "h2", "v2"}, {"h3", "v3"}},
+			size:         DatasetSize{Points: 3, Values: 3, Known: true},
+		},
+		{
+			name:         "marker between the lines of an item",
+			linesPerItem: 2,
+			input:        "h1\ndataset-size:1,1\nv1\n",
+			want:         [][]string{{"h1", "v1"}},
+			size:         DatasetSize{Points: 1, Values: 1, Known: true},
+		},
+		{
+			name:         "last line without a line break",
+			linesPerItem: 1,
+			input:        "a\nb\nc",
+			want:         [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name:         "lines longer than the read buffer",
+			linesPerItem: 1,
+			input:        "cpu,hostname=host_0 usage_user=1 0\n" + strings.Repeat("x", 100),
+			want:         [][]string{{"cpu,hostname=host_0 usage_user=1 0", strings.Repeat("x", 100)}},
+		},
+		{
+			name:         "empty",
+			linesPerItem: 1,
+			input:        "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			batches, size := decodeAll(t, NewLineDecoder(2, tc.linesPerItem), tc.input)
+			if !reflect.DeepEqual(batches, tc.want) {
+				t.Errorf("batches %q, want %q", batches, tc.want)
+			}
+			if size != tc.size {
+				t.Errorf("size %+v, want %+v", size, tc.size)
+			}
+		})
+	}
+}
+
+func TestLineDecoderIncompleteItem(t *testing.T) {
+	d := NewLineDecoder(10, 3)
+	r := bufio.NewReader(strings.NewReader("h1\nv1\nw1\nh2\nv2\n"))
+	var size DatasetSize
+	var err error
+	for err == nil {
+		_, _, err = d.Decode(r, &size)
+	}
+	if want := "incomplete item: 2 of 3 lines"; err.Error() != want {
+		t.Errorf("error %q, want %q", err, want)
+	}
+}
+
+func TestDatasetSizeInvalidMarker(t *testing.T) {
+	var size DatasetSize
+	isMarker, err := size.ParseMarker([]byte("dataset-size:x,1"))
+	if !isMarker || err == nil {
+		t.Errorf("invalid marker parsed as %v, %v", isMarker, err)
+	}
+	if size.Known {
+		t.Error("invalid marker recorded")
+	}
+}
@@ -0,0 +1,88 @@
+package bulk_load
+
+import (
+	"bufio"
+	"errors"
+)
+
+// ErrBackoff is returned by a Processor when the database asks to slow down:
+// the batch is written again after the -backoff duration.
+var ErrBackoff = errors.New("backpressure is needed")
+
+// Batch is a batch of items decoded from the input, to be written by a
+// Processor.
+type Batch interface {
+	// Len returns the number of items of the batch.
+	Len() int
+}
+
+// WorkerBatch is a Batch which must be written by a given worker, e.g. to
+// keep all the items of a series on the same connection.
+type WorkerBatch interface {
+	Batch
+	Worker() int
+}
+
+// Releaser is implemented by batches holding pooled resources, released once
+// the batch has been written.
+type Releaser interface {
+	Release()
+}
+
+// BatchDecoder is the format-specific part of a loader: it decodes the items
+// of an input and groups them into batches.
+type BatchDecoder interface {
+	// Decode reads the next item of r. It returns the number of bytes read,
+	// and a batch once one is complete, nil otherwise. It returns io.EOF
+	// after the last item. Decoders of formats ending with a dataset size
+	// marker record it in size.
+	Decode(r *bufio.Reader, size *DatasetSize) (int, Batch, error)
+
+	// Flush returns a batch of the items decoded since the last complete
+	// batch, or nil when there are none left. It is called at the end of the
+	// input, and at each checkpoint of the load, until it returns nil.
+	Flush() Batch
+}
+
+// Processor is the database-specific part of a loader: it writes batches to
+// the database. Each worker has its own.
+type Processor interface {
+	// ProcessBatch writes a batch. It returns ErrBackoff when the batch must
+	// be written again later.
+	ProcessBatch(b Batch) error
+
+	// Close releases the resources of the processor, once the worker is done.
+	Close()
+}
+
+// Loader describes a database loader to a LoadRunner.
+type Loader struct {
+	// DBType names the database in the results report.
+	DBType string
+
+	// DestinationUrl is the URL of the database, as reported.
+	DestinationUrl string
+
+	// IsGzip tells whether the requests are gzip compressed, as reported.
+	IsGzip bool
+
+	// ReportTags are the database-specific tags of the results report.
+	ReportTags [][2]string
+
+	// ValuesPerItem estimates the number of values of an item, to limit the
+	// ingest rate and to compute the value rate when the input does not give
+	// the size of the dataset.
+	ValuesPerItem float64
+
+	// ItemsAreValues tells that the items of the input are values rather
+	// than points, as with the Cassandra format.
+	ItemsAreValues bool
+
+	// NewDecoder returns a decoder of the input, making batches of batchSize
+	// items.
+	NewDecoder func(batchSize int) BatchDecoder
+
+	// NewProcessor returns the processor of a worker. It is only called when
+	// loading.
+	NewProcessor func(worker int) (Processor, error)
+}
@@ -0,0 +1,18 @@
+package bulk_load
+
+import (
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+// CheckManifest reads a manifest written by bulk_data_gen, and checks that
+// the dataset it describes is in one of the formats the loader reads.
+func CheckManifest(path string, formats ...string) (*common.Manifest, error) {
+	m, err := common.ReadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.CheckFormat(formats...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,56 @@
+package bulk_load
+
+import (
+	"time"
+)
+
+// TODO AP: Maybe useless
+const RateControlGranularity = 1000 // 1000 ms = 1s
+const RateControlMinBatchSize = 100
+
+// rateLimiter limits the ingest rate of a worker: once the worker has written
+// its share of values of a RateControlGranularity period, it sleeps until the
+// end of the period.
+type rateLimiter struct {
+	// values per period:
+	gran float32
+
+	count float32
+	start time.Time
+	// ms overslept (negative) or underslept in the previous periods:
+	debt int64
+}
+
+func newRateLimiter(gran float32) *rateLimiter {
+	return &rateLimiter{gran: gran, start: time.Now()}
+}
+
+// wait accounts for values written, sleeping when needed.
+func (l *rateLimiter) wait(values float32) {
+	l.count += values
+	if l.count < l.gran {
+		return
+	}
+	now := time.Now()
+	remaining := now.Sub(l.start)
+	remainingMs := RateControlGranularity - (remaining.Nanoseconds() / 1e6) + l.debt
+	l.debt = 0
+	if remainingMs > 0 {
+		time.Sleep(time.Duration(remainingMs) * time.Millisecond) // TODO discount 5 ms for syscalls (sleep & wakeup) overhead?
+		l.start = time.Now()
+		realDelay := l.start.Sub(now).Nanoseconds() / 1e6
+		if realDelay != remainingMs {
+			l.debt = -(realDelay - remainingMs) // TODO how about spurios wakeups?
+		}
+	} else {
+		l.start = now
+		l.debt = remainingMs
+	}
+	if l.debt != 0 {
+		l.debt = int64(float64(l.debt) * float64(1.05))
+		if l.debt < -RateControlGranularity { // trim to monitored period
+			l.debt = -RateControlGranularity
+		}
+	}
+	l.count -= l.gran
+}
@@ -0,0 +1,698 @@
+package bulk_load
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/rpc"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"github.com/influxdata/influxdb-comparisons/util/report"
+)
+
+// LoadRunner runs a bulk load with the options common to all loaders: it
+// reads the inputs with the BatchDecoder of a Loader, and has the batches
+// written by a pool of workers, each one with its Processor.
+type LoadRunner struct {
+	// first for the alignment of atomic operations:
+	progressIntervalItems uint64
+	// items read or being read by the readers, to stop at the item limit:
+	itemsReserved int64
+	// start of the load, in ns since the epoch: the time the first batch was
+	// sent to the workers, so that skipping the input of a resumed load is
+	// not counted.
+	loadStart int64
+
+	Workers                int
+	BatchSize              int
+	ItemLimit              int64
+	IngestRateLimit        int
+	Backoff                time.Duration
+	TimeLimit              time.Duration
+	ProgressInterval       time.Duration
+	DoLoad                 bool
+	NotificationListenPort int
+	ManifestFile           string
+	ReportDatabase         string
+	ReportHost             string
+	ReportUser             string
+	ReportPassword         string
+	ReportTagsCSV          string
+	CheckpointFile         string
+	CheckpointInterval     time.Duration
+	Resume                 bool
+	Files                  string
+	Readers                int
+
+	// inputs are the files to load, stdinInput for the standard input.
+	inputs []string
+	// manifest is the manifest of the dataset, if any.
+	manifest *common.Manifest
+
+	reportTags     [][2]string
+	reportHostname string
+
+	// resumed is the checkpoint the load resumes from, if any.
+	resumed     *Checkpoint
+	checkpoints *checkpointer
+
+	// stop is closed to end the load before the end of the input.
+	stop               chan struct{}
+	stopOnce           sync.Once
+	prematureEndReason string
+
+	// write latencies of the batches, of the whole load and of the current
+	// progress interval:
+	latencyLock     sync.Mutex
+	latency         LatencyHistogram
+	intervalLatency LatencyHistogram
+}
+
+// RegisterFlags registers the flags of the common options. The loader
+// registers its own before parsing them, and validates them with Validate.
+func (r *LoadRunner) RegisterFlags(defaultBatchSize int) {
+	flag.IntVar(&r.BatchSize, "batch-size", defaultBatchSize, "Batch size (input items).")
+	flag.IntVar(&r.Workers, "workers", 1, "Number of parallel requests to make.")
+	flag.Int64Var(&r.ItemLimit, "item-limit", -1, "Number of items to read from the input before quitting (-1 is the default: no limit).")
+	flag.IntVar(&r.IngestRateLimit, "ingest-rate-limit", -1, "Ingest rate limit in values/s (-1 = no limit).")
+	flag.DurationVar(&r.Backoff, "backoff", time.Second, "Time to sleep between requests when server indicates backpressure is needed.")
+	flag.DurationVar(&r.TimeLimit, "time-limit", -1, "Maximum duration to run (-1 is the default: no limit).")
+	flag.DurationVar(&r.ProgressInterval, "progress-interval", -1, "Duration between printing progress messages.")
+	flag.BoolVar(&r.DoLoad, "do-load", true, "Whether to write data. Set this flag to false to check input read speed.")
+	flag.IntVar(&r.NotificationListenPort, "notification-port", -1, "Listen port for remote notification messages. Used to remotely finish benchmark. -1 to disable feature")
+	flag.StringVar(&r.ManifestFile, "manifest", "", "Manifest of the dataset written by bulk_data_gen, to check the input format against (optional).")
+	flag.StringVar(&r.ReportDatabase, "report-database", "database_benchmarks", "Database name where to store result metrics")
+	flag.StringVar(&r.ReportHost, "report-host", "", "Host to send result metrics")
+	flag.StringVar(&r.ReportUser, "report-user", "", "User for host to send result metrics")
+	flag.StringVar(&r.ReportPassword, "report-password", "", "User password for Host to send result metrics")
+	flag.StringVar(&r.ReportTagsCSV, "report-tags", "", "Comma separated k:v tags to send  alongside result metrics")
+	flag.StringVar(&r.Files, "file", "", "Comma-separated files, directories or glob patterns of the input, gzip or zstd compressed or not (the default is stdin).")
+	flag.IntVar(&r.Readers, "readers", 1, "Number of input files to read in parallel.")
+	flag.StringVar(&r.CheckpointFile, "checkpoint-file", "", "File where to record periodically how many input items were written, to resume the load with -resume (optional).")
+	flag.DurationVar(&r.CheckpointInterval, "checkpoint-interval", 10*time.Second, "Duration between checkpoints.")
+	flag.BoolVar(&r.Resume, "resume", false, "Whether to resume the load recorded in the checkpoint file: the input items it wrote are skipped, and the database is not created. The load starts over if the file does not exist.")
+}
+
+// Validate checks the common options once parsed, formats being those of the
+// inputs the loader reads.
+func (r *LoadRunner) Validate(formats ...string) error {
+	if r.Workers < 1 {
+		return fmt.Errorf("invalid number of workers: %d", r.Workers)
+	}
+	if r.BatchSize < 1 {
+		return fmt.Errorf("invalid batch size: %d", r.BatchSize)
+	}
+	if r.Readers < 1 {
+		return fmt.Errorf("invalid number of readers: %d", r.Readers)
+	}
+	r.inputs = []string{stdinInput}
+	if r.Files != "" {
+		var err error
+		if r.inputs, err = expandInputs(r.Files); err != nil {
+			return err
+		}
+	}
+	if r.ManifestFile != "" {
+		var err error
+		if r.manifest, err = CheckManifest(r.ManifestFile, formats...); err != nil {
+			return err
+		}
+	}
+	if r.CheckpointFile != "" {
+		if !r.DoLoad {
+			return fmt.Errorf("checkpoints need -do-load")
+		}
+		if r.CheckpointInterval <= 0 {
+			return fmt.Errorf("invalid checkpoint interval: %v", r.CheckpointInterval)
+		}
+		// the checkpoints record the inputs by name:
+		listed := make(map[string]bool)
+		for _, name := range r.inputs {
+			if listed[name] {
+				return fmt.Errorf("input %s is listed twice", name)
+			}
+			listed[name] = true
+		}
+	}
+	if r.Resume {
+		if r.CheckpointFile == "" {
+			return fmt.Errorf("-resume needs a -checkpoint-file")
+		}
+		c, err := ReadCheckpoint(r.CheckpointFile)
+		if os.IsNotExist(err) {
+			fmt.Printf("no checkpoint in %s, loading from the start of the input\n", r.CheckpointFile)
+		} else if err != nil {
+			return err
+		} else {
+			r.resumed = c
+		}
+	}
+
+	if r.ReportHost != "" {
+		fmt.Printf("results report destination: %v\n", r.ReportHost)
+		fmt.Printf("results report database: %v\n", r.ReportDatabase)
+
+		var err error
+		r.reportHostname, err = os.Hostname()
+		if err != nil {
+			return fmt.Errorf("os.Hostname() error: %s", err.Error())
+		}
+		fmt.Printf("hostname for results report: %v\n", r.reportHostname)
+
+		if r.ReportTagsCSV != "" {
+			pairs := strings.Split(r.ReportTagsCSV, ",")
+			for _, pair := range pairs {
+				fields := strings.SplitN(pair, ":", 2)
+				if len(fields) != 2 {
+					return fmt.Errorf("invalid report tag '%s'", pair)
+				}
+				tagpair := [2]string{fields[0], fields[1]}
+				r.reportTags = append(r.reportTags, tagpair)
+			}
+		}
+		fmt.Printf("results report tags: %v\n", r.reportTags)
+	}
+	return nil
+}
+
+// Resuming tells whether the load resumes from a checkpoint, in which case
+// the loader must not create the database.
+func (r *LoadRunner) Resuming() bool {
+	return r.resumed != nil
+}
+
+// Run loads the input, then prints and reports the results.
+func (r *LoadRunner) Run(l *Loader) {
+	valuesPerItem := l.ValuesPerItem
+	if l.ItemsAreValues {
+		valuesPerItem = 1
+	}
+
+	var ingestionRateGran float32
+	if r.IngestRateLimit > 0 {
+		ingestionRateGran = (float32(r.IngestRateLimit) / float32(r.Workers)) / (float32(1000) / float32(RateControlGranularity))
+		log.Printf("Using worker ingestion rate %v values/%v ms", ingestionRateGran, RateControlGranularity)
+		recommendedBatchSize := int((ingestionRateGran / float32(valuesPerItem)) * 0.20)
+		log.Printf("Calculated batch size hint: %v (allowed min: %v max: %v)", recommendedBatchSize, RateControlMinBatchSize, r.BatchSize)
+		if recommendedBatchSize < RateControlMinBatchSize {
+			recommendedBatchSize = RateControlMinBatchSize
+		} else if recommendedBatchSize > r.BatchSize {
+			recommendedBatchSize = r.BatchSize
+		}
+		if recommendedBatchSize != r.BatchSize {
+			log.Printf("Adjusting batchSize from %v to %v (%v values in 1 batch)", r.BatchSize, recommendedBatchSize, float64(recommendedBatchSize)*valuesPerItem)
+			r.BatchSize = recommendedBatchSize
+		}
+	} else {
+		log.Printf("Ingestion rate control is off")
+	}
+
+	r.stop = make(chan struct{})
+	if r.NotificationListenPort > 0 {
+		notif := new(NotifyReceiver)
+		rpc.Register(notif)
+		rpc.HandleHTTP()
+		RegisterHandler(r.notifyHandler)
+		ln, e := net.Listen("tcp", fmt.Sprintf(":%d", r.NotificationListenPort))
+		if e != nil {
+			log.Fatal("listen error:", e)
+		}
+		log.Println("Listening for incoming notification")
+		go http.Serve(ln, nil)
+	}
+	if r.TimeLimit > 0 {
+		timer := time.AfterFunc(r.TimeLimit, func() { r.end("Timeout elapsed") })
+		defer timer.Stop()
+	}
+
+	inputs := make([]inputState, len(r.inputs))
+	for i, name := range r.inputs {
+		inputs[i] = inputState{index: i, name: name}
+	}
+
+	var base Checkpoint
+	if r.resumed != nil {
+		base = *r.resumed
+		if base.DBType != l.DBType {
+			log.Fatalf("The checkpoint is that of a %s load", base.DBType)
+		}
+		var items int64
+		for i := range inputs {
+			inputs[i].skip = base.Inputs[inputs[i].name]
+			items += inputs[i].skip.Items
+		}
+		fmt.Printf("resuming after %d items loaded in %fsec\n", items, base.Took.Seconds())
+		r.itemsReserved = items
+		r.latency.Merge(&base.Latency)
+	}
+	if r.CheckpointFile != "" {
+		r.checkpoints = newCheckpointer(r, r.CheckpointFile, l.DBType, r.inputs, base)
+	}
+
+	batchChan := make(chan job, r.Workers)
+	workerBatchChans := make([]chan job, r.Workers)
+	var workersGroup sync.WaitGroup
+	for i := 0; i < r.Workers; i++ {
+		workerBatchChans[i] = make(chan job, 1)
+		var p Processor
+		if r.DoLoad {
+			var err error
+			if p, err = l.NewProcessor(i); err != nil {
+				log.Fatal(err)
+			}
+		}
+		var limiter *rateLimiter
+		if r.IngestRateLimit > 0 {
+			limiter = newRateLimiter(ingestionRateGran)
+		}
+		workersGroup.Add(1)
+		go func(i int, p Processor, limiter *rateLimiter) {
+			r.processBatches(i, p, batchChan, workerBatchChans[i], limiter, valuesPerItem)
+			workersGroup.Done()
+		}(i, p, limiter)
+	}
+
+	if r.ProgressInterval > 0 {
+		ticker := time.NewTicker(r.ProgressInterval)
+		defer ticker.Stop()
+		go func() {
+			start := time.Now()
+			for end := range ticker.C {
+				n := atomic.SwapUint64(&r.progressIntervalItems, 0)
+
+				absoluteMillis := start.UTC().UnixNano() / 1e6
+				fmt.Printf("[interval_progress_items] %dms, %d\n", absoluteMillis, n)
+				r.latencyLock.Lock()
+				if r.intervalLatency.Count() > 0 {
+					fmt.Printf("[interval_write_latency] %dms, %s\n", absoluteMillis, &r.intervalLatency)
+					r.intervalLatency.Reset()
+				}
+				r.latencyLock.Unlock()
+				start = end
+			}
+		}()
+	}
+
+	queue := make(chan *inputState, len(inputs))
+	for i := range inputs {
+		queue <- &inputs[i]
+	}
+	close(queue)
+	readers := r.Readers
+	if readers > len(inputs) {
+		readers = len(inputs)
+	}
+	var readersGroup sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		readersGroup.Add(1)
+		go func() {
+			for in := range queue {
+				if !r.read(l, in, batchChan, workerBatchChans) {
+					break
+				}
+			}
+			readersGroup.Done()
+		}()
+	}
+	readersGroup.Wait()
+	r.startClock()
+
+	close(batchChan)
+	for _, c := range workerBatchChans {
+		close(c)
+	}
+	workersGroup.Wait()
+	took := base.Took + r.elapsed()
+
+	// no premature end from now on:
+	r.stopOnce.Do(func() {})
+	endedPrematurely := r.prematureEndReason != ""
+
+	// The inputs may each end with the size of their dataset, or the last one
+	// with that of all of them, as when a dataset is split.
+	var itemsRead, bytesRead int64
+	var size DatasetSize
+	complete := true
+	for _, in := range inputs {
+		itemsRead += in.items
+		bytesRead += in.bytes
+		complete = complete && in.complete
+		if in.size.Known {
+			size.Points += in.size.Points
+			size.Values += in.size.Values
+			size.Known = true
+		}
+	}
+
+	// The manifest gives the size of the dataset, which its markers, if
+	// any, must match.
+	if complete && r.manifest != nil {
+		if size.Known && (size.Points != r.manifest.Points || size.Values != r.manifest.Values) {
+			log.Fatalf("Incorrect number of read items: %d points and %d values, expected by the manifest: %d points and %d values", size.Points, size.Values, r.manifest.Points, r.manifest.Values)
+		}
+		size = DatasetSize{Points: r.manifest.Points, Values: r.manifest.Values, Known: true}
+	}
+
+	valuesRead := int64(float64(itemsRead) * valuesPerItem)
+	if complete && size.Known {
+		expected := size.Points
+		if l.ItemsAreValues {
+			expected = size.Values
+		}
+		if itemsRead != expected {
+			log.Fatalf("Incorrect number of read items: %d, expected: %d", itemsRead, expected)
+		}
+		valuesRead = size.Values
+	}
+
+	itemsRate := float64(itemsRead) / took.Seconds()
+	bytesRate := float64(bytesRead) / took.Seconds()
+	valuesRate := float64(valuesRead) / took.Seconds()
+
+	fmt.Printf("loaded %d items in %fsec with %d workers (mean item rate %f/sec, mean value rate %f/sec, %.2fMB/sec from input)\n", itemsRead, took.Seconds(), r.Workers, itemsRate, valuesRate, bytesRate/(1<<20))
+
+	var latency *report.LoadLatency
+	if r.latency.Count() > 0 {
+		fmt.Printf("write latency: %s\n", &r.latency)
+		latency = &report.LoadLatency{
+			P50:  r.latency.Percentile(50),
+			P90:  r.latency.Percentile(90),
+			P99:  r.latency.Percentile(99),
+			P999: r.latency.Percentile(99.9),
+			Max:  r.latency.Max(),
+		}
+	}
+
+	if r.ReportHost != "" {
+		reportTags := append(r.reportTags, l.ReportTags...)
+		if endedPrematurely {
+			reportTags = append(reportTags, [2]string{"premature_end_reason", report.Escape(r.prematureEndReason)})
+		}
+		if r.TimeLimit.Seconds() > 0 {
+			reportTags = append(reportTags, [2]string{"time_limit", r.TimeLimit.String()})
+		}
+		if r.IngestRateLimit > 0 {
+			reportTags = append(reportTags, [2]string{"ingest_rate_limit", strconv.Itoa(r.IngestRateLimit)})
+		}
+		if r.resumed != nil {
+			reportTags = append(reportTags, [2]string{"resumed", "true"})
+		}
+		reportParams := &report.LoadReportParams{
+			ReportParams: report.ReportParams{
+				DBType:             l.DBType,
+				ReportDatabaseName: r.ReportDatabase,
+				ReportHost:         r.ReportHost,
+				ReportUser:         r.ReportUser,
+				ReportPassword:     r.ReportPassword,
+				ReportTags:         reportTags,
+				Hostname:           r.reportHostname,
+				DestinationUrl:     l.DestinationUrl,
+				Workers:            r.Workers,
+				ItemLimit:          int(r.ItemLimit),
+			},
+			IsGzip:    l.IsGzip,
+			BatchSize: r.BatchSize,
+		}
+		err := report.ReportLoadResult(reportParams, itemsRead, valuesRate, bytesRate, took, latency)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// end ends the load before the end of the input.
+func (r *LoadRunner) end(reason string) {
+	r.stopOnce.Do(func() {
+		r.prematureEndReason = reason
+		close(r.stop)
+	})
+}
+
+func (r *LoadRunner) notifyHandler(arg int) (int, error) {
+	var e error
+	if arg == 0 {
+		fmt.Println("Received external finish request")
+		r.end("External notification")
+	} else {
+		e = fmt.Errorf("unknown notification code: %d", arg)
+	}
+	return 0, e
+}
+
+// job is a batch sent to the workers, numbered from 1 in the order of its
+// input.
+type job struct {
+	batch Batch
+	input int
+	seq   uint64
+}
+
+// inputState is the reading of an input. Its items and bytes are counted from
+// the start of the input, skipped ones included.
+type inputState struct {
+	index int
+	name  string
+	// skip is the position a resumed load starts from.
+	skip     InputPosition
+	items    int64
+	bytes    int64
+	size     DatasetSize
+	complete bool
+}
+
+func (in *inputState) String() string {
+	if in.name == stdinInput {
+		return "stdin"
+	}
+	return in.name
+}
+
+// startClock starts the clock of the load, if it is not yet.
+func (r *LoadRunner) startClock() {
+	atomic.CompareAndSwapInt64(&r.loadStart, 0, time.Now().UnixNano())
+}
+
+// elapsed returns the time since the start of the load.
+func (r *LoadRunner) elapsed() time.Duration {
+	start := atomic.LoadInt64(&r.loadStart)
+	if start == 0 {
+		return 0
+	}
+	return time.Duration(time.Now().UnixNano() - start)
+}
+
+// reserveItem tells whether an item may be read without exceeding the item
+// limit, and if so, counts it.
+func (r *LoadRunner) reserveItem() bool {
+	if r.ItemLimit < 0 {
+		return true
+	}
+	if atomic.AddInt64(&r.itemsReserved, 1) > r.ItemLimit {
+		atomic.AddInt64(&r.itemsReserved, -1)
+		return false
+	}
+	return true
+}
+
+// read reads an input with a decoder of its own. It tells whether it read
+// the whole input, and the next one should be.
+func (r *LoadRunner) read(l *Loader, in *inputState, batchChan chan job, workerBatchChans []chan job) bool {
+	select {
+	case <-r.stop:
+		return false
+	default:
+	}
+	f, err := openInput(in.name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	reader := bufio.NewReaderSize(f, 4*1024*1024)
+	decoder := l.NewDecoder(r.BatchSize)
+
+	if in.skip.Items > 0 {
+		start := time.Now()
+		r.skip(reader, decoder, in)
+		fmt.Printf("skipped %d items of %s in %fsec\n", in.items, in, time.Now().Sub(start).Seconds())
+	}
+	r.scan(reader, decoder, in, batchChan, workerBatchChans)
+	return in.complete
+}
+
+// skip decodes the first items of an input, which a previous run of the load
+// wrote, without writing them.
+func (r *LoadRunner) skip(reader *bufio.Reader, decoder BatchDecoder, in *inputState) {
+	drop := func(b Batch) {
+		if rb, ok := b.(Releaser); ok {
+			rb.Release()
+		}
+	}
+	for in.items < in.skip.Items {
+		n, b, err := decoder.Decode(reader, &in.size)
+		in.bytes += int64(n)
+		if err == io.EOF {
+			log.Fatalf("%s ends after %d items, before the checkpoint", in, in.items)
+		} else if err != nil {
+			log.Fatalf("Error reading %s after %d items: %s", in, in.items, err.Error())
+		}
+		in.items++
+		if b != nil {
+			drop(b)
+		}
+	}
+	for b := decoder.Flush(); b != nil; b = decoder.Flush() {
+		drop(b)
+	}
+}
+
+// scan decodes the items of an input, and sends the batches to the workers.
+// It records whether it read the whole input. With checkpoints, the decoder
+// is flushed at each one, so that all the items read are in the batches sent.
+func (r *LoadRunner) scan(reader *bufio.Reader, decoder BatchDecoder, in *inputState, batchChan chan job, workerBatchChans []chan job) {
+	var seq uint64
+	send := func(b Batch) {
+		r.startClock()
+		seq++
+		if wb, ok := b.(WorkerBatch); ok {
+			workerBatchChans[wb.Worker()] <- job{b, in.index, seq}
+		} else {
+			batchChan <- job{b, in.index, seq}
+		}
+	}
+	flush := func() {
+		for b := decoder.Flush(); b != nil; b = decoder.Flush() {
+			send(b)
+		}
+	}
+	position := func() InputPosition {
+		return InputPosition{Items: in.items, Bytes: in.bytes}
+	}
+
+	var checkpointC <-chan time.Time
+	if r.checkpoints != nil {
+		ticker := time.NewTicker(r.CheckpointInterval)
+		defer ticker.Stop()
+		checkpointC = ticker.C
+	}
+
+outer:
+	for r.reserveItem() {
+		select {
+		case <-r.stop:
+			break outer
+		case <-checkpointC:
+			flush()
+			r.checkpoints.cut(in.index, seq, position())
+		default:
+		}
+
+		n, b, err := decoder.Decode(reader, &in.size)
+		in.bytes += int64(n)
+		if err == io.EOF {
+			if r.ItemLimit >= 0 {
+				atomic.AddInt64(&r.itemsReserved, -1)
+			}
+			in.complete = true
+			break
+		} else if err != nil {
+			log.Fatalf("Error reading %s after %d items: %s", in, in.items, err.Error())
+		}
+		in.items++
+		if b != nil {
+			send(b)
+		}
+	}
+
+	// Finished reading input, make sure the last batches go out.
+	flush()
+	if r.checkpoints != nil {
+		r.checkpoints.cut(in.index, seq, position())
+	}
+}
+
+// processBatches writes the batches sent to a worker, until the input is
+// done. A worker without processor only reads the input.
+func (r *LoadRunner) processBatches(worker int, p Processor, batchChan, workerBatchChan <-chan job, limiter *rateLimiter, valuesPerItem float64) {
+	var totalBackoff time.Duration
+	for batchChan != nil || workerBatchChan != nil {
+		var j job
+		var ok bool
+		select {
+		case j, ok = <-batchChan:
+			if !ok {
+				batchChan = nil
+				continue
+			}
+		case j, ok = <-workerBatchChan:
+			if !ok {
+				workerBatchChan = nil
+				continue
+			}
+		}
+
+		b := j.batch
+		if p != nil {
+			totalBackoff += r.write(worker, p, b)
+		}
+		if r.checkpoints != nil {
+			r.checkpoints.ack(j.input, j.seq)
+		}
+		if rb, ok := b.(Releaser); ok {
+			rb.Release()
+		}
+		if limiter != nil {
+			limiter.wait(float32(float64(b.Len()) * valuesPerItem))
+		}
+		atomic.AddUint64(&r.progressIntervalItems, uint64(b.Len()))
+	}
+
+	if p != nil {
+		p.Close()
+		fmt.Printf("[worker %d] backoffs took a total of %fsec of runtime\n", worker, totalBackoff.Seconds())
+	}
+}
+
+// write writes a batch, until backoff is not needed. It returns the time
+// spent backing off. The write latency of the batch is that of its last
+// write: it includes the encoding of the batch by the processor, not the
+// backoff.
+func (r *LoadRunner) write(worker int, p Processor, b Batch) time.Duration {
+	var backoffStart time.Time
+	for {
+		start := time.Now()
+		err := p.ProcessBatch(b)
+		lat := time.Now().Sub(start)
+		if err == ErrBackoff {
+			if backoffStart.IsZero() {
+				backoffStart = time.Now()
+			}
+			time.Sleep(r.Backoff)
+			continue
+		}
+		if err != nil {
+			log.Fatalf("Error writing: %s\n", err.Error())
+		}
+		r.latencyLock.Lock()
+		r.latency.Record(lat)
+		r.intervalLatency.Record(lat)
+		r.latencyLock.Unlock()
+		break
+	}
+	if backoffStart.IsZero() {
+		return 0
+	}
+	took := time.Now().Sub(backoffStart)
+	fmt.Printf("[worker %d] backoff took %.02fsec\n", worker, took.Seconds())
+	return took
+}
@@ -3,7 +3,6 @@ package cassandra
 import (
 	"fmt"
 	bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
-	"math/rand"
 	"time"
 )
 
@@ -61,7 +60,7 @@ func (d *CassandraDevops) MaxCPUUsage12HoursByMinuteOneHost(q bulkQuerygen.Query
 // SELECT max(usage_user) from cpu where (hostname = '$HOSTNAME_1' or ... or hostname = '$HOSTNAME_N') and time >= '$HOUR_START' and time < '$HOUR_END' group by time(1m)
 func (d *CassandraDevops) maxCPUUsageHourByMinuteNHosts(qi bulkQuerygen.Query, nhosts int, timeRange time.Duration) {
 	interval := d.AllInterval.RandWindow(timeRange)
-	nn := rand.Perm(d.ScaleVar)[:nhosts]
+	nn := bulkQuerygen.RandomHosts(d.ScaleVar, nhosts, interval)
 
 	tagSets := [][]string{}
 	tagSet := []string{}
@@ -1,5 +1,19 @@
 package bulk_query_gen
 
+import (
+	"math/rand"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/devops"
+)
+
+// DevopsHostChurn is the host replacement model of the devops data set, nil
+// when its hosts live for the whole time range.
+var DevopsHostChurn *devops.HostChurn
+
+// DevopsHostOffset is the number of the first host of the devops data set,
+// its scale var offset.
+var DevopsHostOffset int64
+
 // Devops describes a devops query generator.
 type Devops interface {
 	MaxCPUUsageHourByMinuteOneHost(Query)
@@ -57,3 +71,22 @@ func DevopsDispatchAll(d Devops, iteration int, q Query, scaleVar int) {
 		panic("logic error in switch statement")
 	}
 }
+
+// RandomHosts returns the numbers n of nhosts distinct random hosts
+// (host_<n>) out of the scaleVar hosts of the devops data set, numbered from
+// DevopsHostOffset. With host churn, only the hosts having data in the given
+// interval are chosen.
+func RandomHosts(scaleVar int, nhosts int, interval TimeInterval) []int64 {
+	hosts := make([]int64, 0, nhosts)
+	if DevopsHostChurn == nil {
+		for _, n := range rand.Perm(scaleVar)[:nhosts] {
+			hosts = append(hosts, DevopsHostOffset+int64(n))
+		}
+		return hosts
+	}
+	live := DevopsHostChurn.LiveHosts(interval.Start, interval.End)
+	for _, i := range rand.Perm(len(live))[:nhosts] {
+		hosts = append(hosts, live[i])
+	}
+	return hosts
+}
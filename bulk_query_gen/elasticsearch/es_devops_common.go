@@ -5,7 +5,6 @@ import (
 	"fmt"
 	bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
 	"io"
-	"math/rand"
 	"strings"
 	"text/template"
 	"time"
@@ -79,7 +78,7 @@ func (d *ElasticSearchDevops) MaxCPUUsage12HoursByMinuteOneHost(q bulkQuerygen.Q
 
 func (d *ElasticSearchDevops) maxCPUUsageHourByMinuteNHosts(qi bulkQuerygen.Query, nhosts int, timeRange time.Duration) {
 	interval := d.AllInterval.RandWindow(timeRange)
-	nn := rand.Perm(d.ScaleVar)[:nhosts]
+	nn := bulkQuerygen.RandomHosts(d.ScaleVar, nhosts, interval)
 
 	hostnames := []string{}
 	for _, n := range nn {
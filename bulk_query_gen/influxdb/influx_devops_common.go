@@ -3,7 +3,6 @@ package influxdb
 import (
 	"fmt"
 	bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
-	"math/rand"
 	"strings"
 	"time"
 )
@@ -64,7 +63,7 @@ func (d *InfluxDevops) MaxCPUUsage12HoursByMinuteOneHost(q bulkQuerygen.Query) {
 // SELECT max(usage_user) from cpu where (hostname = '$HOSTNAME_1' or ... or hostname = '$HOSTNAME_N') and time >= '$HOUR_START' and time < '$HOUR_END' group by time(1m)
 func (d *InfluxDevops) maxCPUUsageHourByMinuteNHosts(qi bulkQuerygen.Query, nhosts int, timeRange time.Duration) {
 	interval := d.AllInterval.RandWindow(timeRange)
-	nn := rand.Perm(d.ScaleVar)[:nhosts]
+	nn := bulkQuerygen.RandomHosts(d.ScaleVar, nhosts, interval)
 
 	hostnames := []string{}
 	for _, n := range nn {
@@ -0,0 +1,29 @@
+package influxdb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// InfluxKubernetesOneNodeOneHour produces Influx-specific queries for the max node cpu, one node, one hour case.
+type InfluxKubernetesOneNodeOneHour struct {
+	InfluxKubernetes
+}
+
+func NewInfluxQLKubernetesOneNodeOneHour(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(InfluxQL, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesOneNodeOneHour{
+		InfluxKubernetes: *underlying,
+	}
+}
+
+func NewFluxKubernetesOneNodeOneHour(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(Flux, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesOneNodeOneHour{
+		InfluxKubernetes: *underlying,
+	}
+}
+
+func (d *InfluxKubernetesOneNodeOneHour) Dispatch(i int) bulkQuerygen.Query {
+	q := bulkQuerygen.NewHTTPQuery() // from pool
+	d.MaxNodeCPUHourByMinuteOneNode(q)
+	return q
+}
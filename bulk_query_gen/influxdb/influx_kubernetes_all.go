@@ -0,0 +1,23 @@
+package influxdb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// InfluxKubernetesAll produces Influx-specific queries for all the Kubernetes query types, in turn.
+type InfluxKubernetesAll struct {
+	InfluxKubernetes
+}
+
+func NewInfluxQLKubernetesAll(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(InfluxQL, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesAll{
+		InfluxKubernetes: *underlying,
+	}
+}
+
+func NewFluxKubernetesAll(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(Flux, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesAll{
+		InfluxKubernetes: *underlying,
+	}
+}
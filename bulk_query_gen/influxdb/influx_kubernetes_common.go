@@ -0,0 +1,140 @@
+package influxdb
+
+import (
+	"fmt"
+	bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+	"time"
+)
+
+// InfluxKubernetes produces Influx-specific queries for all the Kubernetes query types.
+type InfluxKubernetes struct {
+	InfluxCommon
+}
+
+// newInfluxKubernetesCommon makes an InfluxKubernetes object ready to generate Queries.
+func newInfluxKubernetesCommon(lang Language, dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	if _, ok := dbConfig[bulkQuerygen.DatabaseName]; !ok {
+		panic("need influx database name")
+	}
+
+	return &InfluxKubernetes{
+		InfluxCommon: *newInfluxCommon(lang, dbConfig[bulkQuerygen.DatabaseName], interval, scaleVar),
+	}
+}
+
+// Dispatch fulfills the QueryGenerator interface.
+func (d *InfluxKubernetes) Dispatch(i int) bulkQuerygen.Query {
+	q := bulkQuerygen.NewHTTPQuery() // from pool
+	bulkQuerygen.KubernetesDispatchAll(d, i, q, d.ScaleVar)
+	return q
+}
+
+// MaxNodeCPUHourByMinuteOneNode populates a Query with a query that looks like:
+// SELECT max(cpu_usage_cores) from kube_node where node_name = '$NODE' and time >= '$HOUR_START' and time < '$HOUR_END' group by time(1m)
+func (d *InfluxKubernetes) MaxNodeCPUHourByMinuteOneNode(qi bulkQuerygen.Query) {
+	interval := bulkQuerygen.KubernetesWindow(&d.AllInterval, time.Hour)
+	node := bulkQuerygen.RandomNode(d.ScaleVar)
+
+	var query string
+	if d.language == InfluxQL {
+		query = fmt.Sprintf("SELECT max(cpu_usage_cores) from kube_node where node_name = '%s' and time >= '%s' and time < '%s' group by time(1m)", node, interval.StartString(), interval.EndString())
+	} else { // Flux
+		query = fmt.Sprintf(`from(db:"%s") `+
+			`|> range(start:%s, stop:%s) `+
+			`|> filter(fn:(r) => r._measurement == "kube_node" and r._field == "cpu_usage_cores" and r.node_name == "%s") `+
+			`|> keep(columns:["_start", "_stop", "_time", "_value"]) `+
+			`|> window(every:1m) `+
+			`|> max() `+
+			`|> yield()`,
+			d.DatabaseName,
+			interval.StartString(), interval.EndString(),
+			node)
+	}
+
+	humanLabel := fmt.Sprintf("InfluxDB (%s) max node cpu, rand node, rand %s by 1m", d.language.String(), interval.Duration())
+	q := qi.(*bulkQuerygen.HTTPQuery)
+	d.getHttpQuery(humanLabel, interval.StartString(), query, q)
+}
+
+// MeanDeploymentCPUHourByMinuteByPod populates a Query with a query that looks like:
+// SELECT mean(usage_cores) from container_cpu where namespace = '$NAMESPACE' and deployment = '$DEPLOYMENT' and time >= '$HOUR_START' and time < '$HOUR_END' group by time(1m),pod_name
+func (d *InfluxKubernetes) MeanDeploymentCPUHourByMinuteByPod(qi bulkQuerygen.Query) {
+	interval := bulkQuerygen.KubernetesWindow(&d.AllInterval, time.Hour)
+	w := bulkQuerygen.RandomWorkload()
+
+	var query string
+	if d.language == InfluxQL {
+		query = fmt.Sprintf("SELECT mean(usage_cores) from container_cpu where namespace = '%s' and deployment = '%s' and time >= '%s' and time < '%s' group by time(1m),pod_name", w.Namespace, w.Deployment, interval.StartString(), interval.EndString())
+	} else { // Flux
+		query = fmt.Sprintf(`from(db:"%s") `+
+			`|> range(start:%s, stop:%s) `+
+			`|> filter(fn:(r) => r._measurement == "container_cpu" and r._field == "usage_cores" and r.namespace == "%s" and r.deployment == "%s") `+
+			`|> keep(columns:["_start", "_stop", "pod_name", "_value", "_time"]) `+
+			`|> group(by:["pod_name"]) `+
+			`|> window(every:1m) `+
+			`|> mean() `+
+			`|> yield()`,
+			d.DatabaseName,
+			interval.StartString(), interval.EndString(),
+			w.Namespace, w.Deployment)
+	}
+
+	humanLabel := fmt.Sprintf("InfluxDB (%s) mean container cpu, rand deployment, rand %s by 1m and pod", d.language.String(), interval.Duration())
+	q := qi.(*bulkQuerygen.HTTPQuery)
+	d.getHttpQuery(humanLabel, interval.StartString(), query, q)
+}
+
+// MaxNamespaceMemoryHourByMinuteByDeployment populates a Query with a query that looks like:
+// SELECT max(usage_bytes) from container_memory where namespace = '$NAMESPACE' and time >= '$HOUR_START' and time < '$HOUR_END' group by time(1m),deployment
+func (d *InfluxKubernetes) MaxNamespaceMemoryHourByMinuteByDeployment(qi bulkQuerygen.Query) {
+	interval := bulkQuerygen.KubernetesWindow(&d.AllInterval, time.Hour)
+	namespace := bulkQuerygen.RandomWorkload().Namespace
+
+	var query string
+	if d.language == InfluxQL {
+		query = fmt.Sprintf("SELECT max(usage_bytes) from container_memory where namespace = '%s' and time >= '%s' and time < '%s' group by time(1m),deployment", namespace, interval.StartString(), interval.EndString())
+	} else { // Flux
+		query = fmt.Sprintf(`from(db:"%s") `+
+			`|> range(start:%s, stop:%s) `+
+			`|> filter(fn:(r) => r._measurement == "container_memory" and r._field == "usage_bytes" and r.namespace == "%s") `+
+			`|> keep(columns:["_start", "_stop", "deployment", "_value", "_time"]) `+
+			`|> group(by:["deployment"]) `+
+			`|> window(every:1m) `+
+			`|> max() `+
+			`|> yield()`,
+			d.DatabaseName,
+			interval.StartString(), interval.EndString(),
+			namespace)
+	}
+
+	humanLabel := fmt.Sprintf("InfluxDB (%s) max container memory, rand namespace, rand %s by 1m and deployment", d.language.String(), interval.Duration())
+	q := qi.(*bulkQuerygen.HTTPQuery)
+	d.getHttpQuery(humanLabel, interval.StartString(), query, q)
+}
+
+// MaxPodRestarts12HoursByPod populates a Query with a query that looks like:
+// SELECT max(restarts_total) from kube_pod where namespace = '$NAMESPACE' and time >= '$START' and time < '$END' group by pod_name
+func (d *InfluxKubernetes) MaxPodRestarts12HoursByPod(qi bulkQuerygen.Query) {
+	interval := bulkQuerygen.KubernetesWindow(&d.AllInterval, 12*time.Hour)
+	namespace := bulkQuerygen.RandomWorkload().Namespace
+
+	var query string
+	if d.language == InfluxQL {
+		query = fmt.Sprintf("SELECT max(restarts_total) from kube_pod where namespace = '%s' and time >= '%s' and time < '%s' group by pod_name", namespace, interval.StartString(), interval.EndString())
+	} else { // Flux
+		query = fmt.Sprintf(`from(db:"%s") `+
+			`|> range(start:%s, stop:%s) `+
+			`|> filter(fn:(r) => r._measurement == "kube_pod" and r._field == "restarts_total" and r.namespace == "%s") `+
+			`|> keep(columns:["pod_name", "_value"]) `+
+			`|> group(by:["pod_name"]) `+
+			`|> max() `+
+			`|> yield()`,
+			d.DatabaseName,
+			interval.StartString(), interval.EndString(),
+			namespace)
+	}
+
+	humanLabel := fmt.Sprintf("InfluxDB (%s) max pod restarts, rand namespace, rand %s by pod", d.language.String(), interval.Duration())
+	q := qi.(*bulkQuerygen.HTTPQuery)
+	d.getHttpQuery(humanLabel, interval.StartString(), query, q)
+}
@@ -0,0 +1,29 @@
+package influxdb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// InfluxKubernetesDeploymentCpu produces Influx-specific queries for the deployment cpu by pod case.
+type InfluxKubernetesDeploymentCpu struct {
+	InfluxKubernetes
+}
+
+func NewInfluxQLKubernetesDeploymentCpu(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(InfluxQL, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesDeploymentCpu{
+		InfluxKubernetes: *underlying,
+	}
+}
+
+func NewFluxKubernetesDeploymentCpu(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(Flux, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesDeploymentCpu{
+		InfluxKubernetes: *underlying,
+	}
+}
+
+func (d *InfluxKubernetesDeploymentCpu) Dispatch(i int) bulkQuerygen.Query {
+	q := bulkQuerygen.NewHTTPQuery() // from pool
+	d.MeanDeploymentCPUHourByMinuteByPod(q)
+	return q
+}
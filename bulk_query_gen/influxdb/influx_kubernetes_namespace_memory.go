@@ -0,0 +1,29 @@
+package influxdb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// InfluxKubernetesNamespaceMemory produces Influx-specific queries for the namespace memory by deployment case.
+type InfluxKubernetesNamespaceMemory struct {
+	InfluxKubernetes
+}
+
+func NewInfluxQLKubernetesNamespaceMemory(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(InfluxQL, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesNamespaceMemory{
+		InfluxKubernetes: *underlying,
+	}
+}
+
+func NewFluxKubernetesNamespaceMemory(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(Flux, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesNamespaceMemory{
+		InfluxKubernetes: *underlying,
+	}
+}
+
+func (d *InfluxKubernetesNamespaceMemory) Dispatch(i int) bulkQuerygen.Query {
+	q := bulkQuerygen.NewHTTPQuery() // from pool
+	d.MaxNamespaceMemoryHourByMinuteByDeployment(q)
+	return q
+}
@@ -0,0 +1,29 @@
+package influxdb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// InfluxKubernetesPodRestarts produces Influx-specific queries for the pod restarts case.
+type InfluxKubernetesPodRestarts struct {
+	InfluxKubernetes
+}
+
+func NewInfluxQLKubernetesPodRestarts(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(InfluxQL, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesPodRestarts{
+		InfluxKubernetes: *underlying,
+	}
+}
+
+func NewFluxKubernetesPodRestarts(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newInfluxKubernetesCommon(Flux, dbConfig, interval, duration, scaleVar).(*InfluxKubernetes)
+	return &InfluxKubernetesPodRestarts{
+		InfluxKubernetes: *underlying,
+	}
+}
+
+func (d *InfluxKubernetesPodRestarts) Dispatch(i int) bulkQuerygen.Query {
+	q := bulkQuerygen.NewHTTPQuery() // from pool
+	d.MaxPodRestarts12HoursByPod(q)
+	return q
+}
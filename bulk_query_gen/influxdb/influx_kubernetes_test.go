@@ -0,0 +1,41 @@
+package influxdb
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+)
+
+var timeBoundsRE = regexp.MustCompile(`time >= '([^']+)' and time < '([^']+)'`)
+
+// TestKubernetesShortDataset checks that the queries of datasets shorter
+// than their windows cover the whole dataset.
+func TestKubernetesShortDataset(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, duration := range []time.Duration{time.Hour, 3 * time.Hour, 24 * time.Hour} {
+		interval := bulkQuerygen.NewTimeInterval(start, start.Add(duration))
+		dbConfig := bulkQuerygen.DatabaseConfig{bulkQuerygen.DatabaseName: "benchmark_db"}
+		g := NewInfluxQLKubernetesAll(dbConfig, interval, duration, 4)
+		for i := 0; i < 8; i++ {
+			q := g.Dispatch(i).(*bulkQuerygen.HTTPQuery)
+			path := string(q.Path)
+			values, err := url.ParseQuery(path[strings.Index(path, "?")+1:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			bounds := timeBoundsRE.FindStringSubmatch(values.Get("q"))
+			if bounds == nil {
+				t.Fatalf("no time bounds in %s", values.Get("q"))
+			}
+			from, _ := time.Parse(time.RFC3339, bounds[1])
+			to, _ := time.Parse(time.RFC3339, bounds[2])
+			if from.Before(interval.Start) || to.After(interval.End) || !from.Before(to) {
+				t.Errorf("%v dataset: query %d out of its time range: %s", duration, i, values.Get("q"))
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package bulk_query_gen
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/kubernetes"
+)
+
+// KubernetesNodeOffset is the number of the first node of the Kubernetes
+// data set, its scale var offset.
+var KubernetesNodeOffset int64
+
+// Kubernetes describes a Kubernetes query generator.
+type Kubernetes interface {
+	MaxNodeCPUHourByMinuteOneNode(Query)
+	MeanDeploymentCPUHourByMinuteByPod(Query)
+	MaxNamespaceMemoryHourByMinuteByDeployment(Query)
+	MaxPodRestarts12HoursByPod(Query)
+
+	Dispatch(int) Query
+}
+
+// KubernetesDispatchAll round-robins through the different Kubernetes queries.
+func KubernetesDispatchAll(d Kubernetes, iteration int, q Query, scaleVar int) {
+	if scaleVar <= 0 {
+		panic("logic error: bad scalevar")
+	}
+
+	switch iteration % 4 {
+	case 0:
+		d.MaxNodeCPUHourByMinuteOneNode(q)
+	case 1:
+		d.MeanDeploymentCPUHourByMinuteByPod(q)
+	case 2:
+		d.MaxNamespaceMemoryHourByMinuteByDeployment(q)
+	case 3:
+		d.MaxPodRestarts12HoursByPod(q)
+	default:
+		panic("logic error in switch statement")
+	}
+}
+
+// KubernetesWindow returns a window of the given duration at a random start
+// within the interval of the queries, or the whole interval when it is not
+// longer.
+func KubernetesWindow(all *TimeInterval, window time.Duration) TimeInterval {
+	if all.Duration() <= window {
+		return *all
+	}
+	return all.RandWindow(window)
+}
+
+// RandomNode returns the name of one of the scaleVar simulated nodes,
+// numbered from KubernetesNodeOffset.
+func RandomNode(scaleVar int) string {
+	return fmt.Sprintf(kubernetes.NodeNameFormat, KubernetesNodeOffset+int64(rand.Intn(scaleVar)))
+}
+
+// RandomWorkload returns one of the simulated deployments. Every deployment
+// has pods in a large enough cluster, as they are spread over the nodes at
+// random.
+func RandomWorkload() *kubernetes.Workload {
+	return &kubernetes.Workloads[rand.Intn(len(kubernetes.Workloads))]
+}
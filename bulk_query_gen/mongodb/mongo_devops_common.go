@@ -3,7 +3,6 @@ package mongodb
 import (
 	"fmt"
 	bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
-	"math/rand"
 	"time"
 )
 
@@ -66,7 +65,7 @@ func (d *MongoDevops) MaxCPUUsage12HoursByMinuteOneHost(q bulkQuerygen.Query) {
 
 func (d *MongoDevops) maxCPUUsageHourByMinuteNHosts(qi bulkQuerygen.Query, nhosts int, timeRange time.Duration) {
 	interval := d.AllInterval.RandWindow(timeRange)
-	nn := rand.Perm(d.ScaleVar)[:nhosts]
+	nn := bulkQuerygen.RandomHosts(d.ScaleVar, nhosts, interval)
 
 	hostnames := []string{}
 	for _, n := range nn {
@@ -0,0 +1,22 @@
+package timescaledb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// TimescaleKubernetesOneNodeOneHour produces Timescale-specific queries for the max node cpu, one node, one hour case.
+type TimescaleKubernetesOneNodeOneHour struct {
+	TimescaleKubernetes
+}
+
+func NewTimescaleKubernetesOneNodeOneHour(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newTimescaleKubernetesCommon(dbConfig, interval, duration, scaleVar).(*TimescaleKubernetes)
+	return &TimescaleKubernetesOneNodeOneHour{
+		TimescaleKubernetes: *underlying,
+	}
+}
+
+func (d *TimescaleKubernetesOneNodeOneHour) Dispatch(i int) bulkQuerygen.Query {
+	q := NewSQLQuery() // from pool
+	d.MaxNodeCPUHourByMinuteOneNode(q)
+	return q
+}
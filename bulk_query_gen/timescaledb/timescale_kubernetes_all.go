@@ -0,0 +1,16 @@
+package timescaledb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// TimescaleKubernetesAll produces Timescale-specific queries for all the Kubernetes query types, in turn.
+type TimescaleKubernetesAll struct {
+	TimescaleKubernetes
+}
+
+func NewTimescaleKubernetesAll(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newTimescaleKubernetesCommon(dbConfig, interval, duration, scaleVar).(*TimescaleKubernetes)
+	return &TimescaleKubernetesAll{
+		TimescaleKubernetes: *underlying,
+	}
+}
@@ -0,0 +1,88 @@
+package timescaledb
+
+import (
+	"fmt"
+	bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+	"time"
+)
+
+// TimescaleKubernetes produces Timescale-specific queries for all the Kubernetes query types.
+type TimescaleKubernetes struct {
+	bulkQuerygen.CommonParams
+	DatabaseName string
+}
+
+// newTimescaleKubernetesCommon makes an TimescaleKubernetes object ready to generate Queries.
+func newTimescaleKubernetesCommon(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	if _, ok := dbConfig[bulkQuerygen.DatabaseName]; !ok {
+		panic("need timescale database name")
+	}
+
+	return &TimescaleKubernetes{
+		CommonParams: *bulkQuerygen.NewCommonParams(interval, scaleVar),
+		DatabaseName: dbConfig[bulkQuerygen.DatabaseName],
+	}
+}
+
+// Dispatch fulfills the QueryGenerator interface.
+func (d *TimescaleKubernetes) Dispatch(i int) bulkQuerygen.Query {
+	q := NewSQLQuery() // from pool
+	bulkQuerygen.KubernetesDispatchAll(d, i, q, d.ScaleVar)
+	return q
+}
+
+// MaxNodeCPUHourByMinuteOneNode populates a Query with a query that looks like:
+// select time_bucket(60000000000,time) as time1min,max(cpu_usage_cores) from kube_node where node_name = '$NODE' and time >=$HOUR_START and time < $HOUR_END group by time1min order by time1min;
+func (d *TimescaleKubernetes) MaxNodeCPUHourByMinuteOneNode(qi bulkQuerygen.Query) {
+	interval := bulkQuerygen.KubernetesWindow(&d.AllInterval, time.Hour)
+	node := bulkQuerygen.RandomNode(d.ScaleVar)
+
+	humanLabel := fmt.Sprintf("Timescale max node cpu, rand node, rand %s by 1m", interval.Duration())
+	q := qi.(*SQLQuery)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", humanLabel, interval.StartString()))
+
+	q.QuerySQL = []byte(fmt.Sprintf("select time_bucket(60000000000,time) as time1min,max(cpu_usage_cores) from kube_node where node_name = '%s' and time >=%d and time < %d group by time1min order by time1min", node, interval.StartUnixNano(), interval.EndUnixNano()))
+}
+
+// MeanDeploymentCPUHourByMinuteByPod populates a Query with a query that looks like:
+// select time_bucket(60000000000,time) as time1min,pod_name,avg(usage_cores) from container_cpu where namespace = '$NAMESPACE' and deployment = '$DEPLOYMENT' and time >=$HOUR_START and time < $HOUR_END group by time1min,pod_name order by time1min;
+func (d *TimescaleKubernetes) MeanDeploymentCPUHourByMinuteByPod(qi bulkQuerygen.Query) {
+	interval := bulkQuerygen.KubernetesWindow(&d.AllInterval, time.Hour)
+	w := bulkQuerygen.RandomWorkload()
+
+	humanLabel := fmt.Sprintf("Timescale mean container cpu, rand deployment, rand %s by 1m and pod", interval.Duration())
+	q := qi.(*SQLQuery)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", humanLabel, interval.StartString()))
+
+	q.QuerySQL = []byte(fmt.Sprintf("select time_bucket(60000000000,time) as time1min,pod_name,avg(usage_cores) from container_cpu where namespace = '%s' and deployment = '%s' and time >=%d and time < %d group by time1min,pod_name order by time1min", w.Namespace, w.Deployment, interval.StartUnixNano(), interval.EndUnixNano()))
+}
+
+// MaxNamespaceMemoryHourByMinuteByDeployment populates a Query with a query that looks like:
+// select time_bucket(60000000000,time) as time1min,deployment,max(usage_bytes) from container_memory where namespace = '$NAMESPACE' and time >=$HOUR_START and time < $HOUR_END group by time1min,deployment order by time1min;
+func (d *TimescaleKubernetes) MaxNamespaceMemoryHourByMinuteByDeployment(qi bulkQuerygen.Query) {
+	interval := bulkQuerygen.KubernetesWindow(&d.AllInterval, time.Hour)
+	namespace := bulkQuerygen.RandomWorkload().Namespace
+
+	humanLabel := fmt.Sprintf("Timescale max container memory, rand namespace, rand %s by 1m and deployment", interval.Duration())
+	q := qi.(*SQLQuery)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", humanLabel, interval.StartString()))
+
+	q.QuerySQL = []byte(fmt.Sprintf("select time_bucket(60000000000,time) as time1min,deployment,max(usage_bytes) from container_memory where namespace = '%s' and time >=%d and time < %d group by time1min,deployment order by time1min", namespace, interval.StartUnixNano(), interval.EndUnixNano()))
+}
+
+// MaxPodRestarts12HoursByPod populates a Query with a query that looks like:
+// select pod_name,max(restarts_total) from kube_pod where namespace = '$NAMESPACE' and time >=$START and time < $END group by pod_name;
+func (d *TimescaleKubernetes) MaxPodRestarts12HoursByPod(qi bulkQuerygen.Query) {
+	interval := bulkQuerygen.KubernetesWindow(&d.AllInterval, 12*time.Hour)
+	namespace := bulkQuerygen.RandomWorkload().Namespace
+
+	humanLabel := fmt.Sprintf("Timescale max pod restarts, rand namespace, rand %s by pod", interval.Duration())
+	q := qi.(*SQLQuery)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", humanLabel, interval.StartString()))
+
+	q.QuerySQL = []byte(fmt.Sprintf("select pod_name,max(restarts_total) from kube_pod where namespace = '%s' and time >=%d and time < %d group by pod_name", namespace, interval.StartUnixNano(), interval.EndUnixNano()))
+}
@@ -0,0 +1,22 @@
+package timescaledb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// TimescaleKubernetesDeploymentCpu produces Timescale-specific queries for the deployment cpu by pod case.
+type TimescaleKubernetesDeploymentCpu struct {
+	TimescaleKubernetes
+}
+
+func NewTimescaleKubernetesDeploymentCpu(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newTimescaleKubernetesCommon(dbConfig, interval, duration, scaleVar).(*TimescaleKubernetes)
+	return &TimescaleKubernetesDeploymentCpu{
+		TimescaleKubernetes: *underlying,
+	}
+}
+
+func (d *TimescaleKubernetesDeploymentCpu) Dispatch(i int) bulkQuerygen.Query {
+	q := NewSQLQuery() // from pool
+	d.MeanDeploymentCPUHourByMinuteByPod(q)
+	return q
+}
@@ -0,0 +1,22 @@
+package timescaledb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// TimescaleKubernetesNamespaceMemory produces Timescale-specific queries for the namespace memory by deployment case.
+type TimescaleKubernetesNamespaceMemory struct {
+	TimescaleKubernetes
+}
+
+func NewTimescaleKubernetesNamespaceMemory(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newTimescaleKubernetesCommon(dbConfig, interval, duration, scaleVar).(*TimescaleKubernetes)
+	return &TimescaleKubernetesNamespaceMemory{
+		TimescaleKubernetes: *underlying,
+	}
+}
+
+func (d *TimescaleKubernetesNamespaceMemory) Dispatch(i int) bulkQuerygen.Query {
+	q := NewSQLQuery() // from pool
+	d.MaxNamespaceMemoryHourByMinuteByDeployment(q)
+	return q
+}
@@ -0,0 +1,22 @@
+package timescaledb
+
+import "time"
+import bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+
+// TimescaleKubernetesPodRestarts produces Timescale-specific queries for the pod restarts case.
+type TimescaleKubernetesPodRestarts struct {
+	TimescaleKubernetes
+}
+
+func NewTimescaleKubernetesPodRestarts(dbConfig bulkQuerygen.DatabaseConfig, interval bulkQuerygen.TimeInterval, duration time.Duration, scaleVar int) bulkQuerygen.QueryGenerator {
+	underlying := newTimescaleKubernetesCommon(dbConfig, interval, duration, scaleVar).(*TimescaleKubernetes)
+	return &TimescaleKubernetesPodRestarts{
+		TimescaleKubernetes: *underlying,
+	}
+}
+
+func (d *TimescaleKubernetesPodRestarts) Dispatch(i int) bulkQuerygen.Query {
+	q := NewSQLQuery() // from pool
+	d.MaxPodRestarts12HoursByPod(q)
+	return q
+}
@@ -0,0 +1,35 @@
+package timescaledb
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	bulkQuerygen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
+)
+
+var timeBoundsRE = regexp.MustCompile(`time >=(\d+) and time < (\d+)`)
+
+// TestKubernetesShortDataset checks that the queries of datasets shorter
+// than their windows cover the whole dataset.
+func TestKubernetesShortDataset(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, duration := range []time.Duration{time.Hour, 3 * time.Hour, 24 * time.Hour} {
+		interval := bulkQuerygen.NewTimeInterval(start, start.Add(duration))
+		dbConfig := bulkQuerygen.DatabaseConfig{bulkQuerygen.DatabaseName: "benchmark_db"}
+		g := NewTimescaleKubernetesAll(dbConfig, interval, duration, 4)
+		for i := 0; i < 8; i++ {
+			q := g.Dispatch(i).(*SQLQuery)
+			bounds := timeBoundsRE.FindSubmatch(q.QuerySQL)
+			if bounds == nil {
+				t.Fatalf("no time bounds in %s", q.QuerySQL)
+			}
+			from, _ := strconv.ParseInt(string(bounds[1]), 10, 64)
+			to, _ := strconv.ParseInt(string(bounds[2]), 10, 64)
+			if from < interval.StartUnixNano() || to > interval.EndUnixNano() || from >= to {
+				t.Errorf("%v dataset: query %d out of its time range: %s", duration, i, q.QuerySQL)
+			}
+		}
+	}
+}
@@ -0,0 +1,414 @@
+// bulk_data_compare checks that two datasets generated by bulk_data_gen, in
+// different formats, hold the same data: it reads the points of both back and
+// compares their values series by series.
+//
+// It reports every value differing by more than the float tolerance, the
+// series and values missing from either dataset, and the precision lost by
+// the formats: truncated timestamps, integers turned into floats and floats
+// rounded within the tolerance. It exits with status 1 if the datasets
+// differ, precision losses aside.
+//
+// Supported formats: influx-bulk, tsdb, opentsdb and cassandra, possibly
+// gzip or zstd compressed. The first dataset is held in memory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+// dataset is one of the datasets to compare.
+type dataset struct {
+	name      string
+	file      string
+	format    string
+	precision string
+
+	unit time.Duration
+}
+
+// Program option vars:
+var (
+	a = dataset{name: "a"}
+	b = dataset{name: "b"}
+
+	floatTolerance float64
+)
+
+// Parse args:
+func init() {
+	for _, d := range []*dataset{&a, &b} {
+		flag.StringVar(&d.file, "file-"+d.name, "", fmt.Sprintf("File of dataset %s, possibly gzip or zstd compressed.", d.name))
+		flag.StringVar(&d.format, "format-"+d.name, common.ReadableFormatChoices[0], fmt.Sprintf("Format of dataset %s. (choices: %s)", d.name, strings.Join(common.ReadableFormatChoices, ", ")))
+		flag.StringVar(&d.precision, "precision-"+d.name, "ns", fmt.Sprintf("Precision of the timestamps of dataset %s, if influx-bulk. (choices: %s)", d.name, strings.Join(common.InfluxPrecisionChoices, ", ")))
+	}
+	flag.Float64Var(&floatTolerance, "float-tolerance", 1e-9, "Relative difference below which float values are equal.")
+
+	flag.Parse()
+
+	for _, d := range []*dataset{&a, &b} {
+		if d.file == "" {
+			log.Fatalf("the file of dataset %s must be given", d.name)
+		}
+		unit, err := common.InfluxPrecisionUnit(d.precision)
+		if err != nil {
+			log.Fatal(err)
+		}
+		d.unit = common.FormatPrecision(d.format, unit)
+	}
+	if floatTolerance < 0 {
+		log.Fatal("float tolerance must not be negative")
+	}
+}
+
+// valueKey identifies a value of a series.
+type valueKey struct {
+	field string
+	// nanoseconds, truncated to the coarser precision of the datasets:
+	timestamp int64
+}
+
+// series holds the values of a series of dataset a not found in b yet.
+type series struct {
+	values map[valueKey]interface{}
+	seen   bool
+}
+
+// fieldKey identifies a field of a measurement, in precision loss reports.
+type fieldKey struct {
+	measurement string
+	field       string
+}
+
+type typeChange struct {
+	fieldKey
+	a, b string
+}
+
+type rounding struct {
+	count   int64
+	maxDiff float64
+}
+
+// comparison accumulates the differences found.
+type comparison struct {
+	unit time.Duration
+
+	series map[string]*series
+
+	compared    int64
+	mismatches  int64
+	duplicates  int64            // values found twice in a
+	truncated   map[string]int64 // per dataset
+	typeChanges map[typeChange]int64
+	roundings   map[fieldKey]*rounding
+
+	// series of b missing from a, and their number of values:
+	missingSeries map[string]int64
+	// values of b missing from a, per series:
+	missingValues map[string]int64
+}
+
+func main() {
+	c := &comparison{
+		unit:          a.unit,
+		series:        make(map[string]*series),
+		truncated:     make(map[string]int64),
+		typeChanges:   make(map[typeChange]int64),
+		roundings:     make(map[fieldKey]*rounding),
+		missingSeries: make(map[string]int64),
+		missingValues: make(map[string]int64),
+	}
+	if b.unit > c.unit {
+		c.unit = b.unit
+	}
+
+	t := time.Now()
+	if err := readDataset(&a, c.load); err != nil {
+		log.Fatal(err)
+	}
+	if err := readDataset(&b, c.compare); err != nil {
+		log.Fatal(err)
+	}
+	if !c.report() {
+		log.Printf("Datasets differ, took %0f seconds\n", time.Now().Sub(t).Seconds())
+		os.Exit(1)
+	}
+	log.Printf("Datasets match, took %0f seconds\n", time.Now().Sub(t).Seconds())
+}
+
+// readDataset calls f with every point of d.
+func readDataset(d *dataset, f func(p *common.Point)) error {
+	file, err := os.Open(d.file)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	r, _, err := common.NewDecompressingReader(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	points, err := common.NewPointReader(r, d.format, d.unit)
+	if err != nil {
+		return err
+	}
+
+	p := common.MakeUsablePoint()
+	for {
+		p.Reset()
+		err := points.ReadPoint(p)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %v", d.file, err)
+		}
+		f(p)
+	}
+}
+
+// seriesKey returns the measurement and tags of p, sorted by key, as some
+// formats do not keep their order.
+func seriesKey(p *common.Point) string {
+	tags := make([]string, len(p.TagKeys))
+	for i := range p.TagKeys {
+		tags[i] = string(p.TagKeys[i]) + "=" + string(p.TagValues[i])
+	}
+	sort.Strings(tags)
+	return strings.Join(append([]string{string(p.MeasurementName)}, tags...), ",")
+}
+
+// timestamp returns the timestamp of p, truncated to the precision of the
+// comparison, counting the truncations done for dataset d.
+func (c *comparison) timestamp(d *dataset, p *common.Point) int64 {
+	ns := p.Timestamp.UnixNano()
+	if rest := ns % int64(c.unit); rest != 0 {
+		c.truncated[d.name]++
+		ns -= rest
+	}
+	return ns
+}
+
+// load adds the values of a point of dataset a.
+func (c *comparison) load(p *common.Point) {
+	key := seriesKey(p)
+	s, ok := c.series[key]
+	if !ok {
+		s = &series{values: make(map[valueKey]interface{})}
+		c.series[key] = s
+	}
+	ts := c.timestamp(&a, p)
+	for i := range p.FieldKeys {
+		k := valueKey{field: string(p.FieldKeys[i]), timestamp: ts}
+		if _, ok := s.values[k]; ok {
+			c.duplicates++
+			continue
+		}
+		s.values[k] = p.FieldValues[i]
+	}
+}
+
+// compare checks the values of a point of dataset b against those of a.
+func (c *comparison) compare(p *common.Point) {
+	key := seriesKey(p)
+	s, ok := c.series[key]
+	if !ok {
+		c.missingSeries[key] += int64(len(p.FieldKeys))
+		return
+	}
+	s.seen = true
+	ts := c.timestamp(&b, p)
+	for i := range p.FieldKeys {
+		k := valueKey{field: string(p.FieldKeys[i]), timestamp: ts}
+		va, ok := s.values[k]
+		if !ok {
+			// either a duplicate of b, or a value missing from a:
+			c.missingValues[key]++
+			continue
+		}
+		delete(s.values, k)
+		c.compared++
+		c.compareValues(key, fieldKey{string(p.MeasurementName), k.field}, ts, va, p.FieldValues[i])
+	}
+}
+
+func (c *comparison) compareValues(key string, f fieldKey, ts int64, va, vb interface{}) {
+	ka, kb := valueKind(va), valueKind(vb)
+	mismatch := func() {
+		c.mismatches++
+		fmt.Printf("mismatch: %s %s at %s: %v (%s) in a, %v (%s) in b\n", key, f.field, formatTimestamp(ts), formatValue(va), ka, formatValue(vb), kb)
+	}
+	if ka == "string" || kb == "string" || ka == "boolean" || kb == "boolean" {
+		if ka != kb || formatValue(va) != formatValue(vb) {
+			mismatch()
+		}
+		return
+	}
+
+	if ka != kb {
+		c.typeChanges[typeChange{f, ka, kb}]++
+	} else if ka != "float" {
+		// integers of the same kind are exact:
+		if formatValue(va) != formatValue(vb) {
+			mismatch()
+		}
+		return
+	}
+	fa, fb := floatValue(va), floatValue(vb)
+	if fa == fb {
+		return
+	}
+	diff := math.Abs(fa-fb) / math.Max(math.Abs(fa), math.Abs(fb))
+	if diff > floatTolerance || math.IsNaN(diff) {
+		mismatch()
+		return
+	}
+	r, ok := c.roundings[f]
+	if !ok {
+		r = &rounding{}
+		c.roundings[f] = r
+	}
+	r.count++
+	r.maxDiff = math.Max(r.maxDiff, diff)
+}
+
+// report prints the differences other than mismatches, which are printed as
+// they are found, and returns whether the datasets match.
+func (c *comparison) report() bool {
+	var missingA, missingB int64
+	keys := make([]string, 0, len(c.series))
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := c.series[key]
+		if len(s.values) == 0 {
+			continue
+		}
+		missingB += int64(len(s.values))
+		if !s.seen {
+			fmt.Printf("missing series in b: %s (%d values)\n", key, len(s.values))
+			continue
+		}
+		var first valueKey
+		for k := range s.values {
+			if first.field == "" || k.timestamp < first.timestamp || (k.timestamp == first.timestamp && k.field < first.field) {
+				first = k
+			}
+		}
+		fmt.Printf("missing values in b: %s: %d values, the first %s at %s\n", key, len(s.values), first.field, formatTimestamp(first.timestamp))
+	}
+	for _, key := range sortedKeys(c.missingSeries) {
+		missingA += c.missingSeries[key]
+		fmt.Printf("missing series in a: %s (%d values)\n", key, c.missingSeries[key])
+	}
+	for _, key := range sortedKeys(c.missingValues) {
+		missingA += c.missingValues[key]
+		fmt.Printf("missing values in a: %s: %d values\n", key, c.missingValues[key])
+	}
+	if c.duplicates > 0 {
+		fmt.Printf("duplicate values in a: %d\n", c.duplicates)
+	}
+
+	for _, d := range []*dataset{&a, &b} {
+		if n := c.truncated[d.name]; n > 0 {
+			fmt.Printf("precision loss: %d timestamps of %s truncated to %v\n", n, d.name, c.unit)
+		}
+	}
+	changes := make([]typeChange, 0, len(c.typeChanges))
+	for change := range c.typeChanges {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return fmt.Sprint(changes[i]) < fmt.Sprint(changes[j])
+	})
+	for _, change := range changes {
+		fmt.Printf("precision loss: %s %s: %d %s values in a are %s values in b\n", change.measurement, change.field, c.typeChanges[change], change.a, change.b)
+	}
+	fields := make([]fieldKey, 0, len(c.roundings))
+	for f := range c.roundings {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].measurement+" "+fields[i].field < fields[j].measurement+" "+fields[j].field
+	})
+	for _, f := range fields {
+		r := c.roundings[f]
+		fmt.Printf("precision loss: %s %s: %d values rounded, by up to %g (relative)\n", f.measurement, f.field, r.count, r.maxDiff)
+	}
+
+	fmt.Printf("compared %d values: %d mismatches, %d missing in a, %d missing in b, %d duplicates in a\n", c.compared, c.mismatches, missingA, missingB, c.duplicates)
+	return c.mismatches == 0 && missingA == 0 && missingB == 0 && c.duplicates == 0
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// valueKind returns the kind of a field value: integer, unsigned, float,
+// boolean or string.
+func valueKind(v interface{}) string {
+	switch v.(type) {
+	case int, int64:
+		return "integer"
+	case uint, uint64:
+		return "unsigned"
+	case float32, float64:
+		return "float"
+	case bool:
+		return "boolean"
+	case []byte, string:
+		return "string"
+	default:
+		panic(fmt.Sprintf("unknown field type for %#v", v))
+	}
+}
+
+func floatValue(v interface{}) float64 {
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case float64:
+		return x
+	default:
+		panic(fmt.Sprintf("non-numeric field value %#v", v))
+	}
+}
+
+func formatValue(v interface{}) string {
+	switch x := v.(type) {
+	case []byte:
+		return fmt.Sprintf("%q", x)
+	case string:
+		return fmt.Sprintf("%q", x)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatTimestamp(ns int64) string {
+	return time.Unix(0, ns).UTC().Format(time.RFC3339Nano)
+}
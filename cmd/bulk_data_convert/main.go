@@ -0,0 +1,183 @@
+// bulk_data_convert converts a dataset generated by bulk_data_gen to another
+// format, by reading its points back and serializing them again. This way one
+// dataset can feed the loaders of all databases with the same points.
+//
+// Supported input formats:
+// InfluxDB bulk load format, of any timestamp precision (see -input-precision)
+// TSDB format
+// OpenTSDB bulk HTTP format (whose values are all floats)
+// Cassandra query format
+//
+// Supported output formats: those of bulk_data_gen, the csv and tsv tables
+// with the narrow layout only.
+//
+// The input may be gzip or zstd compressed.
+//
+// Float values are only converted exactly if written with all their digits:
+// generate the influx-bulk dataset to convert with -influx-float-digits -1.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+// Output data format choices:
+var formatChoices = []string{"influx-bulk", "es-bulk", "cassandra", "mongo", "opentsdb", "opentsdb-telnet", "graphite", "graphite-tagged", "timescaledb-sql", "timescaledb-copyFrom", "tsdb", "prometheus", "csv", "tsv"}
+
+// Program option vars:
+var (
+	inputFile      string
+	inputFormat    string
+	inputPrecision string
+
+	format        string
+	compression   string
+	influxOptions = common.DefaultInfluxOptions
+)
+
+// Parse args:
+func init() {
+	flag.StringVar(&inputFile, "input-file", "", "File to convert (default Stdin), possibly gzip or zstd compressed.")
+	flag.StringVar(&inputFormat, "input-format", common.ReadableFormatChoices[0], fmt.Sprintf("Format of the input. (choices: %s)", strings.Join(common.ReadableFormatChoices, ", ")))
+	flag.StringVar(&inputPrecision, "input-precision", "ns", fmt.Sprintf("Precision of the timestamps of influx-bulk input. (choices: %s)", strings.Join(common.InfluxPrecisionChoices, ", ")))
+
+	flag.StringVar(&format, "format", formatChoices[0], fmt.Sprintf("Format to emit. (choices: %s)", strings.Join(formatChoices, ", ")))
+	flag.StringVar(&compression, "compression", common.CompressionNone, fmt.Sprintf("Compression of the output. (choices: %s)", strings.Join(common.CompressionChoices, ", ")))
+
+	flag.StringVar(&influxOptions.Precision, "influx-precision", influxOptions.Precision, fmt.Sprintf("Precision of the influx-bulk timestamps emitted. (choices: %s)", strings.Join(common.InfluxPrecisionChoices, ", ")))
+	flag.IntVar(&influxOptions.FloatDigits, "influx-float-digits", influxOptions.FloatDigits, "Digits after the decimal point of influx-bulk float values emitted (-1 for the fewest digits representing them exactly).")
+	flag.BoolVar(&influxOptions.Unsigned, "influx-unsigned", influxOptions.Unsigned, "Write influx-bulk integer fields as unsigned integers.")
+
+	flag.Parse()
+
+	validFormat := false
+	for _, s := range formatChoices {
+		validFormat = validFormat || s == format
+	}
+	if !validFormat {
+		log.Fatal("invalid format specifier")
+	}
+	if err := influxOptions.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if influxOptions != common.DefaultInfluxOptions && format != "influx-bulk" {
+		log.Fatal("influx precision, float digits and unsigned integers only apply to the influx-bulk format")
+	}
+}
+
+func main() {
+	unit, err := common.InfluxPrecisionUnit(inputPrecision)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	in := os.Stdin
+	if inputFile != "" {
+		in, err = os.Open(inputFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer in.Close()
+	}
+	r, _, err := common.NewDecompressingReader(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+	points, err := common.NewPointReader(r, inputFormat, unit)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var serializer common.Serializer
+	switch format {
+	case "influx-bulk":
+		serializer = common.NewSerializerInfluxWithOptions(influxOptions)
+	case "es-bulk":
+		serializer = common.NewSerializerElastic()
+	case "cassandra":
+		serializer = common.NewSerializerCassandra()
+	case "mongo":
+		serializer = common.NewSerializerMongo()
+	case "opentsdb":
+		serializer = common.NewSerializerOpenTSDB()
+	case "opentsdb-telnet":
+		serializer = common.NewSerializerOpenTSDBTelnet(common.OpenTSDBTelnetDevopsDropped)
+	case "graphite":
+		serializer = common.NewSerializerGraphite(common.GraphiteDevopsPath)
+	case "graphite-tagged":
+		serializer = common.NewSerializerGraphiteTagged()
+	case "timescaledb-sql":
+		serializer = common.NewSerializerTimescaleSql()
+	case "timescaledb-copyFrom":
+		serializer = common.NewSerializerTimescaleBin()
+	case "tsdb":
+		serializer = common.NewSerializerTSDB()
+	case "prometheus":
+		serializer = common.NewSerializerPrometheus()
+	case "csv":
+		serializer = common.NewSerializerCSV(common.CSVLayoutNarrow, ',')
+	case "tsv":
+		serializer = common.NewSerializerCSV(common.CSVLayoutNarrow, '\t')
+	default:
+		panic("unreachable")
+	}
+
+	compressor, err := common.NewCompressingWriter(os.Stdout, compression)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := bufio.NewWriterSize(compressor, 4<<20)
+
+	t := time.Now()
+	n, values := int64(0), int64(0)
+	point := common.MakeUsablePoint()
+	for {
+		point.Reset()
+		err := points.ReadPoint(point)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Fatalf("%s: %v", inputName(), err)
+		}
+		if n == 0 {
+			if hs, ok := serializer.(common.HeaderSerializer); ok {
+				if err := hs.SerializeHeader(out, point); err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+		if err := serializer.SerializePoint(out, point); err != nil {
+			log.Fatal(err)
+		}
+		n++
+		values += int64(len(point.FieldValues))
+	}
+
+	if err := serializer.SerializeSize(out, n, values); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err := compressor.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Converted %d points, %d values, took %0f seconds\n", n, values, time.Now().Sub(t).Seconds())
+}
+
+func inputName() string {
+	if inputFile == "" {
+		return "stdin"
+	}
+	return inputFile
+}
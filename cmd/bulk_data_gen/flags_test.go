@@ -0,0 +1,10 @@
+package main
+
+import "testing"
+
+// The flags are parsed by the init function of main.go: the testing flags
+// must be registered before, when the package variables are initialized.
+var _ = func() bool {
+	testing.Init()
+	return true
+}()
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// liveForever is how far the end of a live generation without duration is.
+const liveForever = 100 * 365 * 24 * time.Hour
+
+// liveSimulationEnd returns the end of the simulation of entities sampled
+// every interval. In live mode, it is the end of the epoch running at the end
+// of the generation, for that epoch to be generated too: the simulators only
+// generate whole epochs.
+func liveSimulationEnd(end time.Time, interval time.Duration) time.Time {
+	if !live {
+		return end
+	}
+	d := end.Sub(timestampStart)
+	return timestampStart.Add((d + interval - 1) / interval * interval)
+}
+
+// livePacer releases points when the wall clock reaches their timestamp, and
+// keeps track of how late they are.
+type livePacer struct {
+	out            *output
+	reportInterval time.Duration
+	stop           chan os.Signal
+
+	// the timestamp of the latest points released:
+	released time.Time
+
+	nextReport time.Time
+	releases   int64
+	totalLag   time.Duration
+	maxLag     time.Duration
+	lastLag    time.Duration
+}
+
+func newLivePacer(out *output, reportInterval time.Duration) *livePacer {
+	l := &livePacer{
+		out:            out,
+		reportInterval: reportInterval,
+		stop:           make(chan os.Signal, 1),
+		nextReport:     time.Now().Add(reportInterval),
+	}
+	signal.Notify(l.stop, os.Interrupt, syscall.SIGTERM)
+	return l
+}
+
+// wait blocks until a point stamped t is due, flushing the points due
+// already before sleeping. It returns false once generation is told to stop.
+// Points stamped before the latest released ones (e.g. delayed points) are
+// due immediately.
+func (l *livePacer) wait(t time.Time) bool {
+	if !t.After(l.released) {
+		return !l.stopped()
+	}
+	if !l.sleepUntil(t) {
+		return false
+	}
+	l.released = t
+	l.account(time.Since(t))
+	return true
+}
+
+// waitEnd blocks until the end of the generation, once all the points are
+// released. It returns false if generation is told to stop before.
+func (l *livePacer) waitEnd(end time.Time) bool {
+	return l.sleepUntil(end)
+}
+
+// sleepUntil flushes the points released and sleeps until t, unless told to
+// stop, in which case it returns false.
+func (l *livePacer) sleepUntil(t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return !l.stopped()
+	}
+	if err := l.out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	timer := time.NewTimer(d)
+	select {
+	case <-timer.C:
+		return true
+	case <-l.stop:
+		timer.Stop()
+		return false
+	}
+}
+
+func (l *livePacer) stopped() bool {
+	select {
+	case <-l.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// account records the lag of a release behind its schedule.
+func (l *livePacer) account(lag time.Duration) {
+	l.releases++
+	l.totalLag += lag
+	l.lastLag = lag
+	if lag > l.maxLag {
+		l.maxLag = lag
+	}
+	if l.reportInterval > 0 && !time.Now().Before(l.nextReport) {
+		log.Printf("live: at %s, %v behind schedule (max %v)\n", l.released.Format(time.RFC3339), l.lastLag, l.maxLag)
+		l.nextReport = time.Now().Add(l.reportInterval)
+	}
+}
+
+// Report logs the lag over the whole run.
+func (l *livePacer) Report() {
+	signal.Stop(l.stop)
+	if l.releases == 0 {
+		return
+	}
+	log.Printf("live: released points of %d timestamps, behind schedule by %v on average (max %v)\n", l.releases, l.totalLag/time.Duration(l.releases), l.maxLag)
+}
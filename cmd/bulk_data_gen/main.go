@@ -6,31 +6,58 @@
 // Cassandra query format
 // Mongo custom format
 // OpenTSDB bulk HTTP format
+// OpenTSDB telnet put format
+// Graphite plaintext format (hierarchical or tagged)
+// CSV and TSV tables (wide or narrow)
+// Prometheus remote-write format
 //
 // Supported use cases:
 // Devops: scale_var is the number of hosts to simulate, with log messages
-//         every 10 seconds.
+//         every 10 seconds (see -sampling-interval and -measurement-intervals).
+// Kubernetes: scale_var is the number of cluster nodes to simulate, each
+//         running pods of random deployments, scraped every 10 seconds.
+// Custom: scale_var is the number of sources emitting the measurements
+//         declared by the -schema file.
+// Events: scale_var is the number of application instances to simulate,
+//         handling requests at random times and logging about them.
+// Replay: replays the points of an InfluxDB line protocol file, scale_var
+//         being the number of copies of its series (see -replay-tag).
 package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/custom"
 	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/dashboard"
 	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/devops"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/events"
 	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/iot"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/kubernetes"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/replay"
 	"log"
+	"math/rand"
 	"os"
+	"path/filepath"
+	rdebug "runtime/debug"
 	"strings"
 	"time"
 )
 
 // Output data format choices:
-var formatChoices = []string{"influx-bulk", "es-bulk", "cassandra", "mongo", "opentsdb", "timescaledb-sql", "timescaledb-copyFrom", "tsdb"}
+var formatChoices = []string{"influx-bulk", "es-bulk", "cassandra", "mongo", "opentsdb", "opentsdb-telnet", "graphite", "graphite-tagged", "timescaledb-sql", "timescaledb-copyFrom", "tsdb", "prometheus", "csv", "tsv"}
 
 // Use case choices:
-var useCaseChoices = []string{"devops", "iot", "dashboard"}
+var useCaseChoices = []string{"devops", "iot", "dashboard", "kubernetes", "custom", "events", "replay"}
+
+// Formats supporting the string-heavy points of the events use case:
+var eventsFormatChoices = []string{"influx-bulk", "es-bulk", "mongo", "timescaledb-copyFrom", "csv", "tsv"}
+
+// version of the generator, recorded in manifests. Set it at build time with
+// -ldflags "-X main.version=<version>", it defaults to the VCS revision.
+var version string
 
 // Program option vars:
 var (
@@ -40,6 +67,8 @@ var (
 	format  string
 	useCase string
 
+	schemaFile string
+
 	scaleVar       int64
 	scaleVarOffset int64
 
@@ -52,11 +81,43 @@ var (
 	interleavedGenerationGroupID uint
 	interleavedGenerationGroups  uint
 
+	workers int
+
+	samplingInterval        time.Duration
+	measurementIntervalsStr string
+	measurementIntervals    map[string]time.Duration
+
+	reorder common.ReorderConfig
+
+	outputCfg    outputConfig
+	manifestFile string
+
+	live               bool
+	liveDuration       time.Duration
+	liveReportInterval time.Duration
+
+	churnRate   float64
+	churnPeriod time.Duration
+
+	diurnal bool
+
+	incidentsStr    string
+	randomIncidents int
+	incidentsOutput string
+
 	seed  int64
 	debug int
 
 	outputFile      string
 	onlyOutputToCsv bool
+
+	csvLayout string
+
+	influxOptions = common.DefaultInfluxOptions
+
+	replayFile      string
+	replayPrecision string
+	replayTag       string
 )
 
 // Parse args:
@@ -64,6 +125,7 @@ func init() {
 	flag.StringVar(&format, "format", formatChoices[0], fmt.Sprintf("Format to emit. (choices: %s)", strings.Join(formatChoices, ", ")))
 
 	flag.StringVar(&useCase, "use-case", useCaseChoices[0], fmt.Sprintf("Use case to model. (choices: %s)", strings.Join(useCaseChoices, ", ")))
+	flag.StringVar(&schemaFile, "schema", "", "YAML file declaring the measurements of the custom use case.")
 	flag.Int64Var(&scaleVar, "scale-var", 1, "Scaling variable specific to the use case.")
 	flag.Int64Var(&scaleVarOffset, "scale-var-offset", 0, "Scaling variable offset specific to the use case.")
 
@@ -76,6 +138,48 @@ func init() {
 	flag.UintVar(&interleavedGenerationGroupID, "interleaved-generation-group-id", 0, "Group (0-indexed) to perform round-robin serialization within. Use this to scale up data generation to multiple processes.")
 	flag.UintVar(&interleavedGenerationGroups, "interleaved-generation-groups", 1, "The number of round-robin serialization groups. Use this to scale up data generation to multiple processes.")
 
+	flag.IntVar(&workers, "workers", 1, "Number of goroutines generating data in parallel. The hosts (or smart homes) are split between them, the output is the same as with one worker.")
+
+	flag.DurationVar(&samplingInterval, "sampling-interval", 0, "Time between two samples of the simulated entities (default, or 0, uses the use case default: 10s for devops and kubernetes, 60s for iot, set by the schema for custom).")
+	flag.StringVar(&measurementIntervalsStr, "measurement-intervals", "", "Comma-separated collection intervals of single measurements, e.g. 'disk=1m,diskio=1m'. Each must be a multiple of the sampling interval, the other measurements are collected every sampling interval.")
+
+	flag.Float64Var(&churnRate, "churn-rate", 0, "Fraction of the hosts replaced by new ones every churn period (devops only).")
+	flag.DurationVar(&churnPeriod, "churn-period", devops.DefaultChurnPeriod, "Host replacement period.")
+
+	flag.BoolVar(&diurnal, "diurnal", false, "Make the cpu and nginx measurements follow business hours, with daily and weekly cycles (devops only).")
+
+	flag.StringVar(&incidentsStr, "incidents", "", "Semicolon-separated incidents to inject (devops only), each as <kind>@<start>/<duration>=<host>+<host>..., e.g. 'cpu-spike@2018-01-01T01:00:00Z/15m=host_1+host_3'. Kinds: "+strings.Join(devops.IncidentKinds, ", ")+".")
+	flag.IntVar(&randomIncidents, "random-incidents", 0, "Number of incidents of random kinds, times and hosts to inject in addition (devops only).")
+	flag.StringVar(&incidentsOutput, "incidents-output", "", "File to write the injected incidents to, as JSON.")
+
+	flag.Float64Var(&reorder.DelayFraction, "delayed-fraction", 0, "Fraction of points delivered late, out of order (between 0 and 1).")
+	flag.DurationVar(&reorder.MaxLateness, "max-lateness", time.Minute, "Maximum lateness of delayed points.")
+	flag.Float64Var(&reorder.OutageProbability, "outage-probability", 0, "Probability, for each point, that its host (or smart home) goes offline and later delivers the points it buffered in a burst.")
+	flag.DurationVar(&reorder.MaxOutage, "max-outage", 30*time.Minute, "Maximum duration of a simulated outage.")
+
+	flag.StringVar(&outputCfg.Dir, "output-dir", "", "Directory to write the data to, as numbered shards listed by an index file, instead of Stdout.")
+	flag.StringVar(&outputCfg.Compression, "compression", common.CompressionNone, fmt.Sprintf("Compression of the output, done by compressing blocks in parallel. (choices: %s)", strings.Join(common.CompressionChoices, ", ")))
+	flag.StringVar(&outputCfg.SplitBy, "split-by", "", fmt.Sprintf("Split the output dir data in shards: every -shard-points points, every -shard-duration of point time, or the hosts over -shards shards, or a file per measurement. (choices: %s)", strings.Join(splitByChoices, ", ")))
+	flag.Int64Var(&outputCfg.ShardPoints, "shard-points", 1000000, "Number of points of a shard, when splitting by points.")
+	flag.DurationVar(&outputCfg.ShardDuration, "shard-duration", time.Hour, "Time span of the points of a shard, when splitting by time.")
+	flag.IntVar(&outputCfg.Shards, "shards", 8, "Number of shards, when splitting by host.")
+
+	flag.BoolVar(&live, "live", false, "Generate points in real time: timestamps start at the current time and points are written when the wall clock reaches them. Runs until interrupted or -live-duration elapses.")
+	flag.DurationVar(&liveDuration, "live-duration", 0, "Duration of live generation (default, or 0, runs until interrupted).")
+	flag.DurationVar(&liveReportInterval, "live-report-interval", 10*time.Second, "Interval between reports of how far live generation is behind schedule (0 disables them).")
+
+	flag.StringVar(&manifestFile, "manifest", "", "File to write the JSON manifest of the dataset to (default: "+common.ManifestFile+" in the output dir, if any).")
+
+	flag.StringVar(&csvLayout, "csv-layout", common.CSVLayoutWide, fmt.Sprintf("Layout of the csv and tsv formats: a table per measurement, with a column per tag and field, written to a file per measurement in the output dir, or a single table of series, field, timestamp and value. (choices: %s)", strings.Join(common.CSVLayoutChoices, ", ")))
+
+	flag.StringVar(&influxOptions.Precision, "influx-precision", influxOptions.Precision, fmt.Sprintf("Precision of the influx-bulk timestamps, to load with the same precision. (choices: %s)", strings.Join(common.InfluxPrecisionChoices, ", ")))
+	flag.IntVar(&influxOptions.FloatDigits, "influx-float-digits", influxOptions.FloatDigits, "Digits after the decimal point of influx-bulk float values (-1 for the fewest digits representing them exactly).")
+	flag.BoolVar(&influxOptions.Unsigned, "influx-unsigned", influxOptions.Unsigned, "Write influx-bulk integer fields as unsigned integers.")
+
+	flag.StringVar(&replayFile, "replay-file", "", "InfluxDB line protocol file, possibly gzip or zstd compressed, to replay (replay only). Timestamps are shifted to -timestamp-start, and points from -timestamp-end on are dropped, if given.")
+	flag.StringVar(&replayPrecision, "replay-precision", "ns", fmt.Sprintf("Precision of the timestamps of the replayed file. (choices: %s)", strings.Join(common.InfluxPrecisionChoices, ", ")))
+	flag.StringVar(&replayTag, "replay-tag", "", "Tag whose values get suffixed with _<n> to make -scale-var copies of the replayed series, n starting at -scale-var-offset, e.g. 'hostname'.")
+
 	flag.StringVar(&outputFile, "output-file", "", "CSV file path to output the data in addition to Stdout")
 	flag.BoolVar(&onlyOutputToCsv, "only-csv", false, "Indicates whether to output only to csv rather than csv and stdout")
 	flag.Parse()
@@ -84,6 +188,87 @@ func init() {
 		log.Fatal("incorrect interleaved groups configuration")
 	}
 
+	if workers < 1 {
+		log.Fatal("workers must be at least 1")
+	}
+	if workers > 1 && (interleavedGenerationGroups > 1 || outputFile != "") {
+		log.Fatal("parallel generation does not support interleaved generation groups and CSV output")
+	}
+	if format == "csv" || format == "tsv" {
+		outputCfg.Extension = "." + format
+		switch csvLayout {
+		case common.CSVLayoutWide:
+			if outputCfg.Dir == "" {
+				log.Fatal("the wide csv layout writes a file per measurement, it needs an output dir")
+			}
+			if outputCfg.SplitBy == "" {
+				outputCfg.SplitBy = splitByMeasurement
+			} else if outputCfg.SplitBy != splitByMeasurement {
+				log.Fatalf("the wide csv layout can only be split by %s", splitByMeasurement)
+			}
+		case common.CSVLayoutNarrow:
+		default:
+			log.Fatalf("invalid csv layout '%s' (choices: %s)", csvLayout, strings.Join(common.CSVLayoutChoices, ", "))
+		}
+	}
+	if err := outputCfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if err := influxOptions.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if influxOptions != common.DefaultInfluxOptions && format != "influx-bulk" {
+		log.Fatal("influx precision, float digits and unsigned integers only apply to the influx-bulk format")
+	}
+	if workers > 1 && outputCfg.SplitBy != "" {
+		log.Fatal("parallel generation does not support splitting the output")
+	}
+	if onlyOutputToCsv && (outputCfg.Dir != "" || manifestFile != "") {
+		log.Fatal("an output dir or manifest cannot be used when outputting only to csv")
+	}
+	if manifestFile == "" && outputCfg.Dir != "" {
+		manifestFile = filepath.Join(outputCfg.Dir, common.ManifestFile)
+	}
+	if err := reorder.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if workers > 1 && reorder.Enabled() {
+		log.Fatal("parallel generation does not support delayed points and outages")
+	}
+
+	var err error
+	measurementIntervals, err = parseMeasurementIntervals(measurementIntervalsStr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if (samplingInterval != 0 || len(measurementIntervals) > 0) && useCase == useCaseChoices[2] {
+		log.Fatal("the dashboard use case does not support custom sampling intervals")
+	}
+	if (samplingInterval != 0 || len(measurementIntervals) > 0) && useCase == useCaseChoices[5] {
+		log.Fatal("the events use case does not support sampling intervals")
+	}
+	if (schemaFile != "") != (useCase == useCaseChoices[4]) {
+		log.Fatal("a schema must be given for, and only for, the custom use case")
+	}
+	if churnRate != 0 && useCase != useCaseChoices[0] {
+		log.Fatal("host churn is only supported by the devops use case")
+	}
+	if diurnal && useCase != useCaseChoices[0] {
+		log.Fatal("diurnal patterns are only supported by the devops use case")
+	}
+	if (incidentsStr != "" || randomIncidents != 0) && useCase != useCaseChoices[0] {
+		log.Fatal("incidents are only supported by the devops use case")
+	}
+	if randomIncidents < 0 {
+		log.Fatal("random incidents must not be negative")
+	}
+	if (replayFile != "" || replayTag != "") != (useCase == useCaseChoices[6]) {
+		log.Fatal("a file to replay must be given for, and only for, the replay use case")
+	}
+	if useCase == useCaseChoices[6] && workers > 1 {
+		log.Fatal("the replay use case does not support parallel generation")
+	}
+
 	validFormat := false
 	for _, s := range formatChoices {
 		if s == format {
@@ -94,6 +279,15 @@ func init() {
 	if !validFormat {
 		log.Fatal("invalid format specifier")
 	}
+	if useCase == useCaseChoices[5] {
+		validFormat = false
+		for _, s := range eventsFormatChoices {
+			validFormat = validFormat || s == format
+		}
+		if !validFormat {
+			log.Fatalf("the events use case only supports the formats: %s", strings.Join(eventsFormatChoices, ", "))
+		}
+	}
 
 	// the default seed is the current timestamp:
 	if seed == 0 {
@@ -102,7 +296,6 @@ func init() {
 	fmt.Fprintf(os.Stderr, "using random seed %d\n", seed)
 
 	// Parse timestamps:
-	var err error
 	timestampStart, err = time.Parse(time.RFC3339, timestampStartStr)
 	if err != nil {
 		log.Fatal(err)
@@ -113,12 +306,31 @@ func init() {
 		log.Fatal(err)
 	}
 	timestampEnd = timestampEnd.UTC()
+
+	if live {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "timestamp-start" || f.Name == "timestamp-end" {
+				log.Fatal("timestamps follow the wall clock in live mode")
+			}
+		})
+		if workers > 1 || outputCfg.Compression != common.CompressionNone {
+			log.Fatal("live generation does not support parallel generation and compression")
+		}
+		if liveDuration < 0 {
+			log.Fatal("live duration must not be negative")
+		}
+		// start at the next second, for the first points to be on time:
+		timestampStart = time.Now().UTC().Truncate(time.Second).Add(time.Second)
+		if liveDuration == 0 {
+			timestampEnd = timestampStart.Add(liveForever)
+		} else {
+			timestampEnd = timestampStart.Add(liveDuration)
+		}
+	}
 }
 
 func main() {
-	common.Seed(seed)
-
-	out := bufio.NewWriterSize(os.Stdout, 4<<20)
+	rnd := rand.New(rand.NewSource(seed))
 
 	var csvWriter *bufio.Writer
 
@@ -136,46 +348,250 @@ func main() {
 		defer f.Close()
 	}
 
-	defer out.Flush()
-
 	var sim common.Simulator
+	var slices []common.SlicedSimulator
+	var replaySim *replay.ReplaySimulator
+	// the settings of the simulation, the rest of the manifest is filled in
+	// once the dataset is written:
+	manifest := &common.Manifest{}
 
 	switch useCase {
 	case useCaseChoices[0]:
+		reorder.SourceTag = devops.MachineTagKeys[0]
 		cfg := &devops.DevopsSimulatorConfig{
 			Start: timestampStart,
 			End:   timestampEnd,
 
 			HostCount:  scaleVar,
 			HostOffset: scaleVarOffset,
+
+			SamplingInterval:     samplingInterval,
+			MeasurementIntervals: measurementIntervals,
+
+			ChurnRate:   churnRate,
+			ChurnPeriod: churnPeriod,
+
+			Diurnal: diurnal,
+
+			Rand: rnd,
+		}
+		cfg.End = liveSimulationEnd(cfg.End, cfg.EffectiveSamplingInterval())
+		if err := cfg.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		incidents, err := devops.ParseIncidents(incidentsStr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if randomIncidents > 0 {
+			r := rand.New(rand.NewSource(rnd.Int63()))
+			incidents = append(incidents, devops.RandomIncidents(r, randomIncidents, timestampStart, timestampEnd, cfg.HostChurn())...)
+		}
+		if incidentsOutput != "" {
+			writeIncidents(incidentsOutput, incidents)
+		}
+		manifest.SamplingInterval = common.Duration(cfg.EffectiveSamplingInterval())
+		if churn := cfg.HostChurn(); churn.Enabled() {
+			manifest.ChurnRate, manifest.ChurnPeriod = churn.Rate, common.Duration(churn.Period)
+		}
+		manifest.Diurnal = diurnal
+		if len(incidents) > 0 {
+			manifest.Incidents, err = json.Marshal(incidents)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		if workers > 1 {
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				if len(incidents) > 0 {
+					slices = append(slices, devops.NewIncidentSimulator(s, incidents))
+				} else {
+					slices = append(slices, s)
+				}
+			}
+		} else if len(incidents) > 0 {
+			sim = devops.NewIncidentSimulator(cfg.ToSimulator(), incidents)
+		} else {
+			sim = cfg.ToSimulator()
 		}
-		sim = cfg.ToSimulator()
 	case useCaseChoices[2]:
+		reorder.SourceTag = devops.MachineTagKeys[0]
 		cfg := &dashboard.DashboardSimulatorConfig{
 			Start: timestampStart,
 			End:   timestampEnd,
 
 			HostCount:  scaleVar,
 			HostOffset: scaleVarOffset,
+
+			Rand: rnd,
+		}
+		cfg.End = liveSimulationEnd(cfg.End, devops.EpochDuration)
+		if workers > 1 {
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+		} else {
+			sim = cfg.ToSimulator()
 		}
-		sim = cfg.ToSimulator()
 	case useCaseChoices[1]:
+		reorder.SourceTag = iot.SensorHomeTagKeys[1]
 		cfg := &iot.IotSimulatorConfig{
 			Start: timestampStart,
 			End:   timestampEnd,
 
 			SmartHomeCount:  scaleVar,
 			SmartHomeOffset: scaleVarOffset,
+
+			SamplingInterval:     samplingInterval,
+			MeasurementIntervals: measurementIntervals,
+
+			Rand: rnd,
+		}
+		cfg.End = liveSimulationEnd(cfg.End, cfg.EffectiveSamplingInterval())
+		if err := cfg.Validate(); err != nil {
+			log.Fatal(err)
 		}
-		sim = cfg.ToSimulator()
+		manifest.SamplingInterval = common.Duration(cfg.EffectiveSamplingInterval())
+		if workers > 1 {
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+		} else {
+			sim = cfg.ToSimulator()
+		}
+	case useCaseChoices[3]:
+		reorder.SourceTag = kubernetes.NodeTagKeys[0]
+		cfg := &kubernetes.KubernetesSimulatorConfig{
+			Start: timestampStart,
+			End:   timestampEnd,
+
+			NodeCount:  scaleVar,
+			NodeOffset: scaleVarOffset,
+
+			SamplingInterval:     samplingInterval,
+			MeasurementIntervals: measurementIntervals,
+
+			Rand: rnd,
+		}
+		cfg.End = liveSimulationEnd(cfg.End, cfg.EffectiveSamplingInterval())
+		if err := cfg.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		manifest.SamplingInterval = common.Duration(cfg.EffectiveSamplingInterval())
+		if workers > 1 {
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+		} else {
+			sim = cfg.ToSimulator()
+		}
+	case useCaseChoices[4]:
+		schema, err := custom.LoadSchema(schemaFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(schema.Tags) > 0 {
+			reorder.SourceTag = []byte(schema.Tags[0].Key)
+		}
+		cfg := &custom.CustomSimulatorConfig{
+			Start: timestampStart,
+			End:   timestampEnd,
+
+			SourceCount:  scaleVar,
+			SourceOffset: scaleVarOffset,
+
+			Schema: schema,
+
+			SamplingInterval:     samplingInterval,
+			MeasurementIntervals: measurementIntervals,
+
+			Rand: rnd,
+		}
+		cfg.End = liveSimulationEnd(cfg.End, cfg.EffectiveSamplingInterval())
+		if err := cfg.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		manifest.SamplingInterval = common.Duration(cfg.EffectiveSamplingInterval())
+		if workers > 1 {
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+		} else {
+			sim = cfg.ToSimulator()
+		}
+	case useCaseChoices[5]:
+		reorder.SourceTag = events.InstanceTagKeys[1]
+		cfg := &events.EventsSimulatorConfig{
+			Start: timestampStart,
+			End:   timestampEnd,
+
+			InstanceCount:  scaleVar,
+			InstanceOffset: scaleVarOffset,
+
+			Rand: rnd,
+		}
+		if workers > 1 {
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+		} else {
+			sim = cfg.ToSimulator()
+		}
+	case useCaseChoices[6]:
+		if replayTag != "" {
+			reorder.SourceTag = []byte(replayTag)
+		}
+		precision, err := common.InfluxPrecisionUnit(replayPrecision)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg := &replay.ReplaySimulatorConfig{
+			File:      replayFile,
+			Precision: precision,
+
+			Copies:     scaleVar,
+			CopyOffset: scaleVarOffset,
+			CopyTag:    []byte(replayTag),
+		}
+		// timestamps are only shifted or bounded on demand:
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "timestamp-start":
+				cfg.Start = timestampStart
+			case "timestamp-end":
+				cfg.End = timestampEnd
+			}
+		})
+		if live {
+			cfg.Start, cfg.End = timestampStart, timestampEnd
+		}
+		if err := cfg.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		replaySim, err = cfg.ToSimulator()
+		if err != nil {
+			log.Fatal(err)
+		}
+		sim = replaySim
 	default:
 		panic("unreachable")
 	}
 
+	if len(measurementIntervals) > 0 {
+		manifest.MeasurementIntervals = make(map[string]common.Duration, len(measurementIntervals))
+		for name, interval := range measurementIntervals {
+			manifest.MeasurementIntervals[name] = common.Duration(interval)
+		}
+	}
+
+	if reorder.Enabled() {
+		sim = common.NewReorderingSimulator(sim, reorder, rand.New(rand.NewSource(rnd.Int63())))
+	}
+
 	var serializer common.Serializer
 	switch format {
 	case "influx-bulk":
-		serializer = common.NewSerializerInflux()
+		serializer = common.NewSerializerInfluxWithOptions(influxOptions)
 	case "es-bulk":
 		serializer = common.NewSerializerElastic()
 	case "cassandra":
@@ -184,16 +600,67 @@ func main() {
 		serializer = common.NewSerializerMongo()
 	case "opentsdb":
 		serializer = common.NewSerializerOpenTSDB()
+	case "opentsdb-telnet":
+		serializer = common.NewSerializerOpenTSDBTelnet(common.OpenTSDBTelnetDevopsDropped)
+	case "graphite":
+		serializer = common.NewSerializerGraphite(common.GraphiteDevopsPath)
+	case "graphite-tagged":
+		serializer = common.NewSerializerGraphiteTagged()
 	case "timescaledb-sql":
 		serializer = common.NewSerializerTimescaleSql()
 	case "timescaledb-copyFrom":
 		serializer = common.NewSerializerTimescaleBin()
 	case "tsdb":
 		serializer = common.NewSerializerTSDB()
+	case "prometheus":
+		serializer = common.NewSerializerPrometheus()
+	case "csv":
+		serializer = common.NewSerializerCSV(csvLayout, ',')
+	case "tsv":
+		serializer = common.NewSerializerCSV(csvLayout, '\t')
 	default:
 		panic("unreachable")
 	}
 
+	outputCfg.SourceTag = reorder.SourceTag
+	if outputCfg.SplitBy == splitByHost && outputCfg.SourceTag == nil {
+		log.Fatal("the use case has no host tag to split the output by")
+	}
+	out, err := newOutput(outputCfg, serializer, format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if workers > 1 {
+		t := time.Now()
+		stats, err := generateParallel(out, slices, serializer)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out.AddStats(stats)
+		var points, values int64
+		for _, s := range slices {
+			points += s.SeenPoints()
+			values += s.SeenValues()
+		}
+		err = out.Close(points, values)
+		dur := time.Now().Sub(t)
+		log.Printf("Written %d points, %d values, took %0f seconds\n", points, values, dur.Seconds())
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		if manifestFile != "" {
+			writeManifest(manifestFile, manifest, out)
+		}
+		return
+	}
+
+	var pacer *livePacer
+	if live {
+		pacer = newLivePacer(out, liveReportInterval)
+	}
+	stopped := false
+
 	var currentInterleavedGroup uint = 0
 
 	t := time.Now()
@@ -201,12 +668,18 @@ func main() {
 	n := int64(0)
 	for !sim.Finished() {
 		sim.Next(point)
+		if pacer != nil && !pacer.wait(*point.Timestamp) {
+			// the point is not due yet, the dataset ends before it:
+			stopped = true
+			timestampEnd = *point.Timestamp
+			break
+		}
 		n++
 		// in the default case this is always true
 		if currentInterleavedGroup == interleavedGenerationGroupID {
 			//println("printing")
 			if !onlyOutputToCsv {
-				err := serializer.SerializePoint(out, point)
+				err := out.WritePoint(point)
 				if err != nil {
 					log.Fatal(err)
 				}
@@ -225,14 +698,116 @@ func main() {
 			currentInterleavedGroup = 0
 		}
 	}
-	if n != sim.SeenPoints() {
-		panic(fmt.Sprintf("Logic error, written %d points, generated %d points", n, sim.SeenPoints()))
+	if pacer != nil && !stopped && liveDuration > 0 && !pacer.waitEnd(timestampEnd) {
+		// all the points are written, the dataset ends now:
+		timestampEnd = time.Now().UTC()
+	}
+	if replaySim != nil {
+		if err := replaySim.Err(); err != nil {
+			log.Fatal(err)
+		}
+		if first, last := replaySim.TimeRange(); !first.IsZero() && !stopped {
+			timestampStart, timestampEnd = first, last.Add(time.Nanosecond)
+		}
+	}
+	points, values := sim.SeenPoints(), sim.SeenValues()
+	if stopped {
+		points--
+		values -= int64(len(point.FieldValues))
 	}
-	serializer.SerializeSize(out, sim.SeenPoints(), sim.SeenValues())
-	err := out.Flush()
+	if n != points {
+		panic(fmt.Sprintf("Logic error, written %d points, generated %d points", n, points))
+	}
+	err = out.Close(points, values)
 	dur := time.Now().Sub(t)
-	log.Printf("Written %d points, %d values, took %0f seconds\n", n, sim.SeenValues(), dur.Seconds())
+	log.Printf("Written %d points, %d values, took %0f seconds\n", n, values, dur.Seconds())
 	if err != nil {
 		log.Fatal(err.Error())
 	}
+	if pacer != nil {
+		pacer.Report()
+	}
+	if manifestFile != "" {
+		writeManifest(manifestFile, manifest, out)
+	}
+}
+
+// parseMeasurementIntervals parses a comma-separated list of
+// <measurement>=<duration> pairs.
+func parseMeasurementIntervals(s string) (map[string]time.Duration, error) {
+	intervals := make(map[string]time.Duration)
+	if s == "" {
+		return intervals, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid measurement interval '%s', expected <measurement>=<duration>", pair)
+		}
+		d, err := time.ParseDuration(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval of measurement '%s': %v", kv[0], err)
+		}
+		intervals[kv[0]] = d
+	}
+	return intervals, nil
+}
+
+// writeIncidents writes the ground truth of the injected incidents to a file.
+func writeIncidents(path string, incidents []devops.Incident) {
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if err := devops.WriteIncidents(f, incidents); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeManifest completes the manifest m, holding the settings of the
+// simulation, with the dataset written to out, and writes it.
+func writeManifest(path string, m *common.Manifest, out *output) {
+	m.GeneratorVersion = generatorVersion()
+	m.Seed = seed
+	m.UseCase = useCase
+	m.ScaleVar, m.ScaleVarOffset = scaleVar, scaleVarOffset
+	m.Start, m.End = timestampStart, timestampEnd
+	m.Format = format
+	if format == "influx-bulk" {
+		m.Precision = influxOptions.Precision
+	}
+	out.FillManifest(m)
+	if err := common.WriteManifest(path, m); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generatorVersion() string {
+	if version != "" {
+		return version
+	}
+	info, ok := rdebug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	revision, modified := "", false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	switch {
+	case revision != "" && modified:
+		return revision + "-dirty"
+	case revision != "":
+		return revision
+	case info.Main.Version != "" && info.Main.Version != "(devel)":
+		return info.Main.Version
+	default:
+		return "unknown"
+	}
 }
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+// Ways of splitting the output in shards:
+const (
+	splitByPoints      = "points"
+	splitByTime        = "time"
+	splitByHost        = "host"
+	splitByMeasurement = "measurement"
+)
+
+var splitByChoices = []string{splitByPoints, splitByTime, splitByHost, splitByMeasurement}
+
+// outputConfig configures where the serialized points go: stdout, or
+// numbered shards in Dir.
+type outputConfig struct {
+	Dir         string
+	Compression string
+
+	// SplitBy spreads the points over several shards: a new shard starts
+	// every ShardPoints points, or every ShardDuration of point time, or
+	// the points of each host go to one of Shards shards, or each
+	// measurement has its own shard, named after it.
+	SplitBy       string
+	ShardPoints   int64
+	ShardDuration time.Duration
+	Shards        int
+
+	// SourceTag is the key of the tag identifying hosts.
+	SourceTag []byte
+
+	// Extension is the file name extension of the format, if any.
+	Extension string
+}
+
+func (c *outputConfig) Validate() error {
+	valid := false
+	for _, choice := range common.CompressionChoices {
+		valid = valid || c.Compression == choice
+	}
+	if !valid {
+		return fmt.Errorf("invalid compression '%s' (choices: %s)", c.Compression, strings.Join(common.CompressionChoices, ", "))
+	}
+	switch c.SplitBy {
+	case "":
+		return nil
+	case splitByPoints:
+		if c.ShardPoints <= 0 {
+			return fmt.Errorf("shard points must be positive, got %d", c.ShardPoints)
+		}
+	case splitByTime:
+		if c.ShardDuration <= 0 {
+			return fmt.Errorf("shard duration must be positive, got %v", c.ShardDuration)
+		}
+	case splitByHost:
+		if c.Shards <= 0 {
+			return fmt.Errorf("shards must be positive, got %d", c.Shards)
+		}
+	case splitByMeasurement:
+	default:
+		return fmt.Errorf("invalid split '%s' (choices: %s)", c.SplitBy, strings.Join(splitByChoices, ", "))
+	}
+	if c.Dir == "" {
+		return fmt.Errorf("splitting the output needs an output dir")
+	}
+	return nil
+}
+
+// shard is an output file (or stdout).
+type shard struct {
+	info       common.Shard
+	file       *os.File
+	compressor io.WriteCloser
+	w          *bufio.Writer
+
+	// with split by time, the shard takes the points before end:
+	end time.Time
+
+	// whether the header of the format was written:
+	headed bool
+}
+
+func newShard(f *os.File, compression string) (*shard, error) {
+	compressor, err := common.NewCompressingWriter(f, compression)
+	if err != nil {
+		return nil, err
+	}
+	return &shard{
+		file:       f,
+		compressor: compressor,
+		w:          bufio.NewWriterSize(compressor, 4<<20),
+	}, nil
+}
+
+// close terminates the shard with its dataset size marker.
+func (s *shard) close(serializer common.Serializer) error {
+	if err := serializer.SerializeSize(s.w, s.info.Points, s.info.Values); err != nil {
+		return err
+	}
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	if err := s.compressor.Close(); err != nil {
+		return err
+	}
+	if s.file == os.Stdout {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// output writes the serialized points, routing them to their shard.
+type output struct {
+	cfg        outputConfig
+	serializer common.Serializer
+	format     string
+
+	all     []*shard // in order
+	shards  []*shard // open shards
+	current *shard   // shard of the last point
+
+	// with split by measurement, the shard of each measurement:
+	measurementShards map[string]*shard
+
+	// for the manifest:
+	stats    *common.DatasetStats
+	checksum hash.Hash
+	buf      bytes.Buffer
+}
+
+func newOutput(cfg outputConfig, serializer common.Serializer, format string) (*output, error) {
+	o := &output{
+		cfg:        cfg,
+		serializer: serializer,
+		format:     format,
+		stats:      common.NewDatasetStats(),
+		checksum:   sha256.New(),
+	}
+	if cfg.Dir == "" {
+		s, err := newShard(os.Stdout, cfg.Compression)
+		if err != nil {
+			return nil, err
+		}
+		o.shards = []*shard{s}
+		o.current = s
+		return o, nil
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, err
+	}
+	if cfg.SplitBy == splitByMeasurement {
+		// shards are created as measurements show up:
+		o.measurementShards = make(map[string]*shard)
+		return o, nil
+	}
+	n := 1
+	if cfg.SplitBy == splitByHost {
+		n = cfg.Shards
+	}
+	for i := 0; i < n; i++ {
+		if _, err := o.newShard(fmt.Sprintf("data-%05d", len(o.all))); err != nil {
+			return nil, err
+		}
+	}
+	o.current = o.shards[0]
+	return o, nil
+}
+
+// newShard creates a shard file with the given base name.
+func (o *output) newShard(base string) (*shard, error) {
+	name := base + o.cfg.Extension + common.CompressionExtension(o.cfg.Compression)
+	f, err := os.Create(filepath.Join(o.cfg.Dir, name))
+	if err != nil {
+		return nil, err
+	}
+	s, err := newShard(f, o.cfg.Compression)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.info.File = name
+	o.all = append(o.all, s)
+	o.shards = append(o.shards, s)
+	return s, nil
+}
+
+// rotate closes the current shard and starts the next one.
+func (o *output) rotate() error {
+	if err := o.current.close(o.serializer); err != nil {
+		return err
+	}
+	o.shards = o.shards[:0]
+	s, err := o.newShard(fmt.Sprintf("data-%05d", len(o.all)))
+	if err != nil {
+		return err
+	}
+	o.current = s
+	return nil
+}
+
+// WritePoint serializes a point to its shard.
+func (o *output) WritePoint(p *common.Point) error {
+	switch o.cfg.SplitBy {
+	case splitByPoints:
+		if o.current.info.Points == o.cfg.ShardPoints {
+			if err := o.rotate(); err != nil {
+				return err
+			}
+		}
+	case splitByTime:
+		// delayed points stay in the current shard:
+		if !p.Timestamp.Before(o.current.end) {
+			if o.current.info.Points > 0 {
+				if err := o.rotate(); err != nil {
+					return err
+				}
+			}
+			o.current.end = p.Timestamp.Truncate(o.cfg.ShardDuration).Add(o.cfg.ShardDuration)
+		}
+	case splitByHost:
+		h := fnv.New32a()
+		for i, key := range p.TagKeys {
+			if string(key) == string(o.cfg.SourceTag) {
+				h.Write(p.TagValues[i])
+				break
+			}
+		}
+		o.current = o.shards[h.Sum32()%uint32(len(o.shards))]
+	case splitByMeasurement:
+		s, ok := o.measurementShards[string(p.MeasurementName)]
+		if !ok {
+			var err error
+			s, err = o.newShard(filepath.Base(string(p.MeasurementName)))
+			if err != nil {
+				return err
+			}
+			o.measurementShards[string(p.MeasurementName)] = s
+		}
+		o.current = s
+	}
+	if err := o.writeHeader(p); err != nil {
+		return err
+	}
+	o.current.info.Add(p)
+	o.stats.Add(p)
+
+	o.buf.Reset()
+	if err := o.serializer.SerializePoint(&o.buf, p); err != nil {
+		return err
+	}
+	o.checksum.Write(o.buf.Bytes())
+	_, err := o.current.w.Write(o.buf.Bytes())
+	return err
+}
+
+// Write writes points serialized already, which can only go to a single
+// shard. Their stats must be given to AddStats.
+func (o *output) Write(b []byte) (int, error) {
+	if o.cfg.SplitBy != "" {
+		panic("logic error: cannot split serialized points")
+	}
+	if err := o.writeHeader(nil); err != nil {
+		return 0, err
+	}
+	o.checksum.Write(b)
+	return o.current.w.Write(b)
+}
+
+// writeHeader starts the current shard with the header of the format, if
+// it has one and the shard has none yet. Headers are not part of the
+// checksum, as they depend on how the output is split.
+func (o *output) writeHeader(first *common.Point) error {
+	hs, ok := o.serializer.(common.HeaderSerializer)
+	if !ok || o.current.headed {
+		return nil
+	}
+	o.current.headed = true
+	return hs.SerializeHeader(o.current.w, first)
+}
+
+// AddStats accounts for points given to Write.
+func (o *output) AddStats(stats *common.DatasetStats) {
+	o.stats.Merge(stats)
+}
+
+// FillManifest sets the counts and checksum of the points written.
+func (o *output) FillManifest(m *common.Manifest) {
+	o.stats.Fill(m)
+	m.Checksum = "sha256:" + hex.EncodeToString(o.checksum.Sum(nil))
+}
+
+// Flush writes out the points buffered by the open shards.
+func (o *output) Flush() error {
+	for _, s := range o.shards {
+		if err := s.w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close terminates the shards and writes their index. Without split, the
+// size marker of the single shard reports the given points and values, the
+// totals of the dataset.
+func (o *output) Close(points, values int64) error {
+	if o.cfg.SplitBy == "" {
+		o.current.info.Points = points
+		o.current.info.Values = values
+	}
+	for _, s := range o.shards {
+		if err := s.close(o.serializer); err != nil {
+			return err
+		}
+	}
+	if o.cfg.Dir == "" {
+		return nil
+	}
+	index := &common.ShardIndex{
+		Format:      o.format,
+		Compression: o.cfg.Compression,
+		SplitBy:     o.cfg.SplitBy,
+	}
+	for _, s := range o.all {
+		index.Shards = append(index.Shards, s.info)
+	}
+	return common.WriteShardIndex(o.cfg.Dir, index)
+}
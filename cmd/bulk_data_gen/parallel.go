@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+)
+
+// A slice worker hands its output to the merger once it has serialized at
+// least this many bytes (rounding up to the end of a round):
+const sliceFlushSize = 1 << 20
+
+// serializedRounds holds the serialized points of consecutive rounds of a
+// simulator slice.
+type serializedRounds struct {
+	buf  bytes.Buffer
+	ends []int // end offset in buf of each round
+}
+
+var serializedRoundsPool = sync.Pool{
+	New: func() interface{} {
+		return &serializedRounds{}
+	},
+}
+
+func (r *serializedRounds) closeRound() {
+	r.ends = append(r.ends, r.buf.Len())
+}
+
+func (r *serializedRounds) round(i int) []byte {
+	start := 0
+	if i > 0 {
+		start = r.ends[i-1]
+	}
+	return r.buf.Bytes()[start:r.ends[i]]
+}
+
+func (r *serializedRounds) release() {
+	r.buf.Reset()
+	r.ends = r.ends[:0]
+	serializedRoundsPool.Put(r)
+}
+
+// generateParallel runs every simulator slice in its own goroutine,
+// serializing points in parallel, and writes the output to w in the order a
+// single simulator of the whole use case would have produced it. It returns
+// the stats of the points written.
+func generateParallel(w io.Writer, sims []common.SlicedSimulator, serializer common.Serializer) (*common.DatasetStats, error) {
+	stats := common.NewDatasetStats()
+	if len(sims) == 0 {
+		return stats, nil
+	}
+
+	outputs := make([]chan *serializedRounds, len(sims))
+	sliceStats := make([]*common.DatasetStats, len(sims))
+	for i := range sims {
+		outputs[i] = make(chan *serializedRounds, 16)
+		sliceStats[i] = common.NewDatasetStats()
+		go runSlice(sims[i], serializer, outputs[i], sliceStats[i])
+	}
+
+	// Every slice goes through the same rounds; merge them round by round:
+	current := make([]*serializedRounds, len(sims))
+	next := make([]int, len(sims))
+	for round := int64(0); round < sims[0].Rounds(); round++ {
+		for i := range sims {
+			if current[i] == nil || next[i] == len(current[i].ends) {
+				if current[i] != nil {
+					current[i].release()
+				}
+				current[i] = <-outputs[i]
+				next[i] = 0
+			}
+			_, err := w.Write(current[i].round(next[i]))
+			if err != nil {
+				return nil, err
+			}
+			next[i]++
+		}
+	}
+
+	for i := range sims {
+		if current[i] != nil {
+			current[i].release()
+		}
+		// wait for the worker to finish:
+		for range outputs[i] {
+		}
+		stats.Merge(sliceStats[i])
+	}
+	return stats, nil
+}
+
+// runSlice generates and serializes all points of a simulator slice, sending
+// them to out in chunks of whole rounds, and counting them in stats.
+func runSlice(sim common.SlicedSimulator, serializer common.Serializer, out chan<- *serializedRounds, stats *common.DatasetStats) {
+	chunk := serializedRoundsPool.Get().(*serializedRounds)
+	point := common.MakeUsablePoint()
+	round := int64(0)
+	n := int64(0)
+	for !sim.Finished() {
+		sim.Next(point)
+		n++
+		// close the finished rounds, including the ones this slice had no points for:
+		for round < sim.Round() {
+			chunk.closeRound()
+			round++
+			if chunk.buf.Len() >= sliceFlushSize {
+				out <- chunk
+				chunk = serializedRoundsPool.Get().(*serializedRounds)
+			}
+		}
+		err := serializer.SerializePoint(&chunk.buf, point)
+		if err != nil {
+			log.Fatal(err)
+		}
+		stats.Add(point)
+		point.Reset()
+	}
+	for ; round < sim.Rounds(); round++ {
+		chunk.closeRound()
+	}
+	if n != sim.SeenPoints() {
+		panic(fmt.Sprintf("Logic error, written %d points, generated %d points", n, sim.SeenPoints()))
+	}
+	if len(chunk.ends) > 0 {
+		out <- chunk
+	}
+	close(out)
+}
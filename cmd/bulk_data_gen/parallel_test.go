@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/custom"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/dashboard"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/devops"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/events"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/iot"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/kubernetes"
+)
+
+const testSchema = `
+sampling_interval: 10s
+tags:
+  - key: hostname
+  - key: region
+    values: [us-east-1, us-west-1, eu-central-1]
+measurements:
+  - name: temperature
+    interval: 1m
+    tags:
+      - key: sensor
+        cardinality: 4
+    fields:
+      - key: celsius
+        distribution: {type: cwd, min: -20, max: 45, state: 20, step: {type: nd, mean: 0, stddev: 0.5}}
+      - key: errors
+        type: int
+        distribution: {type: mwd, step: {type: ud, low: 0, high: 2}}
+`
+
+// useCaseSimulators returns the simulator of a use case with one worker, and
+// its slices with more.
+type useCaseSimulators func(workers int) (common.Simulator, []common.SlicedSimulator)
+
+func testUseCases(t *testing.T) map[string]useCaseSimulators {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	schemaFile := filepath.Join(t.TempDir(), "schema.yaml")
+	if err := ioutil.WriteFile(schemaFile, []byte(testSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := custom.LoadSchema(schemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	incidents, err := devops.ParseIncidents("cpu-spike@2018-01-01T00:10:00Z/15m=host_1+host_3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]useCaseSimulators{
+		"devops": func(workers int) (common.Simulator, []common.SlicedSimulator) {
+			cfg := &devops.DevopsSimulatorConfig{Start: start, End: end, HostCount: 7, Rand: rand.New(rand.NewSource(42))}
+			if workers == 1 {
+				return cfg.ToSimulator(), nil
+			}
+			var slices []common.SlicedSimulator
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+			return nil, slices
+		},
+		"devops-churn-incidents": func(workers int) (common.Simulator, []common.SlicedSimulator) {
+			cfg := &devops.DevopsSimulatorConfig{Start: start, End: end, HostCount: 7, HostOffset: 3,
+				ChurnRate: 0.3, ChurnPeriod: 10 * time.Minute, Diurnal: true, Rand: rand.New(rand.NewSource(42))}
+			if workers == 1 {
+				return devops.NewIncidentSimulator(cfg.ToSimulator(), incidents), nil
+			}
+			var slices []common.SlicedSimulator
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, devops.NewIncidentSimulator(s, incidents))
+			}
+			return nil, slices
+		},
+		"iot": func(workers int) (common.Simulator, []common.SlicedSimulator) {
+			cfg := &iot.IotSimulatorConfig{Start: start, End: end, SmartHomeCount: 5, Rand: rand.New(rand.NewSource(42))}
+			if workers == 1 {
+				return cfg.ToSimulator(), nil
+			}
+			var slices []common.SlicedSimulator
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+			return nil, slices
+		},
+		"dashboard": func(workers int) (common.Simulator, []common.SlicedSimulator) {
+			cfg := &dashboard.DashboardSimulatorConfig{Start: start, End: end, HostCount: 7, Rand: rand.New(rand.NewSource(42))}
+			if workers == 1 {
+				return cfg.ToSimulator(), nil
+			}
+			var slices []common.SlicedSimulator
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+			return nil, slices
+		},
+		"kubernetes": func(workers int) (common.Simulator, []common.SlicedSimulator) {
+			cfg := &kubernetes.KubernetesSimulatorConfig{Start: start, End: end, NodeCount: 4, Rand: rand.New(rand.NewSource(42))}
+			if workers == 1 {
+				return cfg.ToSimulator(), nil
+			}
+			var slices []common.SlicedSimulator
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+			return nil, slices
+		},
+		"custom": func(workers int) (common.Simulator, []common.SlicedSimulator) {
+			cfg := &custom.CustomSimulatorConfig{Start: start, End: end, SourceCount: 5, Schema: schema, Rand: rand.New(rand.NewSource(42))}
+			if workers == 1 {
+				return cfg.ToSimulator(), nil
+			}
+			var slices []common.SlicedSimulator
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+			return nil, slices
+		},
+		"events": func(workers int) (common.Simulator, []common.SlicedSimulator) {
+			cfg := &events.EventsSimulatorConfig{Start: start, End: end, InstanceCount: 5, Rand: rand.New(rand.NewSource(42))}
+			if workers == 1 {
+				return cfg.ToSimulator(), nil
+			}
+			var slices []common.SlicedSimulator
+			for _, s := range cfg.ToSimulatorSlices(workers) {
+				slices = append(slices, s)
+			}
+			return nil, slices
+		},
+	}
+}
+
+func TestGenerateParallel(t *testing.T) {
+	for name, simulators := range testUseCases(t) {
+		t.Run(name, func(t *testing.T) {
+			serializer := common.NewSerializerInflux()
+
+			var want bytes.Buffer
+			sim, _ := simulators(1)
+			point := common.MakeUsablePoint()
+			for !sim.Finished() {
+				sim.Next(point)
+				if err := serializer.SerializePoint(&want, point); err != nil {
+					t.Fatal(err)
+				}
+				point.Reset()
+			}
+			if want.Len() == 0 {
+				t.Fatal("no points generated")
+			}
+
+			var got bytes.Buffer
+			_, slices := simulators(3)
+			stats, err := generateParallel(&got, slices, serializer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Fatalf("the output of 3 workers (%d bytes) differs from the output of 1 worker (%d bytes)", got.Len(), want.Len())
+			}
+
+			var points int64
+			for _, s := range slices {
+				points += s.SeenPoints()
+			}
+			if points != sim.SeenPoints() {
+				t.Errorf("3 workers generated %d points, 1 worker %d", points, sim.SeenPoints())
+			}
+			if stats == nil {
+				t.Error("no stats")
+			}
+		})
+	}
+}
@@ -5,93 +5,48 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
+	"github.com/influxdata/influxdb-comparisons/bulk_load"
 	"log"
-	"os"
-	"sync"
 	"time"
 
 	"github.com/gocql/gocql"
-	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
-	"github.com/influxdata/influxdb-comparisons/util/report"
 	"strconv"
-	"strings"
 )
 
 // Program option vars:
 var (
-	daemonUrl      string
-	workers        int
-	batchSize      int
-	doLoad         bool
-	writeTimeout   time.Duration
-	reportDatabase string
-	reportHost     string
-	reportUser     string
-	reportPassword string
-	reportTagsCSV  string
+	daemonUrl    string
+	writeTimeout time.Duration
+	loadRunner   bulk_load.LoadRunner
 )
 
 // Global vars
 var (
-	batchChan      chan *gocql.Batch
-	inputDone      chan struct{}
-	workersGroup   sync.WaitGroup
-	reportTags     [][2]string
-	reportHostname string
+	session *gocql.Session
 )
 
 // Parse args:
 func init() {
+	loadRunner.RegisterFlags(100)
 	flag.StringVar(&daemonUrl, "url", "localhost:9042", "Cassandra URL.")
 
-	flag.IntVar(&batchSize, "batch-size", 100, "Batch size (input items).")
-	flag.IntVar(&workers, "workers", 1, "Number of parallel requests to make.")
 	flag.DurationVar(&writeTimeout, "write-timeout", 10*time.Second, "Write timeout.")
 
-	flag.BoolVar(&doLoad, "do-load", true, "Whether to write data. Set this flag to false to check input read speed.")
-
-	flag.StringVar(&reportDatabase, "report-database", "database_benchmarks", "Database name where to store result metrics")
-	flag.StringVar(&reportHost, "report-host", "", "Host to send result metrics")
-	flag.StringVar(&reportUser, "report-user", "", "User for host to send result metrics")
-	flag.StringVar(&reportPassword, "report-password", "", "User password for Host to send result metrics")
-	flag.StringVar(&reportTagsCSV, "report-tags", "", "Comma separated k:v tags to send  alongside result metrics")
-
 	flag.Parse()
 
-	if reportHost != "" {
-		fmt.Printf("results report destination: %v\n", reportHost)
-		fmt.Printf("results report database: %v\n", reportDatabase)
-
-		var err error
-		reportHostname, err = os.Hostname()
-		if err != nil {
-			log.Fatalf("os.Hostname() error: %s", err.Error())
-		}
-		fmt.Printf("hostname for results report: %v\n", reportHostname)
-
-		if reportTagsCSV != "" {
-			pairs := strings.Split(reportTagsCSV, ",")
-			for _, pair := range pairs {
-				fields := strings.SplitN(pair, ":", 2)
-				tagpair := [2]string{fields[0], fields[1]}
-				reportTags = append(reportTags, tagpair)
-			}
-		}
-		fmt.Printf("results report tags: %v\n", reportTags)
+	if err := loadRunner.Validate("cassandra"); err != nil {
+		log.Fatal(err)
 	}
 }
 
 func main() {
-	if doLoad {
-		createKeyspace(daemonUrl)
-	}
-
-	var session *gocql.Session
+	if loadRunner.DoLoad {
+		if !loadRunner.Resuming() {
+			createKeyspace(daemonUrl)
+		}
 
-	if doLoad {
 		cluster := gocql.NewCluster(daemonUrl)
 		cluster.Keyspace = "measurements"
 		cluster.Timeout = writeTimeout
@@ -105,138 +60,38 @@ func main() {
 		defer session.Close()
 	}
 
-	batchChan = make(chan *gocql.Batch, workers)
-	inputDone = make(chan struct{})
-
-	for i := 0; i < workers; i++ {
-		workersGroup.Add(1)
-		go processBatches(session)
-	}
-
-	start := time.Now()
-	itemsRead, bytesRead, valuesRead := scan(session, batchSize)
-
-	<-inputDone
-	close(batchChan)
-	workersGroup.Wait()
-	end := time.Now()
-	took := end.Sub(start)
-
-	//itemsRate := float64(itemsRead) / float64(took.Seconds())
-	bytesRate := float64(bytesRead) / float64(took.Seconds())
-	valuesRate := float64(valuesRead) / float64(took.Seconds())
-
-	fmt.Printf("loaded %d items in %fsec with %d workers (mean value rate %f/s, %.2fMB/sec from stdin)\n", itemsRead, took.Seconds(), workers, valuesRate, bytesRate/(1<<20))
-
-	if reportHost != "" {
-		//append db specific tags to custom tags
-		reportTags = append(reportTags, [2]string{"write_timeout", strconv.Itoa(int(writeTimeout))})
-
-		reportParams := &report.LoadReportParams{
-			ReportParams: report.ReportParams{
-				DBType:             "Cassandra",
-				ReportDatabaseName: reportDatabase,
-				ReportHost:         reportHost,
-				ReportUser:         reportUser,
-				ReportPassword:     reportPassword,
-				ReportTags:         reportTags,
-				Hostname:           reportHostname,
-				DestinationUrl:     daemonUrl,
-				Workers:            workers,
-				ItemLimit:          -1,
-			},
-			IsGzip:    false,
-			BatchSize: batchSize,
-		}
-		err := report.ReportLoadResult(reportParams, itemsRead, valuesRate, bytesRate, took)
-
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
+	loadRunner.Run(&bulk_load.Loader{
+		DBType:         "Cassandra",
+		DestinationUrl: daemonUrl,
+		ReportTags: [][2]string{
+			{"write_timeout", strconv.Itoa(int(writeTimeout))},
+		},
+		// Cassandra's schema stores each value separately, a point is
+		// represented in series_id: 1 item = 1 line = 1 value.
+		ItemsAreValues: true,
+		NewDecoder: func(batchSize int) bulk_load.BatchDecoder {
+			return bulk_load.NewLineDecoder(batchSize, 1)
+		},
+		NewProcessor: func(worker int) (bulk_load.Processor, error) {
+			return &processor{}, nil
+		},
+	})
 }
 
-// scan reads lines from stdin. It expects input in the Cassandra CQL format.
-func scan(session *gocql.Session, itemsPerBatch int) (int64, int64, int64) {
-	var batch *gocql.Batch
-	if doLoad {
-		batch = session.NewBatch(gocql.LoggedBatch)
-	}
-
-	var n int
-	var itemsRead, bytesRead int64
-	var totalPoints, totalValues int64
-
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, common.DatasetSizeMarker) {
-			parts := common.DatasetSizeMarkerRE.FindAllStringSubmatch(line, -1)
-			if parts == nil || len(parts[0]) != 3 {
-				log.Fatalf("Incorrent number of matched groups: %#v", parts)
-			}
-			if i, err := strconv.Atoi(parts[0][1]); err == nil {
-				totalPoints = int64(i)
-			} else {
-				log.Fatal(err)
-			}
-			if i, err := strconv.Atoi(parts[0][2]); err == nil {
-				totalValues = int64(i)
-			} else {
-				log.Fatal(err)
-			}
-			continue
-		}
-		itemsRead++
-		bytesRead += int64(len(scanner.Bytes()))
-
-		if !doLoad {
-			continue
-		}
-
-		batch.Query(string(scanner.Bytes()))
-
-		n++
-		if n >= itemsPerBatch {
-			batchChan <- batch
-			batch = session.NewBatch(gocql.LoggedBatch)
-			n = 0
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error reading input: %s", err.Error())
-	}
-
-	// Finished reading input, make sure last batch goes out.
-	if n > 0 {
-		batchChan <- batch
-	}
-
-	// Closing inputDone signals to the application that we've read everything and can now shut down.
-	close(inputDone)
-	//cassandra's schema stores each value separately, point is represented in series_id
-	if itemsRead != totalValues {
-		log.Fatalf("Incorrent number of read items: %d, expected: %d:", itemsRead, totalPoints)
-	}
-
-	return itemsRead, bytesRead, totalValues
+// processor writes batches of CQL queries, with the session shared by all
+// the workers.
+type processor struct{}
+
+func (p *processor) ProcessBatch(b bulk_load.Batch) error {
+	batch := session.NewBatch(gocql.LoggedBatch)
+	b.(*bulk_load.LinesBatch).Lines(func(line []byte) error {
+		batch.Query(string(line))
+		return nil
+	})
+	return session.ExecuteBatch(batch)
 }
 
-// processBatches reads byte buffers from batchChan and writes them to the target server, while tracking stats on the write.
-func processBatches(session *gocql.Session) {
-	for batch := range batchChan {
-		if !doLoad {
-			continue
-		}
-
-		// Write the batch.
-		err := session.ExecuteBatch(batch)
-		if err != nil {
-			log.Fatalf("Error writing: %s\n", err.Error())
-		}
-	}
-	workersGroup.Done()
+func (p *processor) Close() {
 }
 
 func createKeyspace(daemon_url string) {
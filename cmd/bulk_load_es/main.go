@@ -5,18 +5,17 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"github.com/influxdata/influxdb-comparisons/bulk_load"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
-	"sync"
 	"text/template"
 	"time"
 
@@ -33,12 +32,8 @@ var (
 	csvDaemonUrls      string
 	daemonUrls         []string
 	refreshEachBatch   bool
-	workers            int
-	batchSize          int
-	itemLimit          int64
 	indexTemplateName  string
 	useGzip            bool
-	doLoad             bool
 	doDBCreate         bool
 	numberOfReplicas   uint
 	numberOfShards     uint
@@ -47,25 +42,15 @@ var (
 	telemetryBatchSize uint64
 	telemetryTagsCSV   string
 	telemetryBasicAuth string
-	reportDatabase     string
-	reportHost         string
-	reportUser         string
-	reportPassword     string
-	reportTagsCSV      string
+	loadRunner         bulk_load.LoadRunner
 )
 
 // Global vars
 var (
-	bufPool             sync.Pool
-	batchChan           chan *bytes.Buffer
-	inputDone           chan struct{}
-	workersGroup        sync.WaitGroup
 	telemetryChanPoints chan *report.Point
 	telemetryChanDone   chan struct{}
 	telemetryHostname   string
 	telemetryTags       [][2]string
-	reportTags          [][2]string
-	reportHostname      string
 )
 
 // Args parsing vars
@@ -150,18 +135,14 @@ var aggregationTemplate = []byte(`
 
 // Parse args:
 func init() {
+	loadRunner.RegisterFlags(5000)
 	flag.StringVar(&csvDaemonUrls, "urls", "http://localhost:9200", "ElasticSearch URLs, comma-separated. Will be used in a round-robin fashion.")
 	flag.BoolVar(&refreshEachBatch, "refresh", true, "Whether each batch is immediately indexed.")
-	flag.Int64Var(&itemLimit, "item-limit", -1, "Number of items to read from stdin before quitting. (2 lines of input = 1 item)")
-
-	flag.IntVar(&batchSize, "batch-size", 5000, "Batch size (input items).")
-	flag.IntVar(&workers, "workers", 1, "Number of parallel requests to make.")
 
 	flag.StringVar(&indexTemplateName, "index-template", "default", "ElasticSearch index template to use (choices: default, aggregation).")
 
 	flag.BoolVar(&useGzip, "gzip", true, "Whether to gzip encode requests (default true).")
 
-	flag.BoolVar(&doLoad, "do-load", true, "Whether to write data. Set this flag to false to check input read speed.")
 	flag.BoolVar(&doDBCreate, "do-db-create", true, "Whether to create the database.")
 
 	flag.UintVar(&numberOfReplicas, "number-of-replicas", 0, "Number of ES replicas (note: replicas == replication_factor - 1). Zero replicas means RF of 1.")
@@ -173,14 +154,12 @@ func init() {
 	flag.StringVar(&telemetryBasicAuth, "telemetry-basic-auth", "", "basic auth (username:password) for telemetry.")
 	flag.StringVar(&telemetryTagsCSV, "telemetry-tags", "", "Tag(s) for telemetry. Format: key0:val0,key1:val1,...")
 
-	flag.StringVar(&reportDatabase, "report-database", "database_benchmarks", "Database name where to store result metrics")
-	flag.StringVar(&reportHost, "report-host", "", "Host to send result metrics")
-	flag.StringVar(&reportUser, "report-user", "", "User for host to send result metrics")
-	flag.StringVar(&reportPassword, "report-password", "", "User password for Host to send result metrics")
-	flag.StringVar(&reportTagsCSV, "report-tags", "", "Comma separated k:v tags to send  alongside result metrics")
-
 	flag.Parse()
 
+	if err := loadRunner.Validate("es-bulk"); err != nil {
+		log.Fatal(err)
+	}
+
 	daemonUrls = strings.Split(csvDaemonUrls, ",")
 	if len(daemonUrls) == 0 {
 		log.Fatal("missing 'urls' flag")
@@ -210,35 +189,13 @@ func init() {
 		fmt.Printf("telemetry tags: %v\n", telemetryTags)
 	}
 
-	if reportHost != "" {
-		fmt.Printf("results report destination: %v\n", reportHost)
-		fmt.Printf("results report database: %v\n", reportDatabase)
-
-		var err error
-		reportHostname, err = os.Hostname()
-		if err != nil {
-			log.Fatalf("os.Hostname() error: %s", err.Error())
-		}
-		fmt.Printf("hostname for results report: %v\n", reportHostname)
-
-		if reportTagsCSV != "" {
-			pairs := strings.Split(reportTagsCSV, ",")
-			for _, pair := range pairs {
-				fields := strings.SplitN(pair, ":", 2)
-				tagpair := [2]string{fields[0], fields[1]}
-				reportTags = append(reportTags, tagpair)
-			}
-		}
-		fmt.Printf("results report tags: %v\n", reportTags)
-	}
-
 	if _, ok := indexTemplateChoices[indexTemplateName]; !ok {
 		log.Fatalf("invalid index template type")
 	}
 }
 
 func main() {
-	if doLoad && doDBCreate {
+	if loadRunner.DoLoad && doDBCreate && !loadRunner.Resuming() {
 		// check that there are no pre-existing index templates:
 		existingIndexTemplates, err := listIndexTemplates(daemonUrls[0])
 		if err != nil {
@@ -266,190 +223,96 @@ func main() {
 			log.Fatal(err)
 		}
 	}
-	bufPool = sync.Pool{
-		New: func() interface{} {
-			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
-		},
-	}
-
-	batchChan = make(chan *bytes.Buffer, workers)
-	inputDone = make(chan struct{})
 
 	if telemetryHost != "" {
 		telemetryCollector := report.NewCollector(telemetryHost, "telegraf", telemetryBasicAuth)
 		telemetryChanPoints, telemetryChanDone = report.TelemetryRunAsync(telemetryCollector, telemetryBatchSize, telemetryStderr, 0)
 	}
 
-	for i := 0; i < workers; i++ {
-		daemonUrl := daemonUrls[i%len(daemonUrls)]
-		workersGroup.Add(1)
-		cfg := HTTPWriterConfig{
-			Host: daemonUrl,
-		}
-		go processBatches(NewHTTPWriter(cfg, refreshEachBatch), telemetryChanPoints, fmt.Sprintf("%d", i))
-	}
-
-	start := time.Now()
-	itemsRead, bytesRead := scan(batchSize)
-
-	<-inputDone
-	close(batchChan)
-	workersGroup.Wait()
-	end := time.Now()
-	took := end.Sub(start)
-	itemsRate := float64(itemsRead) / float64(took.Seconds())
-	bytesRate := float64(bytesRead) / float64(took.Seconds())
-
-	valuesRate := itemsRate * ValuesPerMeasurement
+	loadRunner.Run(&bulk_load.Loader{
+		DBType:         "ElasticSearch",
+		DestinationUrl: csvDaemonUrls,
+		IsGzip:         useGzip,
+		ReportTags: [][2]string{
+			{"replicas", strconv.Itoa(int(numberOfReplicas))},
+			{"shards", strconv.Itoa(int(numberOfShards))},
+			{"index-template", indexTemplateName},
+		},
+		ValuesPerItem: ValuesPerMeasurement,
+		NewDecoder: func(batchSize int) bulk_load.BatchDecoder {
+			// The ElasticSearch bulk format uses two line pairs, the first
+			// line being an 'action' and the second line being the payload.
+			// (2 lines = 1 item)
+			return bulk_load.NewLineDecoder(batchSize, 2)
+		},
+		NewProcessor: newProcessor,
+	})
 
 	if telemetryHost != "" {
 		close(telemetryChanPoints)
 		<-telemetryChanDone
 	}
-
-	fmt.Printf("loaded %d items in %fsec with %d workers (mean point rate %f items/sec, mean value rate %f/s, %.2fMB/sec from stdin)\n", itemsRead, took.Seconds(), workers, itemsRate, valuesRate, bytesRate/(1<<20))
-
-	if reportHost != "" {
-		//append db specific tags to custom tags
-		reportTags = append(reportTags, [2]string{"replicas", strconv.Itoa(int(numberOfReplicas))})
-		reportTags = append(reportTags, [2]string{"shards", strconv.Itoa(int(numberOfShards))})
-		reportTags = append(reportTags, [2]string{"index-template", indexTemplateName})
-
-		reportParams := &report.LoadReportParams{
-			ReportParams: report.ReportParams{
-				DBType:             "ElasticSearch",
-				ReportDatabaseName: reportDatabase,
-				ReportHost:         reportHost,
-				ReportUser:         reportUser,
-				ReportPassword:     reportPassword,
-				ReportTags:         reportTags,
-				Hostname:           reportHostname,
-				DestinationUrl:     csvDaemonUrls,
-				Workers:            workers,
-				ItemLimit:          int(itemLimit),
-			},
-			IsGzip:    useGzip,
-			BatchSize: batchSize,
-		}
-		err := report.ReportLoadResult(reportParams, itemsRead, valuesRate, bytesRate, took)
-
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
 }
 
-// scan reads items from stdin. It expects input in the ElasticSearch bulk
-// format: two line pairs, the first line being an 'action' and the second line
-// being the payload. (2 lines = 1 item)
-func scan(itemsPerBatch int) (int64, int64) {
-	buf := bufPool.Get().(*bytes.Buffer)
-
-	var n int
-	var linesRead int64
-	var itemsRead int64
-	var bytesRead int64
-	var itemsThisBatch int
-	scanner := bufio.NewScanner(os.Stdin)
-
-	for scanner.Scan() {
-		linesRead++
-
-		buf.Write(scanner.Bytes())
-		buf.Write([]byte("\n"))
-
-		//n++
-		if linesRead%2 == 0 {
-			itemsRead++
-			itemsThisBatch++
-		}
-
-		hitLimit := itemLimit >= 0 && itemsRead >= itemLimit
-
-		if itemsThisBatch == itemsPerBatch || hitLimit {
-			bytesRead += int64(buf.Len())
-			batchChan <- buf
-			buf = bufPool.Get().(*bytes.Buffer)
-			itemsThisBatch = 0
-		}
+// processor writes batches of items to a server, while tracking stats on the
+// writes.
+type processor struct {
+	w                    *HTTPWriter
+	telemetryWorkerLabel string
+	compressedBatch      *bytes.Buffer
+	batchesSeen          int64
+}
 
-		if hitLimit {
-			break
-		}
+func newProcessor(worker int) (bulk_load.Processor, error) {
+	cfg := HTTPWriterConfig{
+		Host: daemonUrls[worker%len(daemonUrls)],
 	}
+	return &processor{
+		w:                    NewHTTPWriter(cfg, refreshEachBatch),
+		telemetryWorkerLabel: fmt.Sprintf("%d", worker),
+		compressedBatch:      bytes.NewBuffer(make([]byte, 0, 4*1024*1024)),
+	}, nil
+}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error reading input: %s", err.Error())
+func (p *processor) ProcessBatch(b bulk_load.Batch) error {
+	batch := b.(*bulk_load.LinesBatch)
+	p.batchesSeen++
+
+	var err error
+	var bodySize int
+
+	// Write the batch.
+	if useGzip {
+		p.compressedBatch.Reset()
+		fasthttp.WriteGzip(p.compressedBatch, batch.Buf.Bytes())
+		bodySize = p.compressedBatch.Len()
+		_, err = p.w.WriteLineProtocol(p.compressedBatch.Bytes(), true)
+	} else {
+		bodySize = batch.Buf.Len()
+		_, err = p.w.WriteLineProtocol(batch.Buf.Bytes(), false)
 	}
-
-	// Finished reading input, make sure last batch goes out.
-	if n > 0 {
-		batchChan <- buf
+	if err != nil {
+		return err
 	}
 
-	// Closing inputDone signals to the application that we've read everything and can now shut down.
-	close(inputDone)
-
-	// The ES bulk format uses 2 lines per item:
-	if linesRead%2 != 0 {
-		log.Fatalf("the number of lines read was not a multiple of 2, which indicates a bad bulk format for Elastic")
+	// Report telemetry, if applicable:
+	if telemetryChanPoints != nil {
+		tp := report.GetPointFromGlobalPool()
+		tp.Init("benchmark_write", time.Now().UnixNano())
+		tp.AddTag("src_addr", telemetryHostname)
+		tp.AddTag("dst_addr", p.w.c.Host)
+		tp.AddTag("worker_id", p.telemetryWorkerLabel)
+		tp.AddInt64Field("worker_req_num", p.batchesSeen)
+		tp.AddBoolField("gzip", useGzip)
+		tp.AddInt64Field("body_bytes", int64(bodySize))
+		telemetryChanPoints <- tp
 	}
-
-	return itemsRead, bytesRead
+	return nil
 }
 
-// processBatches reads byte buffers from batchChan and writes them to the target server, while tracking stats on the write.
-func processBatches(w *HTTPWriter, telemetrySink chan *report.Point, telemetryWorkerLabel string) {
-	var batchesSeen int64
-	for batch := range batchChan {
-		batchesSeen++
-		if !doLoad {
-			continue
-		}
-
-		var err error
-		var bodySize int
-
-		// Write the batch.
-		if useGzip {
-			compressedBatch := bufPool.Get().(*bytes.Buffer)
-			fasthttp.WriteGzip(compressedBatch, batch.Bytes())
-			bodySize = len(compressedBatch.Bytes())
-			_, err = w.WriteLineProtocol(compressedBatch.Bytes(), true)
-			// Return the compressed batch buffer to the pool.
-			compressedBatch.Reset()
-			bufPool.Put(compressedBatch)
-		} else {
-			bodySize = len(batch.Bytes())
-			_, err = w.WriteLineProtocol(batch.Bytes(), false)
-		}
-
-		if err != nil {
-			log.Fatalf("Error writing: %s\n", err.Error())
-		}
-
-		// Return the batch buffer to the pool.
-		batch.Reset()
-		bufPool.Put(batch)
-
-		// Report telemetry, if applicable:
-		if telemetrySink != nil {
-			p := report.GetPointFromGlobalPool()
-			p.Init("benchmark_write", time.Now().UnixNano())
-			p.AddTag("src_addr", telemetryHostname)
-			p.AddTag("dst_addr", w.c.Host)
-			p.AddTag("worker_id", telemetryWorkerLabel)
-			p.AddInt64Field("worker_req_num", batchesSeen)
-			p.AddBoolField("gzip", useGzip)
-			p.AddInt64Field("body_bytes", int64(bodySize))
-			telemetrySink <- p
-		}
-	}
-	workersGroup.Done()
+func (p *processor) Close() {
 }
 
-// createESTemplate uses a Go text/template to create an ElasticSearch index
-// template. (This terminological conflict is mostly unavoidable).
 func createESTemplate(daemonUrl, indexTemplateName string, indexTemplateBodyTemplate []byte, numberOfReplicas, numberOfShards uint) error {
 	// set up URL:
 	u, err := url.Parse(daemonUrl)
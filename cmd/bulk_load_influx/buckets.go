@@ -0,0 +1,102 @@
+package main
+
+// Buckets of the v2 API, the counterpart of databases in InfluxDB 2.x.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// v2Request sends a request to the v2 API with the given token, decoding
+// its JSON response into result, if any. It returns the status of the
+// response, and an error holding its body unless the status is 2xx.
+func v2Request(method, u, token string, body interface{}, result interface{}) (int, error) {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("%s %s: status %d: %s", method, u, resp.StatusCode, respBody)
+	}
+	if result == nil {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.Unmarshal(respBody, result)
+}
+
+// bucketExists checks whether an organization has a bucket. This also
+// checks the token.
+func bucketExists(daemonUrl, org, bucket, token string) (bool, error) {
+	u := fmt.Sprintf("%s/api/v2/buckets?org=%s&name=%s", daemonUrl, url.QueryEscape(org), url.QueryEscape(bucket))
+	var listing struct {
+		Buckets []struct {
+			Name string `json:"name"`
+		} `json:"buckets"`
+	}
+	status, err := v2Request("GET", u, token, nil, &listing)
+	if status == http.StatusNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("bucketExists error: %s", err.Error())
+	}
+	for _, b := range listing.Buckets {
+		if b.Name == bucket {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// createBucket creates a bucket keeping its data forever.
+func createBucket(daemonUrl, org, bucket, token string) error {
+	var orgs struct {
+		Orgs []struct {
+			ID string `json:"id"`
+		} `json:"orgs"`
+	}
+	u := fmt.Sprintf("%s/api/v2/orgs?org=%s", daemonUrl, url.QueryEscape(org))
+	if _, err := v2Request("GET", u, token, nil, &orgs); err != nil {
+		return fmt.Errorf("createBucket error: %s", err.Error())
+	}
+	if len(orgs.Orgs) != 1 {
+		return fmt.Errorf("createBucket error: organization '%s' not found", org)
+	}
+
+	body := struct {
+		OrgID          string        `json:"orgID"`
+		Name           string        `json:"name"`
+		RetentionRules []interface{} `json:"retentionRules"`
+	}{orgs.Orgs[0].ID, bucket, []interface{}{}}
+	u = fmt.Sprintf("%s/api/v2/buckets", daemonUrl)
+	if _, err := v2Request("POST", u, token, body, nil); err != nil {
+		return fmt.Errorf("createBucket error: %s", err.Error())
+	}
+	return nil
+}
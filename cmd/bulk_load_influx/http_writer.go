@@ -8,11 +8,12 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/influxdata/influxdb-comparisons/bulk_load"
 	"github.com/valyala/fasthttp"
 )
 
 var (
-	BackoffError        error  = fmt.Errorf("backpressure is needed")
+	BackoffError        error  = bulk_load.ErrBackoff
 	backoffMagicWords0  []byte = []byte("engine: cache maximum memory size exceeded")
 	backoffMagicWords1  []byte = []byte("write failed: hinted handoff queue not empty")
 	backoffMagicWords2a []byte = []byte("write failed: read message type: read tcp")
@@ -20,18 +21,36 @@ var (
 	backoffMagicWords3  []byte = []byte("write failed: engine: cache-max-memory-size exceeded")
 	backoffMagicWords4  []byte = []byte("timeout")
 	backoffMagicWords5  []byte = []byte("write failed: can not exceed max connections of 500")
+	// error codes of the JSON bodies of the v2 API:
+	backoffMagicWords6 []byte = []byte(`"code":"too many requests"`)
+	backoffMagicWords7 []byte = []byte(`"code":"unavailable"`)
 )
 
+// Write APIs:
+const (
+	// apiV1 is the /write endpoint of InfluxDB 1.x.
+	apiV1 = "v1"
+	// apiV2 is the /api/v2/write endpoint of InfluxDB 2.x, also served by
+	// InfluxDB 3.x.
+	apiV2 = "v2"
+)
+
+var apiChoices = []string{apiV1, apiV2}
+
 // HTTPWriterConfig is the configuration used to create an HTTPWriter.
 type HTTPWriterConfig struct {
 	// URL of the host, in form "http://example.com:8086"
 	Host string
 
-	// Name of the target database into which points will be written.
+	// Name of the target database into which points will be written, the
+	// bucket with the v2 API.
 	Database string
 
-	BackingOffChan chan bool
-	BackingOffDone chan struct{}
+	// API to write with: apiV1 or apiV2. The v2 API needs the organization
+	// of the bucket, and a token.
+	API          string
+	Organization string
+	Token        string
 
 	// Debug label for more informative errors.
 	DebugInfo string
@@ -46,14 +65,30 @@ type HTTPWriter struct {
 }
 
 // NewHTTPWriter returns a new HTTPWriter from the supplied HTTPWriterConfig.
-func NewHTTPWriter(c HTTPWriterConfig, consistency string) *HTTPWriter {
+// The precision of the timestamps written is given to the server unless it
+// is the default, nanoseconds. The consistency only applies to the v1 API.
+func NewHTTPWriter(c HTTPWriterConfig, consistency string, precision string) *HTTPWriter {
+	var u string
+	if c.API == apiV2 {
+		u = c.Host + "/api/v2/write?org=" + url.QueryEscape(c.Organization) + "&bucket=" + url.QueryEscape(c.Database) + "&precision=" + precision
+	} else {
+		u = c.Host + "/write?consistency=" + consistency + "&db=" + url.QueryEscape(c.Database)
+		switch precision {
+		case "ns":
+		case "us":
+			// the 1.x write endpoint takes microseconds as 'u':
+			u += "&precision=u"
+		default:
+			u += "&precision=" + precision
+		}
+	}
 	return &HTTPWriter{
 		client: fasthttp.Client{
 			Name: "bulk_load_influx",
 		},
 
 		c:   c,
-		url: []byte(c.Host + "/write?consistency=" + consistency + "&db=" + url.QueryEscape(c.Database)),
+		url: []byte(u),
 	}
 }
 
@@ -73,6 +108,9 @@ func (w *HTTPWriter) WriteLineProtocol(body []byte, isGzip bool) (int64, error)
 	if isGzip {
 		req.Header.Add("Content-Encoding", "gzip")
 	}
+	if w.c.Token != "" {
+		req.Header.Add("Authorization", "Token "+w.c.Token)
+	}
 	req.SetBody(body)
 
 	resp := fasthttp.AcquireResponse()
@@ -81,7 +119,8 @@ func (w *HTTPWriter) WriteLineProtocol(body []byte, isGzip bool) (int64, error)
 	lat := time.Since(start).Nanoseconds()
 	if err == nil {
 		sc := resp.StatusCode()
-		if sc == 500 && backpressurePred(resp.Body()) {
+		// the v2 API answers 429 or 503 when overloaded:
+		if (sc == 500 || sc == 429 || sc == 503) && backpressurePred(resp.Body()) {
 			err = BackoffError
 		} else if sc != fasthttp.StatusNoContent {
 			err = fmt.Errorf("[DebugInfo: %s] Invalid write response (status %d): %s", w.c.DebugInfo, sc, resp.Body())
@@ -107,6 +146,10 @@ func backpressurePred(body []byte) bool {
 		return true
 	} else if bytes.Contains(body, backoffMagicWords5) {
 		return true
+	} else if bytes.Contains(body, backoffMagicWords6) {
+		return true
+	} else if bytes.Contains(body, backoffMagicWords7) {
+		return true
 	} else {
 		return false
 	}
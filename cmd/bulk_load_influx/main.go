@@ -1,11 +1,13 @@
 // bulk_load_influx loads an InfluxDB daemon with data from stdin.
 //
+// It writes with the 1.x API, or with the v2 API of InfluxDB 2.x and 3.x
+// (see -api), to a bucket named by -db.
+//
 // The caller is responsible for assuring that the database is empty before
 // bulk load.
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"flag"
@@ -13,16 +15,12 @@ import (
 	"github.com/influxdata/influxdb-comparisons/bulk_load"
 	"io/ioutil"
 	"log"
-	"net"
 	"net/http"
-	"net/rpc"
 	"net/url"
 	"os"
 	"runtime"
 	"runtime/pprof"
 	"strings"
-	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
@@ -35,62 +33,36 @@ import (
 // TODO VH: This should be calculated from available simulation data
 const ValuesPerMeasurement = 9.636 // dashboard use-case, original value was: 11.2222
 
-// TODO AP: Maybe useless
-const RateControlGranularity = 1000 // 1000 ms = 1s
-const RateControlMinBatchSize = 100
-
 // Program option vars:
 var (
-	csvDaemonUrls          string
-	daemonUrls             []string
-	dbName                 string
-	replicationFactor      int
-	workers                int
-	itemLimit              int64
-	batchSize              int
-	ingestRateLimit        int
-	backoff                time.Duration
-	timeLimit              time.Duration
-	progressInterval       time.Duration
-	doLoad                 bool
-	doDBCreate             bool
-	useGzip                bool
-	doAbortOnExist         bool
-	memprofile             bool
-	cpuProfileFile         string
-	consistency            string
-	telemetryHost          string
-	telemetryStderr        bool
-	telemetryBatchSize     uint64
-	telemetryTagsCSV       string
-	telemetryBasicAuth     string
-	reportDatabase         string
-	reportHost             string
-	reportUser             string
-	reportPassword         string
-	reportTagsCSV          string
-	notificationListenPort int
+	csvDaemonUrls      string
+	daemonUrls         []string
+	dbName             string
+	api                string
+	organization       string
+	token              string
+	replicationFactor  int
+	doDBCreate         bool
+	useGzip            bool
+	doAbortOnExist     bool
+	memprofile         bool
+	cpuProfileFile     string
+	consistency        string
+	precision          string
+	telemetryHost      string
+	telemetryStderr    bool
+	telemetryBatchSize uint64
+	telemetryTagsCSV   string
+	telemetryBasicAuth string
+	loadRunner         bulk_load.LoadRunner
 )
 
 // Global vars
 var (
-	bufPool               sync.Pool
-	batchChan             chan *bytes.Buffer
-	inputDone             chan struct{}
-	workersGroup          sync.WaitGroup
-	backingOffChans       []chan bool
-	backingOffDones       []chan struct{}
-	telemetryChanPoints   chan *report.Point
-	telemetryChanDone     chan struct{}
-	syncChanDone          chan int
-	telemetrySrcAddr      string
-	telemetryTags         [][2]string
-	progressIntervalItems uint64
-	reportTags            [][2]string
-	reportHostname        string
-	ingestionRateGran     float32
-	endedPrematurely      bool
-	prematureEndReason    string
+	telemetryChanPoints chan *report.Point
+	telemetryChanDone   chan struct{}
+	telemetrySrcAddr    string
+	telemetryTags       [][2]string
 )
 
 var consistencyChoices = map[string]struct{}{
@@ -102,19 +74,16 @@ var consistencyChoices = map[string]struct{}{
 
 // Parse args:
 func init() {
+	loadRunner.RegisterFlags(5000)
 	flag.StringVar(&csvDaemonUrls, "urls", "http://localhost:8086", "InfluxDB URLs, comma-separated. Will be used in a round-robin fashion.")
-	flag.StringVar(&dbName, "db", "benchmark_db", "Database name.")
+	flag.StringVar(&dbName, "db", "benchmark_db", "Database name (bucket name with the v2 API).")
+	flag.StringVar(&api, "api", apiV1, fmt.Sprintf("Write API: v1 for InfluxDB 1.x, v2 for InfluxDB 2.x and 3.x (which creates databases on write, use -do-db-create=false). (choices: %s)", strings.Join(apiChoices, ", ")))
+	flag.StringVar(&organization, "org", "", "Organization of the bucket (v2 API only).")
+	flag.StringVar(&token, "token", os.Getenv("INFLUX_TOKEN"), "Token to authenticate with (v2 API only, defaults to $INFLUX_TOKEN).")
 	flag.IntVar(&replicationFactor, "replication-factor", 1, "Cluster replication factor (only applies to clustered databases).")
 	flag.StringVar(&consistency, "consistency", "all", "Write consistency. Must be one of: any, one, quorum, all.")
-	flag.IntVar(&batchSize, "batch-size", 5000, "Batch size (1 line of input = 1 item).")
-	flag.IntVar(&workers, "workers", 1, "Number of parallel requests to make.")
-	flag.IntVar(&ingestRateLimit, "ingest-rate-limit", -1, "Ingest rate limit in values/s (-1 = no limit).")
-	flag.Int64Var(&itemLimit, "item-limit", -1, "Number of items to read from stdin before quitting. (1 item per 1 line of input.)")
-	flag.DurationVar(&backoff, "backoff", time.Second, "Time to sleep between requests when server indicates backpressure is needed.")
-	flag.DurationVar(&timeLimit, "time-limit", -1, "Maximum duration to run (-1 is the default: no limit).")
-	flag.DurationVar(&progressInterval, "progress-interval", -1, "Duration between printing progress messages.")
+	flag.StringVar(&precision, "precision", "ns", fmt.Sprintf("Precision of the input timestamps, as written by bulk_data_gen -influx-precision. (choices: %s)", strings.Join(common.InfluxPrecisionChoices, ", ")))
 	flag.BoolVar(&useGzip, "gzip", true, "Whether to gzip encode requests (default true).")
-	flag.BoolVar(&doLoad, "do-load", true, "Whether to write data. Set this flag to false to check input read speed.")
 	flag.BoolVar(&doDBCreate, "do-db-create", true, "Whether to create the database.")
 	flag.BoolVar(&doAbortOnExist, "do-abort-on-exist", true, "Whether to abort if the destination database already exists.")
 	flag.BoolVar(&memprofile, "memprofile", false, "Whether to write a memprofile (file automatically determined).")
@@ -123,19 +92,41 @@ func init() {
 	flag.StringVar(&telemetryTagsCSV, "telemetry-tags", "", "Tag(s) for telemetry. Format: key0:val0,key1:val1,...")
 	flag.BoolVar(&telemetryStderr, "telemetry-stderr", false, "Whether to write telemetry also to stderr.")
 	flag.Uint64Var(&telemetryBatchSize, "telemetry-batch-size", 10, "Telemetry batch size (lines).")
-	flag.StringVar(&reportDatabase, "report-database", "database_benchmarks", "Database name where to store result metrics")
-	flag.StringVar(&reportHost, "report-host", "", "Host to send result metrics")
-	flag.StringVar(&reportUser, "report-user", "", "User for host to send result metrics")
-	flag.StringVar(&reportPassword, "report-password", "", "User password for Host to send result metrics")
-	flag.StringVar(&reportTagsCSV, "report-tags", "", "Comma separated k:v tags to send  alongside result metrics")
-	flag.IntVar(&notificationListenPort, "notification-port", -1, "Listen port for remote notification messages. Used to remotely finish benchmark. -1 to disable feature")
 	flag.StringVar(&cpuProfileFile, "cpu-profile", "", "Write cpu profile to `file`")
 
 	flag.Parse()
 
+	if _, err := common.InfluxPrecisionUnit(precision); err != nil {
+		log.Fatal(err)
+	}
+	if err := loadRunner.Validate("influx-bulk"); err != nil {
+		log.Fatal(err)
+	}
+	if loadRunner.ManifestFile != "" {
+		m, err := common.ReadManifest(loadRunner.ManifestFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := m.CheckPrecision(precision, "ns"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if _, ok := consistencyChoices[consistency]; !ok {
 		log.Fatalf("invalid consistency settings")
 	}
+	switch api {
+	case apiV1:
+		if organization != "" {
+			log.Fatal("an organization only applies to the v2 API")
+		}
+	case apiV2:
+		if organization == "" {
+			log.Fatal("the v2 API needs an organization")
+		}
+	default:
+		log.Fatalf("invalid api '%s' (choices: %s)", api, strings.Join(apiChoices, ", "))
+	}
 
 	daemonUrls = strings.Split(csvDaemonUrls, ",")
 	if len(daemonUrls) == 0 {
@@ -166,58 +157,6 @@ func init() {
 		}
 		fmt.Printf("telemetry tags: %v\n", telemetryTags)
 	}
-	if reportHost != "" {
-		fmt.Printf("results report destination: %v\n", reportHost)
-		fmt.Printf("results report database: %v\n", reportDatabase)
-
-		var err error
-		reportHostname, err = os.Hostname()
-		if err != nil {
-			log.Fatalf("os.Hostname() error: %s", err.Error())
-		}
-		fmt.Printf("hostname for results report: %v\n", reportHostname)
-
-		if reportTagsCSV != "" {
-			pairs := strings.Split(reportTagsCSV, ",")
-			for _, pair := range pairs {
-				fields := strings.SplitN(pair, ":", 2)
-				tagpair := [2]string{fields[0], fields[1]}
-				reportTags = append(reportTags, tagpair)
-			}
-		}
-		fmt.Printf("results report tags: %v\n", reportTags)
-	}
-
-	if ingestRateLimit > 0 {
-		ingestionRateGran = (float32(ingestRateLimit) / float32(workers)) / (float32(1000) / float32(RateControlGranularity))
-		log.Printf("Using worker ingestion rate %v values/%v ms", ingestionRateGran, RateControlGranularity)
-		recommendedBatchSize := int((ingestionRateGran / ValuesPerMeasurement) * 0.20)
-		log.Printf("Calculated batch size hint: %v (allowed min: %v max: %v)", recommendedBatchSize, RateControlMinBatchSize, batchSize)
-		if recommendedBatchSize < RateControlMinBatchSize {
-			recommendedBatchSize = RateControlMinBatchSize
-		} else if recommendedBatchSize > batchSize {
-			recommendedBatchSize = batchSize
-		}
-		if recommendedBatchSize != batchSize {
-			log.Printf("Adjusting batchSize from %v to %v (%v values in 1 batch)", batchSize, recommendedBatchSize, float32(recommendedBatchSize)*ValuesPerMeasurement)
-			batchSize = recommendedBatchSize
-		}
-	} else {
-		log.Printf("Ingestion rate control is off")
-	}
-}
-
-func notifyHandler(arg int) (int, error) {
-	var e error
-	if arg == 0 {
-		fmt.Println("Received external finish request")
-		endedPrematurely = true
-		prematureEndReason = "External notification"
-		syncChanDone <- 1
-	} else {
-		e = fmt.Errorf("unknown notification code: %d", arg)
-	}
-	return 0, e
 }
 
 func printInfo() {
@@ -241,376 +180,160 @@ func main() {
 		}
 		defer pprof.StopCPUProfile()
 	}
-	// check that there are no pre-existing databases
-	// this also test db connection
-	existingDatabases, err := listDatabases(daemonUrls[0])
-	if err != nil {
-		log.Fatal(err)
-	}
-	if doLoad && doDBCreate {
-
-		if len(existingDatabases) > 0 {
-			if doAbortOnExist {
-				log.Fatalf("There are databases already in the data store. If you know what you are doing, run the command:\ncurl 'http://localhost:8086/query?q=drop%%20database%%20%s'\n", existingDatabases[0])
+	if api == apiV2 {
+		if loadRunner.DoLoad && doDBCreate && !loadRunner.Resuming() {
+			// check that the bucket does not exist yet
+			// this also tests the connection and token
+			exists, err := bucketExists(daemonUrls[0], organization, dbName, token)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if exists {
+				if doAbortOnExist {
+					log.Fatalf("The bucket %s already exists. If you know what you are doing, delete it, or run with -do-abort-on-exist=false.\n", dbName)
+				} else {
+					log.Printf("Info: the bucket %s already exists.", dbName)
+				}
 			} else {
-				log.Printf("Info: there are databases already in the data store.")
+				err = createBucket(daemonUrls[0], organization, dbName, token)
+				if err != nil {
+					log.Fatal(err)
+				}
+				time.Sleep(1000 * time.Millisecond)
 			}
 		}
+	} else {
+		// check that there are no pre-existing databases
+		// this also test db connection
+		existingDatabases, err := listDatabases(daemonUrls[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if loadRunner.DoLoad && doDBCreate && !loadRunner.Resuming() {
 
-		if len(existingDatabases) == 0 {
-			err = createDb(daemonUrls[0], dbName, replicationFactor)
-			if err != nil {
-				log.Fatal(err)
+			if len(existingDatabases) > 0 {
+				if doAbortOnExist {
+					log.Fatalf("There are databases already in the data store. If you know what you are doing, run the command:\ncurl 'http://localhost:8086/query?q=drop%%20database%%20%s'\n", existingDatabases[0])
+				} else {
+					log.Printf("Info: there are databases already in the data store.")
+				}
 			}
-			time.Sleep(1000 * time.Millisecond)
-		}
-	}
 
-	bufPool = sync.Pool{
-		New: func() interface{} {
-			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
-		},
+			if len(existingDatabases) == 0 {
+				err = createDb(daemonUrls[0], dbName, replicationFactor)
+				if err != nil {
+					log.Fatal(err)
+				}
+				time.Sleep(1000 * time.Millisecond)
+			}
+		}
 	}
 
-	batchChan = make(chan *bytes.Buffer, workers)
-	inputDone = make(chan struct{})
-	syncChanDone = make(chan int)
-
-	backingOffChans = make([]chan bool, workers)
-	backingOffDones = make([]chan struct{}, workers)
-
 	if telemetryHost != "" {
 		telemetryCollector := report.NewCollector(telemetryHost, "telegraf", telemetryBasicAuth)
 		telemetryChanPoints, telemetryChanDone = report.TelemetryRunAsync(telemetryCollector, telemetryBatchSize, telemetryStderr, 0)
 	}
 
-	if notificationListenPort > 0 {
-		notif := new(bulk_load.NotifyReceiver)
-		rpc.Register(notif)
-		rpc.HandleHTTP()
-		bulk_load.RegisterHandler(notifyHandler)
-		l, e := net.Listen("tcp", fmt.Sprintf(":%d", notificationListenPort))
-		if e != nil {
-			log.Fatal("listen error:", e)
-		}
-		log.Println("Listening for incoming notification")
-		go http.Serve(l, nil)
-	}
-
-	for i := 0; i < workers; i++ {
-		daemonUrl := daemonUrls[i%len(daemonUrls)]
-		backingOffChans[i] = make(chan bool, 100)
-		backingOffDones[i] = make(chan struct{})
-		workersGroup.Add(1)
-		cfg := HTTPWriterConfig{
-			DebugInfo:      fmt.Sprintf("worker #%d, dest url: %s", i, daemonUrl),
-			Host:           daemonUrl,
-			Database:       dbName,
-			BackingOffChan: backingOffChans[i],
-			BackingOffDone: backingOffDones[i],
-		}
-		go processBatches(NewHTTPWriter(cfg, consistency), backingOffChans[i], backingOffDones[i], telemetryChanPoints, fmt.Sprintf("%d", i))
-		go processBackoffMessages(i, backingOffChans[i], backingOffDones[i])
-	}
-
-	if progressInterval >= 0 {
-		go func() {
-			start := time.Now()
-			for end := range time.NewTicker(progressInterval).C {
-				n := atomic.SwapUint64(&progressIntervalItems, 0)
-
-				//absoluteMillis := end.Add(-progressInterval).UnixNano() / 1e6
-				absoluteMillis := start.UTC().UnixNano() / 1e6
-				fmt.Printf("[interval_progress_items] %dms, %d\n", absoluteMillis, n)
-				start = end
-			}
-		}()
-	}
-
-	start := time.Now()
-	itemsRead, bytesRead, valuesRead := scan(batchSize, syncChanDone)
-
-	<-inputDone
-	close(batchChan)
-	close(syncChanDone)
-
-	workersGroup.Wait()
-
-	for i := range backingOffChans {
-		close(backingOffChans[i])
-		<-backingOffDones[i]
-	}
-
-	end := time.Now()
-	took := end.Sub(start)
-
-	itemsRate := float64(itemsRead) / float64(took.Seconds())
-	bytesRate := float64(bytesRead) / float64(took.Seconds())
-	valuesRate := float64(valuesRead) / float64(took.Seconds())
+	loadRunner.Run(&bulk_load.Loader{
+		DBType:         "InfluxDB",
+		DestinationUrl: csvDaemonUrls,
+		IsGzip:         useGzip,
+		ReportTags: [][2]string{
+			{"replication_factor", strconv.Itoa(int(replicationFactor))},
+			{"back_off", strconv.Itoa(int(loadRunner.Backoff.Seconds()))},
+			{"consistency", consistency},
+		},
+		ValuesPerItem: ValuesPerMeasurement,
+		NewDecoder: func(batchSize int) bulk_load.BatchDecoder {
+			// 1 item = 1 line
+			return bulk_load.NewLineDecoder(batchSize, 1)
+		},
+		NewProcessor: newProcessor,
+	})
 
 	if telemetryHost != "" {
 		close(telemetryChanPoints)
 		<-telemetryChanDone
 	}
-
-	fmt.Printf("loaded %d items in %fsec with %d workers (mean point rate %f/sec, mean value rate %f/s, %.2fMB/sec from stdin)\n", itemsRead, took.Seconds(), workers, itemsRate, valuesRate, bytesRate/(1<<20))
-
-	if reportHost != "" {
-		//append db specific tags to custom tags
-		reportTags = append(reportTags, [2]string{"replication_factor", strconv.Itoa(int(replicationFactor))})
-		reportTags = append(reportTags, [2]string{"back_off", strconv.Itoa(int(backoff.Seconds()))})
-		reportTags = append(reportTags, [2]string{"consistency", consistency})
-		if endedPrematurely {
-			reportTags = append(reportTags, [2]string{"premature_end_reason", report.Escape(prematureEndReason)})
-		}
-		if timeLimit.Seconds() > 0 {
-			reportTags = append(reportTags, [2]string{"time_limit", timeLimit.String()})
-		}
-		if ingestRateLimit > 0 {
-			reportTags = append(reportTags, [2]string{"ingest_rate_limit", strconv.Itoa(ingestRateLimit)})
-		}
-		reportParams := &report.LoadReportParams{
-			ReportParams: report.ReportParams{
-				DBType:             "InfluxDB",
-				ReportDatabaseName: reportDatabase,
-				ReportHost:         reportHost,
-				ReportUser:         reportUser,
-				ReportPassword:     reportPassword,
-				ReportTags:         reportTags,
-				Hostname:           reportHostname,
-				DestinationUrl:     csvDaemonUrls,
-				Workers:            workers,
-				ItemLimit:          int(itemLimit),
-			},
-			IsGzip:    useGzip,
-			BatchSize: batchSize,
-		}
-		err = report.ReportLoadResult(reportParams, itemsRead, valuesRate, bytesRate, took)
-
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-
 }
 
-// scan reads one item at a time from stdin. 1 item = 1 line.
-// When the requested number of items per batch is met, send a batch over batchChan for the workers to write.
-func scan(itemsPerBatch int, doneCh chan int) (int64, int64, int64) {
-	buf := bufPool.Get().(*bytes.Buffer)
-
-	var n int
-	var itemsRead, bytesRead int64
-	var totalPoints, totalValues int64
-
-	newline := []byte("\n")
-	var deadline time.Time
-	if timeLimit > 0 {
-		deadline = time.Now().Add(timeLimit)
-	}
-
-	var batchItemCount uint64
-
-	scanner := bufio.NewScanner(bufio.NewReaderSize(os.Stdin, 4*1024*1024))
-outer:
-	for scanner.Scan() {
-		if itemsRead == itemLimit {
-			break
-		}
-
-		line := scanner.Text()
-		if strings.HasPrefix(line, common.DatasetSizeMarker) {
-			parts := common.DatasetSizeMarkerRE.FindAllStringSubmatch(line, -1)
-			if parts == nil || len(parts[0]) != 3 {
-				log.Fatalf("Incorrent number of matched groups: %#v", parts)
-			}
-			if i, err := strconv.Atoi(parts[0][1]); err == nil {
-				totalPoints = int64(i)
-			} else {
-				log.Fatal(err)
-			}
-			if i, err := strconv.Atoi(parts[0][2]); err == nil {
-				totalValues = int64(i)
-			} else {
-				log.Fatal(err)
-			}
-			continue
-		}
-		itemsRead++
-		batchItemCount++
-
-		buf.Write(scanner.Bytes())
-		buf.Write(newline)
-
-		n++
-		if n >= itemsPerBatch {
-			atomic.AddUint64(&progressIntervalItems, batchItemCount)
-			batchItemCount = 0
-
-			bytesRead += int64(buf.Len())
-			batchChan <- buf
-			buf = bufPool.Get().(*bytes.Buffer)
-			n = 0
-
-			if timeLimit > 0 && time.Now().After(deadline) {
-				endedPrematurely = true
-				prematureEndReason = "Timeout elapsed"
-				break outer
-			}
-		}
-		select {
-		case <-doneCh:
-			break outer
-		default:
-		}
-	}
+// processor writes batches of lines to a server, while tracking stats on the
+// writes.
+type processor struct {
+	w                    *HTTPWriter
+	telemetryWorkerLabel string
+	compressedBatch      *bytes.Buffer
+	batchesSeen          int64
+
+	// when the batch being written after backoff was first written:
+	backingOff bool
+	ts         int64
+}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error reading input: %s", err.Error())
-	}
+func newProcessor(worker int) (bulk_load.Processor, error) {
+	daemonUrl := daemonUrls[worker%len(daemonUrls)]
+	cfg := HTTPWriterConfig{
+		DebugInfo:    fmt.Sprintf("worker #%d, dest url: %s", worker, daemonUrl),
+		Host:         daemonUrl,
+		Database:     dbName,
+		API:          api,
+		Organization: organization,
+		Token:        token,
+	}
+	return &processor{
+		w:                    NewHTTPWriter(cfg, consistency, precision),
+		telemetryWorkerLabel: fmt.Sprintf("%d", worker),
+		compressedBatch:      bytes.NewBuffer(make([]byte, 0, 4*1024*1024)),
+	}, nil
+}
 
-	// Finished reading input, make sure last batch goes out.
-	if n > 0 {
-		batchChan <- buf
+func (p *processor) ProcessBatch(b bulk_load.Batch) error {
+	batch := b.(*bulk_load.LinesBatch)
+	if !p.backingOff {
+		p.batchesSeen++
+		p.ts = time.Now().UnixNano()
 	}
 
-	// Closing inputDone signals to the application that we've read everything and can now shut down.
-	close(inputDone)
-
-	if itemsRead != totalPoints { // totalPoints is unknown (0) when exiting prematurely due to time limit
-		if !endedPrematurely {
-			log.Fatalf("Incorrent number of read points: %d, expected: %d:", itemsRead, totalPoints)
-		} else {
-			totalValues = int64(float64(itemsRead) * ValuesPerMeasurement) // needed for statistics summary
-		}
+	var bodySize int
+	var err error
+	if useGzip {
+		p.compressedBatch.Reset()
+		fasthttp.WriteGzip(p.compressedBatch, batch.Buf.Bytes())
+		bodySize = p.compressedBatch.Len()
+		_, err = p.w.WriteLineProtocol(p.compressedBatch.Bytes(), true)
+	} else {
+		bodySize = batch.Buf.Len()
+		_, err = p.w.WriteLineProtocol(batch.Buf.Bytes(), false)
 	}
-
-	return itemsRead, bytesRead, totalValues
-}
-
-// processBatches reads byte buffers from batchChan and writes them to the target server, while tracking stats on the write.
-func processBatches(w *HTTPWriter, backoffSrc chan bool, backoffDst chan struct{}, telemetrySink chan *report.Point, telemetryWorkerLabel string) {
-	var batchesSeen int64
-
-	// Ingestion rate control vars
-	var gvCount float32
-	var gvStart time.Time
-	var ingestionRateDebt int64
-
-	if ingestRateLimit > 0 {
-		gvStart = time.Now()
+	p.backingOff = err == BackoffError
+	if err != nil {
+		return err
 	}
 
-	for batch := range batchChan {
-		batchesSeen++
-
-		var bodySize int
-		ts := time.Now().UnixNano()
-
-		// Write the batch: try until backoff is not needed.
-		if doLoad {
-			var err error
-			for {
-				if useGzip {
-					compressedBatch := bufPool.Get().(*bytes.Buffer)
-					fasthttp.WriteGzip(compressedBatch, batch.Bytes())
-					bodySize = len(compressedBatch.Bytes())
-					_, err = w.WriteLineProtocol(compressedBatch.Bytes(), true)
-					// Return the compressed batch buffer to the pool.
-					compressedBatch.Reset()
-					bufPool.Put(compressedBatch)
-				} else {
-					bodySize = len(batch.Bytes())
-					_, err = w.WriteLineProtocol(batch.Bytes(), false)
-				}
-
-				if err == BackoffError {
-					backoffSrc <- true
-					time.Sleep(backoff)
-				} else {
-					backoffSrc <- false
-					break
-				}
-			}
-			if err != nil {
-				log.Fatalf("Error writing: %s\n", err.Error())
-			}
-		}
-
-		if ingestRateLimit > 0 {
-			gvCount += float32(batchSize) * ValuesPerMeasurement // TODO last batch may not be full batchSize
-			if gvCount >= ingestionRateGran {
-				now := time.Now()
-				remaining := now.Sub(gvStart)
-				remainingMs := RateControlGranularity - (remaining.Nanoseconds() / 1e6) + ingestionRateDebt
-				ingestionRateDebt = 0
-				if remainingMs > 0 {
-					time.Sleep(time.Duration(remainingMs) * time.Millisecond) // TODO discount 5 ms for syscalls (sleep & wakeup) overhead?
-					gvStart = time.Now()
-					realDelay := gvStart.Sub(now).Nanoseconds() / 1e6
-					if realDelay != remainingMs {
-						ingestionRateDebt = -(realDelay - remainingMs) // TODO how about spurios wakeups?
-					}
-				} else {
-					gvStart = now
-					ingestionRateDebt = remainingMs
-				}
-				if ingestionRateDebt != 0 {
-					ingestionRateDebt = int64(float64(ingestionRateDebt) * float64(1.05))
-					if ingestionRateDebt < -RateControlGranularity { // trim to monitored period
-						ingestionRateDebt = -RateControlGranularity
-					}
-				}
-				gvCount -= ingestionRateGran
-			}
-		}
-
-		// lagMillis intentionally includes backoff time,
-		// and incidentally includes compression time:
-		lagMillis := float64(time.Now().UnixNano()-ts) / 1e6
-
-		// Return the batch buffer to the pool.
-		batch.Reset()
-		bufPool.Put(batch)
+	// lagMillis intentionally includes backoff time,
+	// and incidentally includes compression time:
+	lagMillis := float64(time.Now().UnixNano()-p.ts) / 1e6
 
-		// Report telemetry, if applicable:
-		if telemetrySink != nil {
-			p := report.GetPointFromGlobalPool()
-			p.Init("benchmark_write", time.Now().UnixNano())
-			for _, tagpair := range telemetryTags {
-				p.AddTag(tagpair[0], tagpair[1])
-			}
-			p.AddTag("src_addr", telemetrySrcAddr)
-			p.AddTag("dst_addr", w.c.Host)
-			p.AddTag("worker_id", telemetryWorkerLabel)
-			p.AddInt64Field("worker_req_num", batchesSeen)
-			p.AddFloat64Field("rtt_ms_total", lagMillis)
-			p.AddBoolField("gzip", useGzip)
-			p.AddInt64Field("body_bytes", int64(bodySize))
-			telemetrySink <- p
+	// Report telemetry, if applicable:
+	if telemetryChanPoints != nil {
+		tp := report.GetPointFromGlobalPool()
+		tp.Init("benchmark_write", time.Now().UnixNano())
+		for _, tagpair := range telemetryTags {
+			tp.AddTag(tagpair[0], tagpair[1])
 		}
+		tp.AddTag("src_addr", telemetrySrcAddr)
+		tp.AddTag("dst_addr", p.w.c.Host)
+		tp.AddTag("worker_id", p.telemetryWorkerLabel)
+		tp.AddInt64Field("worker_req_num", p.batchesSeen)
+		tp.AddFloat64Field("rtt_ms_total", lagMillis)
+		tp.AddBoolField("gzip", useGzip)
+		tp.AddInt64Field("body_bytes", int64(bodySize))
+		telemetryChanPoints <- tp
 	}
-	workersGroup.Done()
+	return nil
 }
 
-func processBackoffMessages(workerId int, src chan bool, dst chan struct{}) {
-	var totalBackoffSecs float64
-	var start time.Time
-	last := false
-	for this := range src {
-		if this && !last {
-			start = time.Now()
-			last = true
-		} else if !this && last {
-			took := time.Now().Sub(start)
-			fmt.Printf("[worker %d] backoff took %.02fsec\n", workerId, took.Seconds())
-			totalBackoffSecs += took.Seconds()
-			last = false
-			start = time.Now()
-		}
-	}
-	fmt.Printf("[worker %d] backoffs took a total of %fsec of runtime\n", workerId, totalBackoffSecs)
-	dst <- struct{}{}
+func (p *processor) Close() {
 }
 
 func createDb(daemon_url, dbname string, replicationFactor int) error {
@@ -5,11 +5,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/influxdata/influxdb-comparisons/bulk_load"
 	"github.com/valyala/fasthttp"
 )
 
 var (
-	BackoffError      error  = fmt.Errorf("backpressure is needed")
+	BackoffError      error  = bulk_load.ErrBackoff
 	backoffMagicWords []byte = []byte("engine: cache maximum memory size exceeded")
 )
 
@@ -8,20 +8,16 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"github.com/influxdata/influxdb-comparisons/bulk_load"
 	"log"
-	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/influxdata/influxdb-comparisons/util/report"
 	"github.com/jackc/pgx"
 
-	"bytes"
 	"context"
 	"encoding/binary"
-	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
 	"github.com/influxdata/influxdb-comparisons/timescale_serializaition"
 	"io"
 )
@@ -33,46 +29,25 @@ const DatabaseName = "benchmark_db"
 // Program option vars:
 var (
 	daemonUrl           string
-	workers             int
-	batchSize           int
-	doLoad              bool
 	doDbCreate          bool
-	reportDatabase      string
-	reportHost          string
-	reportUser          string
-	reportPassword      string
-	reportTagsCSV       string
 	psUser              string
 	psPassword          string
-	file                string
 	chunkDuration       time.Duration
 	usePostgresBatching bool
-)
-
-// Global vars
-var (
-	bufPool        sync.Pool
-	batchChan      chan *bytes.Buffer
-	batchChanBin   chan []FlatPoint
-	batchChanBatch chan []string
-	inputDone      chan struct{}
-	workersGroup   sync.WaitGroup
-	reportTags     [][2]string
-	reportHostname string
-	format         string
-	sourceReader   *os.File
+	format              string
+	loadRunner          bulk_load.LoadRunner
 )
 
 // Output data format choices:
 var formatChoices = []string{"timescaledb-sql", "timescaledb-copyFrom"}
 
 var processes = map[string]struct {
-	scan    func(int, io.Reader) (int64, int64, int64)
-	process func(*pgx.Conn) int64
+	newDecoder   func(int) bulk_load.BatchDecoder
+	processBatch func(*pgx.Conn, bulk_load.Batch) error
 }{
-	formatChoices[0]:           {scan, processBatches},
-	formatChoices[1]:           {scanBin, processBatchesBin},
-	"timescaledb-sql-batching": {scanBatch, processBatchesBatch},
+	formatChoices[0]:           {newLineDecoder, processBatch},
+	formatChoices[1]:           {newBinDecoder, processBatchBin},
+	"timescaledb-sql-batching": {newLineDecoder, processBatchBatch},
 }
 
 type FlatPoint struct {
@@ -83,31 +58,25 @@ type FlatPoint struct {
 
 // Parse args:
 func init() {
+	loadRunner.RegisterFlags(100)
 	flag.StringVar(&daemonUrl, "url", "localhost:5432", "Timescale DB URL.")
 	flag.StringVar(&psUser, "user", "postgres", "Postgresql user")
 	flag.StringVar(&psPassword, "password", "", "Postgresql password")
-	flag.StringVar(&file, "file", "", "Input file")
 
 	flag.StringVar(&format, "format", formatChoices[1], "Input data format. One of: "+strings.Join(formatChoices, ","))
-	flag.IntVar(&batchSize, "batch-size", 100, "Batch size (input items).")
-	flag.IntVar(&workers, "workers", 1, "Number of parallel requests to make.")
 	flag.BoolVar(&usePostgresBatching, "postgresql-batching", false, "Whether to use Postgresql batching feature. Works only for '"+formatChoices[0]+"' format")
 
-	flag.BoolVar(&doLoad, "do-load", true, "Whether to write data. Set this flag to false to check input read speed.")
 	flag.BoolVar(&doDbCreate, "do-db-create", true, "Whether to create database. Set this flag to false to write data to existing database")
 	flag.DurationVar(&chunkDuration, "chunk-interval", time.Hour*24, "Timescale chunk interval")
 
-	flag.StringVar(&reportDatabase, "report-database", "database_benchmarks", "Database name where to store result metrics")
-	flag.StringVar(&reportHost, "report-host", "", "Host to send result metrics")
-	flag.StringVar(&reportUser, "report-user", "", "User for host to send result metrics")
-	flag.StringVar(&reportPassword, "report-password", "", "User password for Host to send result metrics")
-	flag.StringVar(&reportTagsCSV, "report-tags", "", "Comma separated k:v tags to send  alongside result metrics")
-
 	flag.Parse()
 
 	if _, ok := processes[format]; !ok {
 		log.Fatal("Invalid format choice '", format, "'. Available are: ", strings.Join(formatChoices, ","))
 	}
+	if err := loadRunner.Validate(format); err != nil {
+		log.Fatal(err)
+	}
 	if usePostgresBatching {
 		if format == formatChoices[1] {
 			log.Fatal("Cannot use Postgresql batching when using format '", formatChoices[1], "'")
@@ -115,68 +84,28 @@ func init() {
 			format = "timescaledb-sql-batching"
 		}
 	}
-	if reportHost != "" {
-		fmt.Printf("results report destination: %v\n", reportHost)
-		fmt.Printf("results report database: %v\n", reportDatabase)
-
-		var err error
-		reportHostname, err = os.Hostname()
-		if err != nil {
-			log.Fatalf("os.Hostname() error: %s", err.Error())
-		}
-		fmt.Printf("hostname for results report: %v\n", reportHostname)
-
-		if reportTagsCSV != "" {
-			pairs := strings.Split(reportTagsCSV, ",")
-			for _, pair := range pairs {
-				fields := strings.SplitN(pair, ":", 2)
-				tagpair := [2]string{fields[0], fields[1]}
-				reportTags = append(reportTags, tagpair)
-			}
-		}
-		fmt.Printf("results report tags: %v\n", reportTags)
-	}
-
-	if file != "" {
-		if f, err := os.Open(file); err == nil {
-			sourceReader = f
-		} else {
-			log.Fatalf("Error opening %s: %v\n", file, err)
-		}
-	}
-	if sourceReader == nil {
-		sourceReader = os.Stdin
-	}
-
 }
 
 func main() {
-	if doLoad && doDbCreate {
+	if loadRunner.DoLoad && doDbCreate && !loadRunner.Resuming() {
 		createDatabase(daemonUrl)
 	}
 
-	bufPool = sync.Pool{
-		New: func() interface{} {
-			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
-		},
-	}
-
-	batchChan = make(chan *bytes.Buffer, workers)
-	batchChanBin = make(chan []FlatPoint, workers)
-	batchChanBatch = make(chan []string, workers)
-	inputDone = make(chan struct{})
-
 	procs := processes[format]
-
-	procReads := make([]int64, workers)
-	for i := 0; i < workers; i++ {
-		workersGroup.Add(1)
-		var conn *pgx.Conn
-		var err error
-		if doLoad {
+	loadRunner.Run(&bulk_load.Loader{
+		DBType:         "TimeScaleDB",
+		DestinationUrl: daemonUrl,
+		ReportTags: [][2]string{
+			{"format", format},
+			{"postgresql_batching", strconv.FormatBool(usePostgresBatching)},
+			{"chunk_interval", chunkDuration.String()},
+		},
+		ValuesPerItem: ValuesPerMeasurement,
+		NewDecoder:    procs.newDecoder,
+		NewProcessor: func(worker int) (bulk_load.Processor, error) {
 			hostPort := strings.Split(daemonUrl, ":")
 			port, _ := strconv.Atoi(hostPort[1])
-			conn, err = pgx.Connect(pgx.ConnConfig{
+			conn, err := pgx.Connect(pgx.ConnConfig{
 				Host:     hostPort[0],
 				Port:     uint16(port),
 				User:     psUser,
@@ -184,354 +113,145 @@ func main() {
 				Database: DatabaseName,
 			})
 			if err != nil {
-				log.Fatal(err)
-			}
-		}
-		go func(ind int, connection *pgx.Conn) {
-			if doLoad {
-				defer connection.Close()
+				return nil, err
 			}
-			procReads[ind] = procs.process(connection)
-		}(i, conn)
-	}
-
-	start := time.Now()
-	itemsRead, bytesRead, valuesRead := procs.scan(batchSize, sourceReader)
-
-	<-inputDone
-	close(batchChan)
-	close(batchChanBin)
-	close(batchChanBatch)
-	workersGroup.Wait()
-	end := time.Now()
-	took := end.Sub(start)
-	itemsRate := float64(itemsRead) / float64(took.Seconds())
-	bytesRate := float64(bytesRead) / float64(took.Seconds())
-
-	valuesRate := float64(valuesRead) / float64(took.Seconds())
-
-	fmt.Printf("loaded %d items in %fsec with %d workers (mean point rate %f/sec, mean value rate %f/sec,  %.2fMB/sec from stdin)\n", itemsRead, took.Seconds(), workers, itemsRate, valuesRate, bytesRate/(1<<20))
-	if file != "" {
-		sourceReader.Close()
-	}
-
-	if reportHost != "" && doLoad {
-		reportTags = append(reportTags, [2]string{"format", format})
-		reportTags = append(reportTags, [2]string{"postgresql_batching", strconv.FormatBool(usePostgresBatching)})
-		reportTags = append(reportTags, [2]string{"chunk_interval", chunkDuration.String()})
-		reportParams := &report.LoadReportParams{
-			ReportParams: report.ReportParams{
-				DBType:             "TimeScaleDB",
-				ReportDatabaseName: reportDatabase,
-				ReportHost:         reportHost,
-				ReportUser:         reportUser,
-				ReportPassword:     reportPassword,
-				ReportTags:         reportTags,
-				Hostname:           reportHostname,
-				DestinationUrl:     daemonUrl,
-				Workers:            workers,
-				ItemLimit:          -1,
-			},
-			IsGzip:    false,
-			BatchSize: batchSize,
-		}
-		err := report.ReportLoadResult(reportParams, itemsRead, valuesRate, bytesRate, took)
-
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
+			return &processor{conn: conn, processBatch: procs.processBatch}, nil
+		},
+	})
 }
 
-// scan reads lines from stdin. It expects input in the postgresql sql format.
-func scan(itemsPerBatch int, reader io.Reader) (int64, int64, int64) {
-	var n int
-	var linesRead, bytesRead int64
-	var totalPoints, totalValues int64
-
-	buff := bufPool.Get().(*bytes.Buffer)
-	newline := []byte("\n")
-
-	scanner := bufio.NewScanner(bufio.NewReaderSize(reader, 4*1024*1024))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, common.DatasetSizeMarker) {
-			parts := common.DatasetSizeMarkerRE.FindAllStringSubmatch(line, -1)
-			if parts == nil || len(parts[0]) != 3 {
-				log.Fatalf("Incorrent number of matched groups: %#v", parts)
-			}
-			if i, err := strconv.Atoi(parts[0][1]); err == nil {
-				totalPoints = int64(i)
-			} else {
-				log.Fatal(err)
-			}
-			if i, err := strconv.Atoi(parts[0][2]); err == nil {
-				totalValues = int64(i)
-			} else {
-				log.Fatal(err)
-			}
-			continue
-		}
-		linesRead++
-
-		buff.Write(scanner.Bytes())
-		buff.Write(newline)
-
-		n++
-		if n >= itemsPerBatch {
-			bytesRead += int64(buff.Len())
-			batchChan <- buff
-			buff = bufPool.Get().(*bytes.Buffer)
-			n = 0
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error reading input: %s", err.Error())
-	}
+// processor writes batches through the connection of a worker.
+type processor struct {
+	conn         *pgx.Conn
+	processBatch func(*pgx.Conn, bulk_load.Batch) error
+}
 
-	// Finished reading input, make sure last batch goes out.
-	if n > 0 {
-		batchChan <- buff
-	}
+func (p *processor) ProcessBatch(b bulk_load.Batch) error {
+	return p.processBatch(p.conn, b)
+}
 
-	// Closing inputDone signals to the application that we've read everything and can now shut down.
-	close(inputDone)
+func (p *processor) Close() {
+	p.conn.Close()
+}
 
-	if linesRead != totalPoints {
-		log.Fatalf("Incorrent number of read points: %d, expected: %d:", linesRead, totalPoints)
-	}
+// newLineDecoder decodes the postgresql sql format: 1 item = 1 line.
+func newLineDecoder(batchSize int) bulk_load.BatchDecoder {
+	return bulk_load.NewLineDecoder(batchSize, 1)
+}
 
-	// The timescaledb format uses 1 line per item:
-	itemsRead := linesRead
+// binBatch is a batch of points of the same measurement.
+type binBatch []FlatPoint
 
-	return itemsRead, bytesRead, totalValues
+func (b binBatch) Len() int {
+	return len(b)
 }
 
-// scan reads lines from stdin. It expects input in the postgresql sql format.
-func scanBatch(itemsPerBatch int, reader io.Reader) (int64, int64, int64) {
-	var n int
-	var linesRead, bytesRead int64
-	var totalPoints, totalValues int64
+// binDecoder decodes protobuf encoded points, starting a new batch with each
+// measurement.
+type binDecoder struct {
+	batchSize int
+	batch     binBatch
+	byteBuff  []byte
+}
 
-	scanner := bufio.NewScanner(bufio.NewReaderSize(reader, 4*1024*1024))
-	var buff = make([]string, 0, itemsPerBatch)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, common.DatasetSizeMarker) {
-			parts := common.DatasetSizeMarkerRE.FindAllStringSubmatch(line, -1)
-			if parts == nil || len(parts[0]) != 3 {
-				log.Fatalf("Incorrent number of matched groups: %#v", parts)
-			}
-			if i, err := strconv.Atoi(parts[0][1]); err == nil {
-				totalPoints = int64(i)
-			} else {
-				log.Fatal(err)
-			}
-			if i, err := strconv.Atoi(parts[0][2]); err == nil {
-				totalValues = int64(i)
-			} else {
-				log.Fatal(err)
-			}
-			continue
-		}
-		linesRead++
-		buff = append(buff, line)
-		bytesRead += int64(len(line))
-		n++
-		if n >= itemsPerBatch {
-			batchChanBatch <- buff
-			buff = make([]string, 0, itemsPerBatch)
-			n = 0
-		}
+func newBinDecoder(batchSize int) bulk_load.BatchDecoder {
+	return &binDecoder{
+		batchSize: batchSize,
+		byteBuff:  make([]byte, 100*1024),
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error reading input: %s", err.Error())
+func (d *binDecoder) Decode(r *bufio.Reader, _ *bulk_load.DatasetSize) (int, bulk_load.Batch, error) {
+	var size uint64
+	err := binary.Read(r, binary.LittleEndian, &size)
+	if err == io.EOF {
+		return 0, nil, io.EOF
+	} else if err != nil {
+		return 0, nil, fmt.Errorf("cannot read size of item: %v", err)
 	}
 
-	// Finished reading input, make sure last batch goes out.
-	if n > 0 {
-		batchChanBatch <- buff
+	if uint64(cap(d.byteBuff)) < size {
+		d.byteBuff = make([]byte, size)
 	}
-
-	// Closing inputDone signals to the application that we've read everything and can now shut down.
-	close(inputDone)
-
-	if linesRead != totalPoints {
-		log.Fatalf("Incorrent number of read points: %d, expected: %d:", linesRead, totalPoints)
+	if _, err := io.ReadFull(r, d.byteBuff[:size]); err != nil {
+		return 8, nil, fmt.Errorf("cannot read item of %d bytes: %v", size, err)
 	}
-
-	// The timescaledb format uses 1 line per item:
-	itemsRead := linesRead
-
-	return itemsRead, bytesRead, totalValues
-}
-
-// scan reads data from stdin. It expects gop encoded points
-func scanBin(itemsPerBatch int, origReader io.Reader) (int64, int64, int64) {
-
-	var n int
-	var itemsRead, bytesRead int64
-	var err error
-	var lastMeasurement string
-	var p FlatPoint
 	var tsfp timescale_serialization.FlatPoint
-	var size uint64
-
-	buff := make([]FlatPoint, 0, itemsPerBatch)
-	byteBuff := make([]byte, 100*1024)
-	reader := bufio.NewReaderSize(origReader, 4*1024*1024)
-	for {
-		err = binary.Read(reader, binary.LittleEndian, &size)
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			log.Fatalf("cannot read size of %d item: %v\n", itemsRead, err)
-		}
-
-		if uint64(cap(byteBuff)) < size {
-			byteBuff = make([]byte, size)
-		}
-
-		bytesPerItem := uint64(0)
-		for i := 10; i > 0; i-- {
-			r, err := reader.Read(byteBuff[bytesPerItem:size])
-			if err != nil && err != io.EOF {
-				log.Fatalf("cannot read %d item: %v\n", itemsRead, err)
-			}
-			bytesPerItem += uint64(r)
-			if bytesPerItem == size {
-				break
-			}
-
-		}
-		if bytesPerItem != size {
-			log.Fatalf("cannot read %d item: read %d, expected %d\n", itemsRead, bytesPerItem, size)
-		}
-		err = tsfp.Unmarshal(byteBuff[:size])
-		if err != nil {
-			log.Fatalf("cannot unmarshall %d item: %v\n", itemsRead, err)
-		}
-
-		bytesRead += int64(size) + 8
-
-		p.MeasurementName = tsfp.MeasurementName
-		p.Columns = tsfp.Columns
-		p.Values = make([]interface{}, len(tsfp.Values))
-		for i, f := range tsfp.Values {
-			switch f.Type {
-			case timescale_serialization.FlatPoint_FLOAT:
-				p.Values[i] = f.DoubleVal
-				break
-			case timescale_serialization.FlatPoint_INTEGER:
-				p.Values[i] = f.IntVal
-				break
-			case timescale_serialization.FlatPoint_STRING:
-				p.Values[i] = f.StringVal
-				break
-			default:
-				log.Fatalf("invalid type of %d item: %d", itemsRead, f.Type)
-			}
-		}
+	if err := tsfp.Unmarshal(d.byteBuff[:size]); err != nil {
+		return 8 + int(size), nil, fmt.Errorf("cannot unmarshall item: %v", err)
+	}
 
-		//log.Printf("Decoded %d point\n",itemsRead+1)
-		newMeasurement := itemsRead > 1 && p.MeasurementName != lastMeasurement
-		if !newMeasurement {
-			buff = append(buff, p)
-			itemsRead++
-			n++
-		}
-		if n > 0 && (n >= itemsPerBatch || newMeasurement) {
-			batchChanBin <- buff
-			n = 0
-			buff = nil
-			buff = make([]FlatPoint, 0, itemsPerBatch)
-		}
-		if newMeasurement {
-			buff = append(buff, p)
-			itemsRead++
-			n++
+	p := FlatPoint{
+		MeasurementName: tsfp.MeasurementName,
+		Columns:         tsfp.Columns,
+		Values:          make([]interface{}, len(tsfp.Values)),
+	}
+	for i, f := range tsfp.Values {
+		switch f.Type {
+		case timescale_serialization.FlatPoint_FLOAT:
+			p.Values[i] = f.DoubleVal
+		case timescale_serialization.FlatPoint_INTEGER:
+			p.Values[i] = f.IntVal
+		case timescale_serialization.FlatPoint_STRING:
+			p.Values[i] = f.StringVal
+		default:
+			return 8 + int(size), nil, fmt.Errorf("invalid type of item: %d", f.Type)
 		}
-		lastMeasurement = p.MeasurementName
-		p = FlatPoint{}
-		tsfp = timescale_serialization.FlatPoint{}
 	}
 
-	if err != nil && err != io.EOF {
-		log.Fatalf("Error reading input after %d items: %s", itemsRead, err.Error())
+	var full bulk_load.Batch
+	if len(d.batch) > 0 && d.batch[0].MeasurementName != p.MeasurementName {
+		full = d.batch
+		d.batch = nil
 	}
-
-	// Finished reading input, make sure last batch goes out.
-	if n > 0 {
-		batchChanBin <- buff
-		buff = nil
+	if d.batch == nil {
+		d.batch = make(binBatch, 0, d.batchSize)
 	}
-
-	// Closing inputDone signals to the application that we've read everything and can now shut down.
-	close(inputDone)
-
-	return itemsRead, bytesRead, int64(float64(itemsRead) * ValuesPerMeasurement)
+	d.batch = append(d.batch, p)
+	if full == nil && len(d.batch) >= d.batchSize {
+		full = d.batch
+		d.batch = nil
+	}
+	return 8 + int(size), full, nil
 }
 
-// processBatches reads byte buffers from batchChan and writes them to the target server, while tracking stats on the write.
-func processBatches(conn *pgx.Conn) int64 {
-	var total int64
-	for batch := range batchChan {
-		if !doLoad {
-			continue
-		}
-
-		// Write the batch.
-		_, err := conn.Exec(string(batch.Bytes()))
-		if err != nil {
-			log.Fatalf("Error writing: %s\n", err.Error())
-		}
-
-		// Return the batch buffer to the pool.
-		batch.Reset()
-		bufPool.Put(batch)
-		total += int64(batch.Len())
+func (d *binDecoder) Flush() bulk_load.Batch {
+	if len(d.batch) == 0 {
+		return nil
 	}
-	workersGroup.Done()
-	return total
+	b := d.batch
+	d.batch = nil
+	return b
 }
 
-// processBatches reads byte buffers from batchChan and writes them to the target server, while tracking stats on the write.
-func processBatchesBatch(conn *pgx.Conn) int64 {
-	var total int64
-	var batches int64
-	for batch := range batchChanBatch {
-		if !doLoad {
-			continue
-		}
+// processBatch writes a batch of sql statements.
+func processBatch(conn *pgx.Conn, b bulk_load.Batch) error {
+	_, err := conn.Exec(b.(*bulk_load.LinesBatch).Buf.String())
+	return err
+}
 
-		// Write the batch.
-		sqlBatch := conn.BeginBatch()
-		for _, line := range batch {
-			sqlBatch.Queue(line, nil, nil, nil)
-		}
+// processBatchBatch writes a batch of sql statements with the Postgresql
+// batching feature.
+func processBatchBatch(conn *pgx.Conn, b bulk_load.Batch) error {
+	sqlBatch := conn.BeginBatch()
+	defer sqlBatch.Close()
+	b.(*bulk_load.LinesBatch).Lines(func(line []byte) error {
+		sqlBatch.Queue(string(line), nil, nil, nil)
+		return nil
+	})
 
-		err := sqlBatch.Send(context.Background(), nil)
+	err := sqlBatch.Send(context.Background(), nil)
+	if err != nil {
+		return err
+	}
 
+	for i := 0; i < b.Len(); i++ {
+		_, err = sqlBatch.ExecResults()
 		if err != nil {
-			log.Fatalf("Error writing: %s\n", err.Error())
+			return fmt.Errorf("line %d of batch: %s", i, err.Error())
 		}
-
-		for i := 0; i < len(batch); i++ {
-			_, err = sqlBatch.ExecResults()
-			if err != nil {
-				log.Fatalf("Error line %d of batch %d: %s\n", i, batch, err.Error())
-			}
-		}
-		sqlBatch.Close()
-		// Return the batch buffer to the pool.
-		total += int64(len(batch))
-		batches++
 	}
-	workersGroup.Done()
-	return total
+	return nil
 }
 
 // CopyFromPoint is implementation of the interface CopyFromSource  used by *Conn.CopyFrom as the source for copy data.
@@ -569,30 +289,18 @@ func (c *CopyFromPoint) Position() int {
 	return c.i
 }
 
-// processBatches reads byte buffers from batchChan and writes them to the target server, while tracking stats on the write.
-func processBatchesBin(conn *pgx.Conn) int64 {
-	n := 0
-	var total int64
-	for batch := range batchChanBin {
-		if !doLoad {
-			continue
-		}
-		//log.Printf("CopyFrom %d of %s\n", n, batch[0].MeasurementName)
-		// Write the batch.
-		c := NewCopyFromPoint(batch)
-		rows, err := conn.CopyFrom(pgx.Identifier{batch[0].MeasurementName}, batch[0].Columns, c)
-		//log.Println("CopyFrom End")
-		if err != nil {
-			log.Fatalf("Error writing %d batch of '%s' of size %d in position %d: %s\n", n, batch[0].MeasurementName, len(batch), c.Position(), err.Error())
-		}
-		if rows != len(batch) {
-			log.Printf("Problem writing of %d batch: Written only %d rows of %d", n, rows, len(batch))
-		}
-		total += int64(len(batch))
-		n++
+// processBatchBin copies a batch of points to the table of their measurement.
+func processBatchBin(conn *pgx.Conn, b bulk_load.Batch) error {
+	batch := b.(binBatch)
+	c := NewCopyFromPoint(batch)
+	rows, err := conn.CopyFrom(pgx.Identifier{batch[0].MeasurementName}, batch[0].Columns, c)
+	if err != nil {
+		return fmt.Errorf("writing batch of '%s' of size %d in position %d: %s", batch[0].MeasurementName, len(batch), c.Position(), err.Error())
 	}
-	workersGroup.Done()
-	return total
+	if rows != len(batch) {
+		log.Printf("Problem writing batch of '%s': Written only %d rows of %d", batch[0].MeasurementName, rows, len(batch))
+	}
+	return nil
 }
 
 const createDatabaseSql = "create database " + DatabaseName + ";"
@@ -626,6 +334,18 @@ var IotCreateTableSql = []string{
 	"CREATE TABLE window_state_room (time bigint not null,room_id TEXT,sensor_id TEXT,window_id TEXT,home_id TEXT, state float8,battery_voltage float8 )",
 }
 
+var EventsCreateTableSql = []string{
+	"CREATE TABLE http_request (time bigint not null,service TEXT,instance TEXT,region TEXT,version TEXT,method TEXT,trace_id TEXT, path TEXT,user_agent TEXT,status bigint,latency_ms float8,response_bytes bigint )",
+	"CREATE TABLE app_log (time bigint not null,service TEXT,instance TEXT,region TEXT,version TEXT,level TEXT,trace_id TEXT, logger TEXT,message TEXT,error TEXT )",
+}
+
+var KubernetesCreateTableSql = []string{
+	"CREATE TABLE kube_node (time bigint not null,node_name TEXT,zone TEXT,instance_type TEXT, cpu_capacity_cores bigint,memory_capacity_bytes bigint,pods bigint,ready bigint,cpu_usage_cores float8,memory_usage_bytes bigint,network_rx_bytes bigint,network_tx_bytes bigint )",
+	"CREATE TABLE kube_pod (time bigint not null,node_name TEXT,zone TEXT,instance_type TEXT,namespace TEXT,deployment TEXT,pod_name TEXT, ready bigint,restarts_total bigint,containers bigint,cpu_requests_cores float8,memory_requests_bytes bigint )",
+	"CREATE TABLE container_cpu (time bigint not null,node_name TEXT,zone TEXT,instance_type TEXT,namespace TEXT,deployment TEXT,pod_name TEXT,container_name TEXT,image TEXT, usage_cores float8,usage_seconds_total float8,throttled_periods_total bigint,limit_cores float8 )",
+	"CREATE TABLE container_memory (time bigint not null,node_name TEXT,zone TEXT,instance_type TEXT,namespace TEXT,deployment TEXT,pod_name TEXT,container_name TEXT,image TEXT, usage_bytes bigint,working_set_bytes bigint,rss_bytes bigint,limit_bytes bigint )",
+}
+
 var devopsCreateHypertableSql = []string{
 	"select create_hypertable('cpu','time', chunk_time_interval => %d);",
 	"select create_hypertable('diskio','time', chunk_time_interval => %d);",
@@ -654,6 +374,18 @@ var iotCreateHypertableSql = []string{
 	"select create_hypertable('window_state_room','time', chunk_time_interval => %d);",
 }
 
+var eventsCreateHypertableSql = []string{
+	"select create_hypertable('http_request','time', chunk_time_interval => %d);",
+	"select create_hypertable('app_log','time', chunk_time_interval => %d);",
+}
+
+var kubernetesCreateHypertableSql = []string{
+	"select create_hypertable('kube_node','time', chunk_time_interval => %d);",
+	"select create_hypertable('kube_pod','time', chunk_time_interval => %d);",
+	"select create_hypertable('container_cpu','time', chunk_time_interval => %d);",
+	"select create_hypertable('container_memory','time', chunk_time_interval => %d);",
+}
+
 var devopsCreateIndexSql = []string{
 	"CREATE index cpu_hostname_index on cpu(hostname, time DESC);",
 	"CREATE index diskio_hostname_index on diskio(hostname, time DESC);",
@@ -682,6 +414,20 @@ var iotCreateIndexSql = []string{
 	"CREATE index window_state_room_home_index on window_state_room(home_id, time DESC);",
 }
 
+var eventsCreateIndexSql = []string{
+	"CREATE index http_request_instance_index on http_request(instance, time DESC);",
+	"CREATE index http_request_trace_index on http_request(trace_id);",
+	"CREATE index app_log_instance_index on app_log(instance, time DESC);",
+	"CREATE index app_log_trace_index on app_log(trace_id);",
+}
+
+var kubernetesCreateIndexSql = []string{
+	"CREATE index kube_node_node_index on kube_node(node_name, time DESC);",
+	"CREATE index kube_pod_namespace_index on kube_pod(namespace, time DESC);",
+	"CREATE index container_cpu_deployment_index on container_cpu(namespace, deployment, time DESC);",
+	"CREATE index container_memory_namespace_index on container_memory(namespace, time DESC);",
+}
+
 func createDatabase(daemon_url string) {
 	hostPort := strings.Split(daemon_url, ":")
 	port, _ := strconv.Atoi(hostPort[1])
@@ -728,6 +474,18 @@ func createDatabase(daemon_url string) {
 			log.Fatal(err)
 		}
 	}
+	for _, sql := range EventsCreateTableSql {
+		_, err = conn.Exec(sql)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, sql := range KubernetesCreateTableSql {
+		_, err = conn.Exec(sql)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 	for _, sql := range devopsCreateIndexSql {
 		_, err = conn.Exec(sql)
 		if err != nil {
@@ -740,6 +498,18 @@ func createDatabase(daemon_url string) {
 			log.Fatal(err)
 		}
 	}
+	for _, sql := range eventsCreateIndexSql {
+		_, err = conn.Exec(sql)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, sql := range kubernetesCreateIndexSql {
+		_, err = conn.Exec(sql)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 	for _, sql := range devopsCreateHypertableSql {
 		_, err = conn.Exec(fmt.Sprintf(sql, chunkDuration.Nanoseconds()))
 		if err != nil {
@@ -752,5 +522,17 @@ func createDatabase(daemon_url string) {
 			log.Fatal(err)
 		}
 	}
+	for _, sql := range eventsCreateHypertableSql {
+		_, err = conn.Exec(fmt.Sprintf(sql, chunkDuration.Nanoseconds()))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, sql := range kubernetesCreateHypertableSql {
+		_, err = conn.Exec(fmt.Sprintf(sql, chunkDuration.Nanoseconds()))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
 }
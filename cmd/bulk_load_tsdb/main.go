@@ -4,39 +4,29 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"github.com/influxdata/influxdb-comparisons/bulk_load"
 	"github.com/pkg/errors"
 	tsdbConfig "github.com/v3io/v3io-tsdb/pkg/config"
 	"github.com/v3io/v3io-tsdb/pkg/tsdb"
 	"github.com/v3io/v3io-tsdb/pkg/tsdb/schema"
 	tsdbUtils "github.com/v3io/v3io-tsdb/pkg/utils"
 	"log"
-	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 )
 
 // Program option vars:
 var (
-	itemLimit      int64
-	workers        int
-	printInterval  int64
 	serverPath     string
 	dbPath         string
 	configFilePath string
+	loadRunner     bulk_load.LoadRunner
 )
 
 // Global vars
 var (
-	inputDone    chan struct{}
-	workersGroup sync.WaitGroup
-
-	tsdbAdapter       *tsdb.V3ioAdapter
-	tsdbAppender      tsdb.Appender
-	batchTSDBChan     chan *TSDBDataPoint
-	batchTSDBChannels []chan *TSDBDataPoint
-	v3ioConf          *tsdbConfig.V3ioConfig
+	v3ioConf *tsdbConfig.V3ioConfig
 )
 
 type TSDBDataPoint struct {
@@ -47,15 +37,18 @@ type TSDBDataPoint struct {
 }
 
 func init() {
-	flag.IntVar(&workers, "workers", 1, "Number of parallel requests to make.")
-	flag.Int64Var(&itemLimit, "item-limit", -1, "Number of parallel requests to make.")
-	flag.Int64Var(&printInterval, "print-interval", 1000000, "Number of records after which to print a log")
+	loadRunner.RegisterFlags(100)
 	flag.StringVar(&serverPath, "server", "", "V3IO Service URL - username:password@ip:port/container")
 	flag.StringVar(&dbPath, "table-path", "", "sub path for the TSDB, inside the container")
 	flag.StringVar(&configFilePath, "config", "", "path to yaml config file")
+	flag.Var(printInterval{&loadRunner.ProgressInterval}, "print-interval", "Deprecated alias of -progress-interval (a number of records, as it used to be, is ignored).")
 
 	flag.Parse()
 
+	if err := loadRunner.Validate("tsdb"); err != nil {
+		log.Fatal(err)
+	}
+
 	var err error
 	v3ioConf, err = tsdbConfig.GetOrLoadFromFile(configFilePath)
 	if err != nil {
@@ -89,125 +82,166 @@ func init() {
 	}
 
 	fmt.Println("v3io conf", v3ioConf)
-	batchTSDBChan = make(chan *TSDBDataPoint, workers)
-	batchTSDBChannels = make([]chan *TSDBDataPoint, workers)
-	for i := 0; i < workers; i++ {
-		batchTSDBChannels[i] = make(chan *TSDBDataPoint, 1)
-	}
 }
 
-func main() {
-	//// Measure performance
-	//metricReporter, _ := performance.DefaultReporterInstance()
-	//metricReporter.Start()
-	//defer metricReporter.Stop()
-
-	createTSDB()
+// printInterval is the deprecated -print-interval flag, formerly the number of
+// records between progress logs. It is kept for the scripts using it: a
+// duration sets the progress interval, a number of records is ignored.
+type printInterval struct {
+	progress *time.Duration
+}
 
-	tsdbAdapter, err := tsdb.NewV3ioAdapter(v3ioConf, nil, nil)
-	if err != nil {
-		log.Fatalf("failed to create TSDB adapter: %v", err)
+func (p printInterval) String() string {
+	if p.progress == nil {
+		return ""
 	}
+	return p.progress.String()
+}
 
-	tsdbAppender, err = tsdbAdapter.Appender()
+func (p printInterval) Set(s string) error {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		log.Printf("-print-interval is deprecated and its number of records is ignored, use -progress-interval")
+		return nil
+	}
+	d, err := time.ParseDuration(s)
 	if err != nil {
-		log.Fatalf("failed to create tsdb appender: %v", err)
+		return err
 	}
+	log.Printf("-print-interval is deprecated, use -progress-interval")
+	*p.progress = d
+	return nil
+}
 
-	for i := 0; i < workers; i++ {
-		workersGroup.Add(1)
-		go processRecords(i)
-	}
+func main() {
+	//// Measure performance
+	//metricReporter, _ := performance.DefaultReporterInstance()
+	//metricReporter.Start()
+	//defer metricReporter.Stop()
 
-	start := time.Now()
-	itemsRead, bytesRead := scan()
-	close(batchTSDBChan)
-	for i := 0; i < workers; i++ {
-		close(batchTSDBChannels[i])
+	if loadRunner.DoLoad && !loadRunner.Resuming() {
+		createTSDB()
 	}
-	workersGroup.Wait()
 
-	end := time.Now()
-	took := end.Sub(start)
-	itemsRate := float64(itemsRead) / float64(took.Seconds())
-	bytesRate := float64(bytesRead) / float64(took.Seconds())
-
-	log.Printf("loaded %d items in %fsec with %d workers (mean point rate %f items/sec, %.2fMB/sec from stdin)\n", itemsRead, took.Seconds(), workers, itemsRate, bytesRate/(1<<20))
+	loadRunner.Run(&bulk_load.Loader{
+		DBType:         "TSDB",
+		DestinationUrl: v3ioConf.WebApiEndpoint,
+		// 1 item = 1 line = 1 value
+		ItemsAreValues: true,
+		NewDecoder: func(batchSize int) bulk_load.BatchDecoder {
+			return &decoder{
+				batchSize: batchSize,
+				batches:   make([]*batch, loadRunner.Workers),
+			}
+		},
+		NewProcessor: newProcessor,
+	})
 }
 
-// scan reads one item at a time from stdin. 1 item = 1 line.
-func scan() (int64, int64) {
-	var itemsRead, bytesRead int64
+// batch is a batch of data points, all the points of a series being written
+// by the same worker.
+type batch struct {
+	worker int
+	points []*TSDBDataPoint
+}
 
-	scanner := bufio.NewScanner(bufio.NewReaderSize(os.Stdin, 4*1024*1024))
+func (b *batch) Len() int {
+	return len(b.points)
+}
 
-	for scanner.Scan() {
-		if itemsRead == itemLimit {
-			break
-		}
+func (b *batch) Worker() int {
+	return b.worker
+}
 
-		line := scanner.Text()
+// decoder reads one item per line, into the batch of the worker of its
+// series.
+type decoder struct {
+	batchSize int
+	batches   []*batch
+	line      []byte
+}
 
-		data, err := stringToTSDBData(line)
-		if err != nil {
-			log.Fatal(err)
-		}
-		//		batchTSDBChan <- data
-		_, _, hash := data.Labels.GetKey()
-		batchTSDBChannels[hash%uint64(workers)] <- data
-
-		itemsRead++
-		bytesRead += int64(len(scanner.Bytes()))
-		if itemsRead%printInterval == 0 {
-			log.Printf("read %v events\n", itemsRead)
-		}
+func (d *decoder) Decode(r *bufio.Reader, _ *bulk_load.DatasetSize) (int, bulk_load.Batch, error) {
+	var n int
+	var err error
+	d.line, n, err = bulk_load.ReadLine(r, d.line[:0])
+	if err != nil {
+		return n, nil, err
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error reading input: %s", err.Error())
+	data, err := stringToTSDBData(string(d.line))
+	if err != nil {
+		return n, nil, err
 	}
+	_, _, hash := data.Labels.GetKey()
+	worker := int(hash % uint64(len(d.batches)))
+	b := d.batches[worker]
+	if b == nil {
+		b = &batch{worker: worker, points: make([]*TSDBDataPoint, 0, d.batchSize)}
+		d.batches[worker] = b
+	}
+	b.points = append(b.points, data)
+	if len(b.points) < d.batchSize {
+		return n, nil, nil
+	}
+	d.batches[worker] = nil
+	return n, b, nil
+}
 
-	//Closing inputDone signals to the application that we've read everything and can now shut down.
-	//close(inputDone)
-
-	return itemsRead, bytesRead
+func (d *decoder) Flush() bulk_load.Batch {
+	for i, b := range d.batches {
+		if b != nil {
+			d.batches[i] = nil
+			return b
+		}
+	}
+	return nil
 }
 
-func processRecords(number int) {
-	var total int64
-	var events int
+// processor adds the data points of a worker with an appender of its own.
+type processor struct {
+	tsdbAppender tsdb.Appender
+	total        int64
+	events       int
+}
 
+func newProcessor(worker int) (bulk_load.Processor, error) {
 	tsdbAdapter, err := tsdb.NewV3ioAdapter(v3ioConf, nil, nil)
 	if err != nil {
-		log.Fatalf("failed to create TSDB adapter: %v", err)
+		return nil, fmt.Errorf("failed to create TSDB adapter: %v", err)
 	}
 
 	tsdbAppender, err := tsdbAdapter.Appender()
 	if err != nil {
-		log.Fatalf("failed to create tsdb appender: %v", err)
+		return nil, fmt.Errorf("failed to create tsdb appender: %v", err)
 	}
+	return &processor{tsdbAppender: tsdbAppender}, nil
+}
 
-	for data := range batchTSDBChannels[number] {
-		events++
+func (p *processor) ProcessBatch(b bulk_load.Batch) error {
+	for _, data := range b.(*batch).points {
+		p.events++
 		start := time.Now().UnixNano()
-		//log.Printf("labels: %v", data.Labels)
-		//log.Printf("time: %v", data.Timestamp)
-		//log.Printf("value: %v", data.Value)
-		//log.Printf("metric name: %v", data.MetricsName)
-		//name, key, hash:=data.Labels.GetKey()
-		//log.Printf("name: %v, key: %v, hash: %v", name, key, hash)
-		_, err := tsdbAppender.Add(data.Labels, data.Timestamp, data.Value)
+		_, err := p.tsdbAppender.Add(data.Labels, data.Timestamp, data.Value)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
-		total += time.Now().UnixNano() - start
-		//log.Printf("got ref: %v", ref)
+		p.total += time.Now().UnixNano() - start
 	}
+	// The appender writes asynchronously, and a batch is recorded in the
+	// checkpoints as written once processed: wait for its points, within the
+	// timeout of the configuration (-1).
+	if loadRunner.CheckpointFile != "" {
+		if _, err := p.tsdbAppender.WaitForCompletion(-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	tsdbAppender.WaitForCompletion(time.Duration(5))
-	log.Println("Process time: ", total, "number of events:", events)
-	workersGroup.Done()
+func (p *processor) Close() {
+	p.tsdbAppender.WaitForCompletion(time.Duration(5))
+	log.Println("Process time: ", p.total, "number of events:", p.events)
 }
 
 func stringToTSDBData(data string) (*TSDBDataPoint, error) {
@@ -8,6 +8,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/common"
+	"github.com/influxdata/influxdb-comparisons/bulk_data_gen/devops"
 	bulkQueryGen "github.com/influxdata/influxdb-comparisons/bulk_query_gen"
 	"github.com/influxdata/influxdb-comparisons/bulk_query_gen/cassandra"
 	"github.com/influxdata/influxdb-comparisons/bulk_query_gen/elasticsearch"
@@ -51,6 +52,12 @@ const (
 	DashboardRedisMemoryUtilization = "redis-memory-utilization"
 	DashboardSystemLoad             = "system-load"
 	DashboardThroughput             = "throughput"
+	Kubernetes                      = "kubernetes"
+	KubernetesAll                   = "kubernetes-all"
+	KubernetesOneNodeOneHour        = "1-node-1-hr"
+	KubernetesDeploymentCpu         = "deployment-cpu"
+	KubernetesNamespaceMemory       = "namespace-memory"
+	KubernetesPodRestarts           = "pod-restarts"
 )
 
 // query generator choices {use-case, query-type, format}
@@ -132,6 +139,33 @@ var useCaseMatrix = map[string]map[string]map[string]bulkQueryGen.QueryGenerator
 		DashboardSystemLoad:             {"influx-http": influxdb.NewInfluxQLDashboardSystemLoad},
 		DashboardThroughput:             {"influx-http": influxdb.NewInfluxQLDashboardThroughput},
 	},
+	Kubernetes: {
+		KubernetesAll: {
+			"influx-flux-http": influxdb.NewFluxKubernetesAll,
+			"influx-http":      influxdb.NewInfluxQLKubernetesAll,
+			"timescaledb":      timescaledb.NewTimescaleKubernetesAll,
+		},
+		KubernetesOneNodeOneHour: {
+			"influx-flux-http": influxdb.NewFluxKubernetesOneNodeOneHour,
+			"influx-http":      influxdb.NewInfluxQLKubernetesOneNodeOneHour,
+			"timescaledb":      timescaledb.NewTimescaleKubernetesOneNodeOneHour,
+		},
+		KubernetesDeploymentCpu: {
+			"influx-flux-http": influxdb.NewFluxKubernetesDeploymentCpu,
+			"influx-http":      influxdb.NewInfluxQLKubernetesDeploymentCpu,
+			"timescaledb":      timescaledb.NewTimescaleKubernetesDeploymentCpu,
+		},
+		KubernetesNamespaceMemory: {
+			"influx-flux-http": influxdb.NewFluxKubernetesNamespaceMemory,
+			"influx-http":      influxdb.NewInfluxQLKubernetesNamespaceMemory,
+			"timescaledb":      timescaledb.NewTimescaleKubernetesNamespaceMemory,
+		},
+		KubernetesPodRestarts: {
+			"influx-flux-http": influxdb.NewFluxKubernetesPodRestarts,
+			"influx-http":      influxdb.NewInfluxQLKubernetesPodRestarts,
+			"timescaledb":      timescaledb.NewTimescaleKubernetesPodRestarts,
+		},
+	},
 }
 
 // Program option vars:
@@ -140,8 +174,9 @@ var (
 	queryType string
 	format    string
 
-	scaleVar   int
-	queryCount int
+	scaleVar       int
+	scaleVarOffset int64
+	queryCount     int
 
 	dbName string // TODO(rw): make this a map[string]string -> DatabaseConfig
 
@@ -153,6 +188,11 @@ var (
 	queryInterval   time.Duration
 	timeWindowShift time.Duration
 
+	churnRate   float64
+	churnPeriod time.Duration
+
+	manifestFile string
+
 	seed  int64
 	debug int
 
@@ -183,6 +223,7 @@ func init() {
 	flag.StringVar(&queryType, "query-type", "", "Query type. (Choices are in the use case matrix.)")
 
 	flag.IntVar(&scaleVar, "scale-var", 1, "Scaling variable (must be the equal to the scalevar used for data generation).")
+	flag.Int64Var(&scaleVarOffset, "scale-var-offset", 0, "Scaling variable offset (must be equal to the offset used for data generation, which the manifest gives).")
 	flag.IntVar(&queryCount, "queries", 1000, "Number of queries to generate.")
 	flag.StringVar(&dbName, "db", "benchmark_db", "Database for influx to use (ignored for ElasticSearch).")
 
@@ -191,6 +232,11 @@ func init() {
 	flag.DurationVar(&queryInterval, "query-interval", bulkQueryGen.DefaultQueryInterval, "Time interval query should ask for.")
 	flag.DurationVar(&timeWindowShift, "time-window-shift", -1, "Sliding time window shift. (When set to > 0s, queries option is ignored - number of queries is calculated.")
 
+	flag.Float64Var(&churnRate, "churn-rate", 0, "Host churn rate of the devops data set (must be equal to the churn rate used for data generation, which the manifest gives). Without manifest, -timestamp-start must be the start of the data generation.")
+	flag.DurationVar(&churnPeriod, "churn-period", devops.DefaultChurnPeriod, "Host churn period of the devops data set (must be equal to the churn period used for data generation, which the manifest gives).")
+
+	flag.StringVar(&manifestFile, "manifest", "", "Manifest of the dataset written by bulk_data_gen, to check the use case, scale var and time range against, and to take the scale var offset and host churn from (optional).")
+
 	flag.Int64Var(&seed, "seed", 0, "PRNG seed (default, or 0, uses the current timestamp).")
 	flag.IntVar(&debug, "debug", 0, "Debug printing (choices: 0, 1) (default 0).")
 
@@ -238,6 +284,45 @@ func init() {
 	}
 	timestampEnd = timestampEnd.UTC()
 
+	// the churn periods count from the start of the data generation, which
+	// is that of the queries unless a manifest gives it:
+	datasetStart := timestampStart
+	if manifestFile != "" {
+		manifest, err := common.ReadManifest(manifestFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := manifest.CheckQueries(useCase, int64(scaleVar), timestampStart, timestampEnd); err != nil {
+			log.Fatal(err)
+		}
+		// the flags not set default to the settings of the dataset:
+		offsetSet, churnSet := false, false
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "scale-var-offset":
+				offsetSet = true
+			case "churn-rate", "churn-period":
+				churnSet = true
+			}
+		})
+		if !offsetSet {
+			scaleVarOffset = manifest.ScaleVarOffset
+		} else if scaleVarOffset != manifest.ScaleVarOffset {
+			log.Fatalf("scale var offset %d does not match the offset of the dataset, %d", scaleVarOffset, manifest.ScaleVarOffset)
+		}
+		if !churnSet {
+			churnRate = manifest.ChurnRate
+			if churnRate != 0 {
+				churnPeriod = time.Duration(manifest.ChurnPeriod)
+			}
+		} else if err := manifest.CheckChurn(churnRate, churnPeriod); err != nil {
+			log.Fatal(err)
+		}
+		datasetStart = manifest.Start
+	}
+	bulkQueryGen.DevopsHostOffset = scaleVarOffset     // global
+	bulkQueryGen.KubernetesNodeOffset = scaleVarOffset // global
+
 	duration := timestampEnd.Sub(timestampStart)
 
 	if duration.Nanoseconds() < 0 {
@@ -265,6 +350,23 @@ func init() {
 		log.Printf("%v queries will be generated to cover time interval using %v shift", queryCount, timeWindowShift)
 	}
 
+	if churnRate != 0 {
+		if useCase != DevOps {
+			log.Fatal("host churn is only supported by the devops use case")
+		}
+		churn := &devops.HostChurn{
+			Start:      datasetStart,
+			HostCount:  int64(scaleVar),
+			HostOffset: scaleVarOffset,
+			Rate:       churnRate,
+			Period:     churnPeriod,
+		}
+		if err := churn.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		bulkQueryGen.DevopsHostChurn = churn // global
+	}
+
 	// the default seed is the current timestamp:
 	if seed == 0 {
 		seed = int64(time.Now().Nanosecond())
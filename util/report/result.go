@@ -29,6 +29,15 @@ type LoadReportParams struct {
 	BatchSize int
 }
 
+// LoadLatency holds percentiles of the batch write latency of a bulk load
+type LoadLatency struct {
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	P999 time.Duration
+	Max  time.Duration
+}
+
 // type QueryReportParams is holder of bulk query specific parameters
 type QueryReportParams struct {
 	ReportParams
@@ -36,8 +45,9 @@ type QueryReportParams struct {
 	BurnIn int64
 }
 
-// ReportLoadResult send results from bulk load to an influxdb according to the given parameters
-func ReportLoadResult(params *LoadReportParams, totalItems int64, valueRate float64, inputSpeed float64, loadDuration time.Duration) error {
+// ReportLoadResult send results from bulk load to an influxdb according to the given parameters.
+// The latency is only reported if not nil.
+func ReportLoadResult(params *LoadReportParams, totalItems int64, valueRate float64, inputSpeed float64, loadDuration time.Duration, latency *LoadLatency) error {
 
 	c, p, err := initReport(&params.ReportParams, "load_benchmarks")
 	if err != nil {
@@ -51,6 +61,13 @@ func ReportLoadResult(params *LoadReportParams, totalItems int64, valueRate floa
 	p.AddFloat64Field("values_rate", valueRate)
 	p.AddFloat64Field("input_rate", inputSpeed)
 	p.AddFloat64Field("duration", loadDuration.Seconds())
+	if latency != nil {
+		p.AddFloat64Field("latency_p50_ms", durationMillis(latency.P50))
+		p.AddFloat64Field("latency_p90_ms", durationMillis(latency.P90))
+		p.AddFloat64Field("latency_p99_ms", durationMillis(latency.P99))
+		p.AddFloat64Field("latency_p999_ms", durationMillis(latency.P999))
+		p.AddFloat64Field("latency_max_ms", durationMillis(latency.Max))
+	}
 
 	err = finishReport(c, p)
 
@@ -58,6 +75,10 @@ func ReportLoadResult(params *LoadReportParams, totalItems int64, valueRate floa
 
 }
 
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
 // initReport prepares a Point and a Collector instance for sending a result report
 func initReport(params *ReportParams, measurement string) (*Collector, *Point, error) {
 	var authString string